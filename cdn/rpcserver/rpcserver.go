@@ -103,16 +103,9 @@ func (css *Server) ObtainSeeds(ctx context.Context, req *cdnsystem.SeedRequest,
 	}
 	for piece := range pieceChan {
 		pieceSeed := &cdnsystem.PieceSeed{
-			PeerId:   peerID,
-			HostUuid: hostID,
-			PieceInfo: &base.PieceInfo{
-				PieceNum:    int32(piece.PieceNum),
-				RangeStart:  piece.PieceRange.StartIndex,
-				RangeSize:   piece.PieceLen,
-				PieceMd5:    piece.PieceMd5,
-				PieceOffset: piece.OriginRange.StartIndex,
-				PieceStyle:  piece.PieceStyle,
-			},
+			PeerId:          peerID,
+			HostUuid:        hostID,
+			PieceInfo:       convertPieceInfo(piece),
 			Done:            false,
 			ContentLength:   registeredTask.SourceFileLength,
 			TotalPieceCount: registeredTask.TotalPieceCount,
@@ -204,15 +197,7 @@ func (css *Server) GetPieceTasks(ctx context.Context, req *base.PieceTaskRequest
 	var count uint32 = 0
 	for _, piece := range pieces {
 		if piece.PieceNum >= req.StartNum && (count < req.Limit || req.Limit <= 0) {
-			p := &base.PieceInfo{
-				PieceNum:    int32(piece.PieceNum),
-				RangeStart:  piece.PieceRange.StartIndex,
-				RangeSize:   piece.PieceLen,
-				PieceMd5:    piece.PieceMd5,
-				PieceOffset: piece.OriginRange.StartIndex,
-				PieceStyle:  piece.PieceStyle,
-			}
-			pieceInfos = append(pieceInfos, p)
+			pieceInfos = append(pieceInfos, convertPieceInfo(piece))
 			count++
 		}
 	}
@@ -226,13 +211,14 @@ func (css *Server) GetPieceTasks(ctx context.Context, req *base.PieceTaskRequest
 		pieceMd5Sign = digestutils.Sha256(pieceMd5s...)
 	}
 	pp := &base.PiecePacket{
-		TaskId:        req.TaskId,
-		DstPid:        req.DstPid,
-		DstAddr:       fmt.Sprintf("%s:%d", css.config.AdvertiseIP, css.config.DownloadPort),
-		PieceInfos:    pieceInfos,
-		TotalPiece:    seedTask.TotalPieceCount,
-		ContentLength: seedTask.SourceFileLength,
-		PieceMd5Sign:  pieceMd5Sign,
+		TaskId:          req.TaskId,
+		DstPid:          req.DstPid,
+		DstAddr:         fmt.Sprintf("%s:%d", css.config.AdvertiseIP, css.config.DownloadPort),
+		PieceInfos:      pieceInfos,
+		TotalPiece:      seedTask.TotalPieceCount,
+		ContentLength:   seedTask.SourceFileLength,
+		PieceMd5Sign:    pieceMd5Sign,
+		PieceDigestSign: getPieceDigestSign(seedTask),
 	}
 	span.SetAttributes(constants.AttributePiecePacketResult.String(pp.String()))
 	return pp, nil
@@ -272,3 +258,37 @@ func (css *Server) Shutdown() error {
 func (css *Server) GetConfig() Config {
 	return css.config
 }
+
+// convertPieceInfo converts cdn piece to base.PieceInfo, sha256 digest is set when it is calculated
+func convertPieceInfo(piece *task.PieceInfo) *base.PieceInfo {
+	pieceInfo := &base.PieceInfo{
+		PieceNum:    int32(piece.PieceNum),
+		RangeStart:  piece.PieceRange.StartIndex,
+		RangeSize:   piece.PieceLen,
+		PieceMd5:    piece.PieceMd5,
+		PieceOffset: piece.OriginRange.StartIndex,
+		PieceStyle:  piece.PieceStyle,
+	}
+	if piece.PieceSha256 != "" {
+		pieceInfo.DigestAlgorithm = base.DigestAlgorithm_SHA256
+		pieceInfo.PieceDigest = piece.PieceSha256
+	}
+	return pieceInfo
+}
+
+// getPieceDigestSign returns sha256 of all piece sha256 digests,
+// it is empty when not all pieces are ready or any piece has no sha256 digest
+func getPieceDigestSign(seedTask *task.SeedTask) string {
+	if len(seedTask.Pieces) != int(seedTask.TotalPieceCount) {
+		return ""
+	}
+	var pieceDigests []string
+	for i := 0; i < len(seedTask.Pieces); i++ {
+		piece, ok := seedTask.Pieces[uint32(i)]
+		if !ok || piece.PieceSha256 == "" {
+			return ""
+		}
+		pieceDigests = append(pieceDigests, piece.PieceSha256)
+	}
+	return digestutils.Sha256(pieceDigests...)
+}
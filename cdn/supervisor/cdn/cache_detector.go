@@ -19,6 +19,7 @@ package cdn
 import (
 	"context"
 	"crypto/md5"
+	"crypto/sha256"
 	"fmt"
 	"hash"
 	"io"
@@ -267,12 +268,19 @@ func checkMetadata(seedTask *task.SeedTask, metadata *storage.FileMetadata) (boo
 func checkPieceContent(reader io.Reader, pieceRecord *storage.PieceMetaRecord, fileDigest hash.Hash) error {
 	// TODO Analyze the original data for the slice format to calculate fileMd5
 	pieceMd5 := md5.New()
-	tee := io.TeeReader(io.TeeReader(io.LimitReader(reader, int64(pieceRecord.PieceLen)), pieceMd5), fileDigest)
+	pieceSha256 := sha256.New()
+	tee := io.TeeReader(io.TeeReader(io.LimitReader(reader, int64(pieceRecord.PieceLen)), io.MultiWriter(pieceMd5, pieceSha256)), fileDigest)
 	if n, err := io.Copy(io.Discard, tee); n != int64(pieceRecord.PieceLen) || err != nil {
 		return errors.Wrap(err, "read piece content")
 	}
+	// check piece content with sha256 first, the records written by old versions only have md5
+	if pieceRecord.Sha256 != "" {
+		if realPieceSha256 := digestutils.ToHashString(pieceSha256); realPieceSha256 != pieceRecord.Sha256 {
+			return errors.Errorf("piece sha256 is inconsistent, expected is %s, but got %s", pieceRecord.Sha256, realPieceSha256)
+		}
+		return nil
+	}
 	realPieceMd5 := digestutils.ToHashString(pieceMd5)
-	// check piece content
 	if realPieceMd5 != pieceRecord.Md5 {
 		return errors.Errorf("piece md5 sign is inconsistent, expected is %s, but got %s", pieceRecord.Md5, realPieceMd5)
 	}
@@ -80,5 +80,6 @@ func convertPieceMeta2SeedPiece(record *storage.PieceMetaRecord) *task.PieceInfo
 		PieceRange:  record.Range,
 		OriginRange: record.OriginRange,
 		PieceLen:    record.PieceLen,
+		PieceSha256: record.Sha256,
 	}
 }
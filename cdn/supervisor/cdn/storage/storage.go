@@ -111,13 +111,19 @@ type PieceMetaRecord struct {
 	OriginRange *rangeutils.Range `json:"originRange"`
 	// 0: PlainUnspecified
 	PieceStyle int32 `json:"pieceStyle"`
+	// sha256 of transported piece content, it is empty in the records written by old versions
+	Sha256 string `json:"sha256,omitempty"`
 }
 
 const fieldSeparator = ":"
 
 func (record PieceMetaRecord) String() string {
-	return fmt.Sprint(record.PieceNum, fieldSeparator, record.PieceLen, fieldSeparator, record.Md5, fieldSeparator, record.Range, fieldSeparator,
+	value := fmt.Sprint(record.PieceNum, fieldSeparator, record.PieceLen, fieldSeparator, record.Md5, fieldSeparator, record.Range, fieldSeparator,
 		record.OriginRange, fieldSeparator, record.PieceStyle)
+	if record.Sha256 != "" {
+		value = fmt.Sprint(value, fieldSeparator, record.Sha256)
+	}
+	return value
 }
 
 func ParsePieceMetaRecord(value string) (record *PieceMetaRecord, err error) {
@@ -148,6 +154,11 @@ func ParsePieceMetaRecord(value string) (record *PieceMetaRecord, err error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "invalid pieceStyle: %s", fields[5])
 	}
+	// sha256 is optional for the records written by old versions
+	var sha256 string
+	if len(fields) > 6 {
+		sha256 = fields[6]
+	}
 	return &PieceMetaRecord{
 		PieceNum:    uint32(pieceNum),
 		PieceLen:    uint32(pieceLen),
@@ -155,6 +166,7 @@ func ParsePieceMetaRecord(value string) (record *PieceMetaRecord, err error) {
 		Range:       pieceRange,
 		OriginRange: originRange,
 		PieceStyle:  int32(pieceStyle),
+		Sha256:      sha256,
 	}, nil
 }
 
@@ -95,6 +95,7 @@ type PieceInfo struct {
 	OriginRange *rangeutils.Range `json:"origin_range"`
 	PieceLen    uint32            `json:"piece_len"`
 	PieceStyle  base.PieceStyle   `json:"piece_style"`
+	PieceSha256 string            `json:"piece_sha256,omitempty"`
 }
 
 const (
@@ -77,3 +77,16 @@ const (
 	SimpleLocalTaskStoreStrategy  = StoreStrategy("io.d7y.storage.v2.simple")
 	AdvanceLocalTaskStoreStrategy = StoreStrategy("io.d7y.storage.v2.advance")
 )
+
+const (
+	LRUEvictionPolicy  = EvictionPolicy("lru")
+	LFUEvictionPolicy  = EvictionPolicy("lfu")
+	GDSFEvictionPolicy = EvictionPolicy("gdsf")
+	ARCEvictionPolicy  = EvictionPolicy("arc")
+)
+
+const (
+	SizePlacementPolicy       = PlacementPolicy("size")
+	FreeSpacePlacementPolicy  = PlacementPolicy("free")
+	RoundRobinPlacementPolicy = PlacementPolicy("round-robin")
+)
@@ -26,6 +26,7 @@ const (
 	AttributeTargetPeerID      = attribute.Key("d7y.peer.target.id")
 	AttributeReusePeerID       = attribute.Key("d7y.peer.reuse.id")
 	AttributeReuseRange        = attribute.Key("d7y.peer.reuse.range")
+	AttributeResumedPieceCount = attribute.Key("d7y.peer.resume.piece.count")
 	AttributeTargetPeerAddr    = attribute.Key("d7y.peer.target.addr")
 	AttributeMainPeer          = attribute.Key("d7y.peer.task.main_peer")
 	AttributePeerPacketCode    = attribute.Key("d7y.peer.packet.code")
@@ -91,6 +91,9 @@ type ClientOption struct {
 	// DisableBackSource indicates whether to not back source to download when p2p fails.
 	DisableBackSource bool `yaml:"disableBackSource,omitempty" mapstructure:"disableBackSource,omitempty"`
 
+	// Resume indicates whether to reuse the verified pieces in the existing output file.
+	Resume bool `yaml:"resume,omitempty" mapstructure:"resume,omitempty"`
+
 	// Insecure indicates whether skip secure verify when supernode interact with the source.
 	Insecure bool `yaml:"insecure,omitempty" mapstructure:"insecure,omitempty"`
 
@@ -127,6 +130,16 @@ type ClientOption struct {
 	RecursiveAcceptRegex string `yaml:"acceptRegex,omitempty" mapstructure:"accept-regex,omitempty"`
 
 	RecursiveRejectRegex string `yaml:"rejectRegex,omitempty" mapstructure:"reject-regex,omitempty"`
+
+	// RecursiveDirectory indicates to download all resources as one directory task,
+	// files are downloaded into a staging directory and renamed to output when all of them succeed
+	RecursiveDirectory bool `yaml:"recursiveDirectory,omitempty" mapstructure:"directory,omitempty"`
+
+	// RecursiveConcurrency indicates the number of files downloaded in parallel in directory task
+	RecursiveConcurrency int `yaml:"recursiveConcurrency,omitempty" mapstructure:"concurrency,omitempty"`
+
+	// RecursiveReport indicates the path of json report of directory task, the report is printed when it's empty
+	RecursiveReport string `yaml:"recursiveReport,omitempty" mapstructure:"report,omitempty"`
 }
 
 func NewDfgetConfig() *ClientOption {
@@ -150,6 +163,10 @@ func (cfg *ClientOption) Validate() error {
 		return err
 	}
 
+	if cfg.RecursiveDirectory && cfg.RecursiveConcurrency <= 0 {
+		return errors.Wrapf(dferrors.ErrInvalidArgument, "concurrency: %d", cfg.RecursiveConcurrency)
+	}
+
 	if err := cfg.checkOutput(); err != nil {
 		return errors.Wrapf(dferrors.ErrInvalidArgument, "output: %v", err)
 	}
@@ -41,4 +41,6 @@ var dfgetConfig = ClientOption{
 	ShowProgress:      false,
 	Recursive:         false,
 	RecursiveLevel:    5,
+
+	RecursiveConcurrency: 4,
 }
@@ -22,6 +22,10 @@ const (
 	HeaderDragonflyTask   = "X-Dragonfly-Task"
 	HeaderDragonflyRange  = "X-Dragonfly-Range"
 	HeaderDragonflyBiz    = "X-Dragonfly-Biz"
+	// HeaderDragonflyPriority is the weight of upload requests when upload fair share is enabled
+	HeaderDragonflyPriority = "X-Dragonfly-Priority"
 	// HeaderDragonflyRegistry is used for dynamic registry mirrors
 	HeaderDragonflyRegistry = "X-Dragonfly-Registry"
+	// HeaderDragonflyTaskIDHeaders is the comma separated request headers whose values are part of the task id
+	HeaderDragonflyTaskIDHeaders = "X-Dragonfly-Task-ID-Headers"
 )
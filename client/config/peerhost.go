@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -64,6 +65,9 @@ type DaemonOption struct {
 	Upload       UploadOption    `mapstructure:"upload" yaml:"upload"`
 	Storage      StorageOption   `mapstructure:"storage" yaml:"storage"`
 	ConfigServer string          `mapstructure:"configServer" yaml:"configServer"`
+	SeedPeer     SeedPeerOption  `mapstructure:"seedPeer" yaml:"seedPeer"`
+	HostLoad     HostLoadOption  `mapstructure:"hostLoad" yaml:"hostLoad"`
+	Drain        DrainOption     `mapstructure:"drain" yaml:"drain"`
 }
 
 func NewDaemonConfig() *DaemonOption {
@@ -126,6 +130,49 @@ func (p *DaemonOption) Validate() error {
 		}
 	}
 
+	if p.SeedPeer.Enable {
+		if !p.Scheduler.Manager.Enable {
+			return errors.New("seed peer requires manager to be enabled")
+		}
+
+		if p.SeedPeer.KeepAlive.Interval <= 0 {
+			return errors.New("seed peer keepAlive interval is not specified")
+		}
+	}
+
+	if p.Scheduler.Announce.Enable && p.Scheduler.Announce.Interval <= 0 {
+		return errors.New("scheduler announce interval is not specified")
+	}
+
+	if p.HostLoad.Enable && p.HostLoad.Interval <= 0 {
+		return errors.New("host load interval is not specified")
+	}
+
+	if p.Proxy != nil {
+		for i, rule := range p.Proxy.Proxies {
+			if err := rule.Validate(); err != nil {
+				return errors.Wrapf(err, "invalid proxy rule %d", i+1)
+			}
+		}
+		if mirror := p.Proxy.RegistryMirror; mirror != nil && mirror.Policy != nil {
+			if err := mirror.Policy.Validate(); err != nil {
+				return errors.Wrap(err, "invalid registry mirror policy")
+			}
+		}
+	}
+
+	if p.Drain.Timeout < 0 {
+		return errors.New("drain timeout should not be negative")
+	}
+
+	if p.Drain.LeaveTimeout < 0 {
+		return errors.New("drain leave timeout should not be negative")
+	}
+
+	if p.Drain.QuietPeriod < 0 {
+		return errors.New("drain quiet period should not be negative")
+	}
+
 	return nil
 }
 
@@ -138,6 +185,17 @@ type SchedulerOption struct {
 	ScheduleTimeout clientutil.Duration `mapstructure:"scheduleTimeout" yaml:"scheduleTimeout"`
 	// DisableAutoBackSource indicates not back source normally, only scheduler says back source
 	DisableAutoBackSource bool `mapstructure:"disableAutoBackSource" yaml:"disableAutoBackSource"`
+	// Announce is the host announcement configuration with scheduler
+	Announce AnnounceOption `mapstructure:"announce" yaml:"announce"`
+}
+
+// AnnounceOption announces the host and its cached tasks to schedulers periodically,
+// so the idle host can be scheduled as a parent, the host leaves schedulers when dfdaemon stops
+type AnnounceOption struct {
+	// Enable indicates to announce the host to schedulers
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// Interval is the announcement interval, it works as the keepalive of the host
+	Interval time.Duration `mapstructure:"interval" yaml:"interval"`
 }
 
 type ManagerOption struct {
@@ -149,6 +207,42 @@ type ManagerOption struct {
 	RefreshInterval time.Duration `mapstructure:"refreshInterval" yaml:"refreshInterval"`
 }
 
+// SeedPeerOption runs dfdaemon as a seed peer, it registers to manager as a cdn,
+// downloads the tasks triggered by scheduler from source and serves pieces to other peers
+type SeedPeerOption struct {
+	// Enable indicates dfdaemon runs as a seed peer
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// ClusterID is the cdn cluster id in manager
+	ClusterID uint `mapstructure:"clusterID" yaml:"clusterID"`
+	// KeepAlive is the keepalive configuration with manager
+	KeepAlive KeepAliveOption `mapstructure:"keepAlive" yaml:"keepAlive"`
+}
+
+type KeepAliveOption struct {
+	// Interval is the keepalive interval
+	Interval time.Duration `mapstructure:"interval" yaml:"interval"`
+}
+
+// HostLoadOption samples the cpu, memory, disk and network utilization of host,
+// the load is reported to scheduler to avoid overloaded hosts as parents
+type HostLoadOption struct {
+	// Enable indicates to sample and report the host load
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// Interval is the sampling interval
+	Interval time.Duration `mapstructure:"interval" yaml:"interval"`
+}
+
+// DrainOption drains dfdaemon before maintenance, it's triggered by SIGUSR2 or "dfget drain",
+// new downloads are rejected, the peers leave schedulers and the in-flight uploads are waited before exiting
+type DrainOption struct {
+	// Timeout is the max duration to wait for the in-flight uploads
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// LeaveTimeout is the max duration to leave tasks from schedulers, it's not counted in Timeout
+	LeaveTimeout time.Duration `mapstructure:"leaveTimeout" yaml:"leaveTimeout"`
+	// QuietPeriod is the duration without any uploads before the uploads are considered finished
+	QuietPeriod time.Duration `mapstructure:"quietPeriod" yaml:"quietPeriod"`
+}
+
 type HostOption struct {
 	// SecurityDomain is the security domain
 	SecurityDomain string `mapstructure:"securityDomain" yaml:"securityDomain"`
@@ -176,6 +270,34 @@ type DownloadOption struct {
 	TransportOption      *TransportOption     `mapstructure:"transportOption" yaml:"transportOption"`
 	GetPiecesMaxRetry    int                  `mapstructure:"getPiecesMaxRetry" yaml:"getPiecesMaxRetry"`
 	Prefetch             bool                 `mapstructure:"prefetch" yaml:"prefetch"`
+	// RangeFromParentTask reads ranged requests from the peer task of the whole file,
+	// the pieces covering the range are downloaded first
+	RangeFromParentTask bool `mapstructure:"rangeFromParentTask" yaml:"rangeFromParentTask"`
+	// ConcurrentSource downloads pieces from source with concurrent ranged requests when the source supports range
+	ConcurrentSource *ConcurrentSourceOption `mapstructure:"concurrentSource" yaml:"concurrentSource"`
+	// AdaptiveConcurrency adapts the piece download concurrency and parent selection to the measured throughput and latency,
+	// the parallel count from scheduler is used when it's not set
+	AdaptiveConcurrency *AdaptiveConcurrencyOption `mapstructure:"adaptiveConcurrency" yaml:"adaptiveConcurrency"`
+}
+
+type ConcurrentSourceOption struct {
+	// GoroutineCount is the count of concurrent ranged requests for every task
+	GoroutineCount int `mapstructure:"goroutineCount" yaml:"goroutineCount"`
+	// ThresholdSize is the min content length to download concurrently, the smaller is downloaded in a single stream
+	ThresholdSize unit.Bytes `mapstructure:"thresholdSize" yaml:"thresholdSize"`
+}
+
+type AdaptiveConcurrencyOption struct {
+	// MinWorkers is the min concurrent piece downloads of a peer task
+	MinWorkers int `mapstructure:"minWorkers" yaml:"minWorkers"`
+	// MaxWorkers is the max concurrent piece downloads of a peer task
+	MaxWorkers int `mapstructure:"maxWorkers" yaml:"maxWorkers"`
+	// LatencyTolerance is the ratio of piece latency to the lowest latency of the same parent, beyond which the parent is treated as congested
+	LatencyTolerance float64 `mapstructure:"latencyTolerance" yaml:"latencyTolerance"`
+	// BlacklistTimeouts is the count of consecutive timeouts to blacklist a parent locally
+	BlacklistTimeouts int `mapstructure:"blacklistTimeouts" yaml:"blacklistTimeouts"`
+	// BlacklistDuration is the duration a blacklisted parent is not used
+	BlacklistDuration time.Duration `mapstructure:"blacklistDuration" yaml:"blacklistDuration"`
 }
 
 type TransportOption struct {
@@ -199,6 +321,18 @@ type ProxyOption struct {
 	Proxies         []*Proxy        `mapstructure:"proxies" yaml:"proxies"`
 	HijackHTTPS     *HijackConfig   `mapstructure:"hijackHTTPS" yaml:"hijackHTTPS"`
 	DumpHTTPContent bool            `mapstructure:"dumpHTTPContent" yaml:"dumpHTTPContent"`
+	// ManifestCache caches the registry manifests in memory when it's set
+	ManifestCache *ManifestCacheOption `mapstructure:"manifestCache" yaml:"manifestCache"`
+}
+
+// ManifestCacheOption caches the registry manifests requested by GET method and the headers requested by HEAD method
+// in proxy, the tag-addressed manifests expire after TTL and the digest-addressed manifests never expire,
+// the cached manifests are only served to the requests with the same credential
+type ManifestCacheOption struct {
+	// TTL is the expiration of tag-addressed manifests, the default is 30s
+	TTL clientutil.Duration `mapstructure:"ttl" yaml:"ttl"`
+	// MaxEntries is the max count of cached manifests, the least recently used ones are evicted, the default is 1024
+	MaxEntries int `mapstructure:"maxEntries" yaml:"maxEntries"`
 }
 
 func (p *ProxyOption) UnmarshalJSON(b []byte) error {
@@ -276,14 +410,15 @@ func (p *ProxyOption) UnmarshalYAML(node *yaml.Node) error {
 func (p *ProxyOption) unmarshal(unmarshal func(in []byte, out interface{}) (err error), b []byte) error {
 	pt := struct {
 		ListenOption    `mapstructure:",squash" yaml:",inline"`
-		BasicAuth       *BasicAuth      `mapstructure:"basicAuth" yaml:"basicAuth"`
-		DefaultFilter   string          `mapstructure:"defaultFilter" yaml:"defaultFilter"`
-		MaxConcurrency  int64           `mapstructure:"maxConcurrency" yaml:"maxConcurrency"`
-		RegistryMirror  *RegistryMirror `mapstructure:"registryMirror" yaml:"registryMirror"`
-		WhiteList       []*WhiteList    `mapstructure:"whiteList" yaml:"whiteList"`
-		Proxies         []*Proxy        `mapstructure:"proxies" yaml:"proxies"`
-		HijackHTTPS     *HijackConfig   `mapstructure:"hijackHTTPS" yaml:"hijackHTTPS"`
-		DumpHTTPContent bool            `mapstructure:"dumpHTTPContent" yaml:"dumpHTTPContent"`
+		BasicAuth       *BasicAuth           `mapstructure:"basicAuth" yaml:"basicAuth"`
+		DefaultFilter   string               `mapstructure:"defaultFilter" yaml:"defaultFilter"`
+		MaxConcurrency  int64                `mapstructure:"maxConcurrency" yaml:"maxConcurrency"`
+		RegistryMirror  *RegistryMirror      `mapstructure:"registryMirror" yaml:"registryMirror"`
+		WhiteList       []*WhiteList         `mapstructure:"whiteList" yaml:"whiteList"`
+		Proxies         []*Proxy             `mapstructure:"proxies" yaml:"proxies"`
+		HijackHTTPS     *HijackConfig        `mapstructure:"hijackHTTPS" yaml:"hijackHTTPS"`
+		DumpHTTPContent bool                 `mapstructure:"dumpHTTPContent" yaml:"dumpHTTPContent"`
+		ManifestCache   *ManifestCacheOption `mapstructure:"manifestCache" yaml:"manifestCache"`
 	}{}
 
 	if err := unmarshal(b, &pt); err != nil {
@@ -299,6 +434,7 @@ func (p *ProxyOption) unmarshal(unmarshal func(in []byte, out interface{}) (err
 	p.DefaultFilter = pt.DefaultFilter
 	p.BasicAuth = pt.BasicAuth
 	p.DumpHTTPContent = pt.DumpHTTPContent
+	p.ManifestCache = pt.ManifestCache
 
 	return nil
 }
@@ -306,6 +442,16 @@ func (p *ProxyOption) unmarshal(unmarshal func(in []byte, out interface{}) (err
 type UploadOption struct {
 	ListenOption `yaml:",inline" mapstructure:",squash"`
 	RateLimit    clientutil.RateLimit `mapstructure:"rateLimit" yaml:"rateLimit"`
+	// GRPC enables other peers to download pieces via DownloadPiece rpc of peer grpc server
+	GRPC bool `mapstructure:"grpc" yaml:"grpc"`
+	// FairShare shares the upload rate limit across concurrent children with weighted fair queuing when it's set
+	FairShare *FairShareOption `mapstructure:"fairShare" yaml:"fairShare"`
+}
+
+type FairShareOption struct {
+	// MaxWeight caps the weight of upload requests, children set the weight with X-Dragonfly-Priority header,
+	// the default weight is 1
+	MaxWeight int `mapstructure:"maxWeight" yaml:"maxWeight"`
 }
 
 type ListenOption struct {
@@ -438,10 +584,78 @@ type StorageOption struct {
 	// Multiplex indicates reusing underlying storage for same task id
 	Multiplex     bool          `mapstructure:"multiplex" yaml:"multiplex"`
 	StoreStrategy StoreStrategy `mapstructure:"strategy" yaml:"strategy"`
+	// EvictionPolicy indicates the order to reclaim tasks when disk gc threshold reached,
+	// supports lru, lfu, gdsf and arc, default is lru
+	EvictionPolicy EvictionPolicy `mapstructure:"evictionPolicy" yaml:"evictionPolicy"`
+	// PinRules indicates the tasks which are pinned when registered, pinned tasks are skipped by gc
+	PinRules []*PinRule `mapstructure:"pinRules" yaml:"pinRules"`
+	// DataPaths indicates multiple directories to store task data, DataPath is used when it's empty
+	DataPaths []*DataPathOption `mapstructure:"dataPaths" yaml:"dataPaths"`
+	// Placement indicates how to choose a data path for a new task, supports size, free and round-robin,
+	// default is free
+	Placement PlacementPolicy `mapstructure:"placement" yaml:"placement"`
+}
+
+// DataPathOption describes a directory to store task data, the zero thresholds fall back to the ones in StorageOption.
+type DataPathOption struct {
+	// Path is the directory to store task data
+	Path string `mapstructure:"path" yaml:"path"`
+	// DiskGCThreshold indicates the threshold to gc the oldest tasks in this data path
+	DiskGCThreshold unit.Bytes `mapstructure:"diskGCThreshold" yaml:"diskGCThreshold"`
+	// DiskGCThresholdPercent indicates the threshold to gc the oldest tasks according the usage of the disk
+	DiskGCThresholdPercent float64 `mapstructure:"diskGCThresholdPercent" yaml:"diskGCThresholdPercent"`
+	// MaxTaskSize indicates the max content length of the tasks placed in this data path, 0 is infinite
+	MaxTaskSize unit.Bytes `mapstructure:"maxTaskSize" yaml:"maxTaskSize"`
+}
+
+// PinRule describes which tasks should be pinned in storage, all the non-empty conditions must match.
+type PinRule struct {
+	// Regx matches the url of the task
+	Regx *Regexp `mapstructure:"regx" yaml:"regx"`
+	// Tag matches the tag of the task
+	Tag string `mapstructure:"tag" yaml:"tag"`
+}
+
+// Match checks if the given url and tag match the rule, the digest of task id headers in tag is ignored.
+func (r *PinRule) Match(url, tag string) bool {
+	if r.Regx == nil && r.Tag == "" {
+		return false
+	}
+	if r.Regx != nil && !r.Regx.MatchString(url) {
+		return false
+	}
+	if r.Tag != "" && r.Tag != tag && r.Tag != TrimHeaderDigest(tag) {
+		return false
+	}
+	return true
+}
+
+// headerDigestSeparator separates the tag and the digest of task id headers
+const headerDigestSeparator = "#"
+
+// headerDigestReg matches the digest of task id headers
+var headerDigestReg = regexp.MustCompile("^[a-f0-9]{64}$")
+
+// TagWithHeaderDigest appends the digest of task id headers to tag
+func TagWithHeaderDigest(tag, digest string) string {
+	return tag + headerDigestSeparator + digest
+}
+
+// TrimHeaderDigest returns the tag without the digest of task id headers
+func TrimHeaderDigest(tag string) string {
+	i := strings.LastIndex(tag, headerDigestSeparator)
+	if i < 0 || !headerDigestReg.MatchString(tag[i+len(headerDigestSeparator):]) {
+		return tag
+	}
+	return tag[:i]
 }
 
 type StoreStrategy string
 
+type EvictionPolicy string
+
+type PlacementPolicy string
+
 type FileString string
 
 func (f *FileString) UnmarshalJSON(b []byte) error {
@@ -531,6 +745,10 @@ type RegistryMirror struct {
 
 	// Whether to use proxies to decide when to use dragonfly
 	UseProxies bool `yaml:"useProxies" mapstructure:"useProxies"`
+
+	// Policy is the download policy of the mirror requests, the policy of matched proxy rule
+	// takes precedence when UseProxies is true
+	Policy *DownloadPolicy `yaml:"policy" mapstructure:"policy"`
 }
 
 // TLSConfig returns the tls.Config used to communicate with the mirror.
@@ -656,6 +874,67 @@ type Proxy struct {
 
 	// Redirect is the host to redirect to, if not empty
 	Redirect string `yaml:"redirect" mapstructure:"redirect"`
+
+	// Header rewrites the request headers of matched requests, if not nil
+	Header *HeaderRewrite `yaml:"header" mapstructure:"header"`
+
+	// TaskIDHeaders are the request headers whose values are part of the task id,
+	// the requests with different values of them are different tasks
+	TaskIDHeaders []string `yaml:"taskIDHeaders" mapstructure:"taskIDHeaders"`
+
+	// Policy is the download policy of matched requests, the daemon-wide settings are used if nil
+	Policy *DownloadPolicy `yaml:"policy" mapstructure:"policy"`
+}
+
+// DownloadPolicy describes how to download the requests matched by a proxy rule or registry mirror with dragonfly
+type DownloadPolicy struct {
+	// RateLimit is the download rate limit of each task, PerPeerRateLimit is used when it is zero
+	RateLimit clientutil.RateLimit `yaml:"rateLimit" mapstructure:"rateLimit"`
+
+	// DisableBackSource indicates failing the download instead of downloading from source when p2p fails
+	DisableBackSource bool `yaml:"disableBackSource" mapstructure:"disableBackSource"`
+
+	// Tag is used when the request is without X-Dragonfly-Biz header, it identifies different tasks for the same url
+	Tag string `yaml:"tag" mapstructure:"tag"`
+
+	// RequireDigest rejects the requests without sha256 digest in url, like the image layers,
+	// the digest is used to verify the whole content downloaded from source, ranged content is not verified
+	RequireDigest bool `yaml:"requireDigest" mapstructure:"requireDigest"`
+
+	// Timeout limits the whole download including reading the response body, zero means no timeout
+	Timeout clientutil.Duration `yaml:"timeout" mapstructure:"timeout"`
+
+	// Priority is the weight of piece requests in the upload fair queue of parents, zero means the default weight
+	Priority int32 `yaml:"priority" mapstructure:"priority"`
+}
+
+// Validate checks the download policy
+func (p *DownloadPolicy) Validate() error {
+	if p.RateLimit.Limit < 0 {
+		return errors.New("rate limit should not be negative")
+	}
+	if p.Timeout.Duration < 0 {
+		return errors.New("timeout should not be negative")
+	}
+	if p.Priority < 0 {
+		return errors.New("priority should not be negative")
+	}
+	return nil
+}
+
+// HeaderRewrite describes how to rewrite the request headers, the headers are removed before set
+type HeaderRewrite struct {
+	Set    []*HeaderValue `yaml:"set" mapstructure:"set"`
+	Remove []string       `yaml:"remove" mapstructure:"remove"`
+}
+
+// HeaderValue is a request header whose value is static, read from a file or an environment variable,
+// the file and environment variable are read for every request, so the rotated secrets are picked up
+type HeaderValue struct {
+	Name  string `yaml:"name" mapstructure:"name"`
+	Value string `yaml:"value" mapstructure:"value"`
+	File  string `yaml:"file" mapstructure:"file"`
+	Env   string `yaml:"env" mapstructure:"env"`
 }
 
 func NewProxy(regx string, useHTTPS bool, direct bool, redirect string) (*Proxy, error) {
@@ -677,6 +956,73 @@ func (r *Proxy) Match(url string) bool {
 	return r.Regx != nil && r.Regx.MatchString(url)
 }
 
+// Validate checks the header rewriting and download policy of the rule
+func (r *Proxy) Validate() error {
+	if r.Policy != nil {
+		if err := r.Policy.Validate(); err != nil {
+			return errors.Wrap(err, "invalid policy")
+		}
+	}
+	if r.Header == nil {
+		return nil
+	}
+	for _, h := range r.Header.Set {
+		if stringutils.IsBlank(h.Name) {
+			return errors.New("header name is not specified")
+		}
+		var sources int
+		for _, source := range []string{h.Value, h.File, h.Env} {
+			if source != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return errors.Errorf("header %s should have exactly one of value, file and env", h.Name)
+		}
+	}
+	return nil
+}
+
+// Rewrite removes and sets the headers, it returns the error when a header value can not be loaded,
+// the headers are removed but none of them is set in this case
+func (h *HeaderRewrite) Rewrite(header http.Header) error {
+	for _, name := range h.Remove {
+		header.Del(name)
+	}
+	values := make([]string, len(h.Set))
+	for i, v := range h.Set {
+		value, err := v.Load()
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+	for i, v := range h.Set {
+		header.Set(v.Name, values[i])
+	}
+	return nil
+}
+
+// Load returns the header value, the content of file is trimmed
+func (v *HeaderValue) Load() (string, error) {
+	switch {
+	case v.File != "":
+		b, err := os.ReadFile(v.File)
+		if err != nil {
+			return "", errors.Wrapf(err, "read header %s from file", v.Name)
+		}
+		return strings.TrimSpace(string(b)), nil
+	case v.Env != "":
+		value, ok := os.LookupEnv(v.Env)
+		if !ok {
+			return "", errors.Errorf("environment variable %s of header %s is not set", v.Env, v.Name)
+		}
+		return value, nil
+	default:
+		return v.Value, nil
+	}
+}
+
 // Regexp is a simple wrapper around regexp. Regexp to make it unmarshallable from a string.
 type Regexp struct {
 	*regexp.Regexp
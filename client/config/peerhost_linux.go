@@ -47,6 +47,10 @@ var peerHostConfig = DaemonOption{
 			},
 		},
 		ScheduleTimeout: clientutil.Duration{Duration: DefaultScheduleTimeout},
+		Announce: AnnounceOption{
+			Enable:   false,
+			Interval: 30 * time.Second,
+		},
 	},
 	Host: HostOption{
 		Hostname:       hostutils.Hostname,
@@ -123,4 +127,19 @@ var peerHostConfig = DaemonOption{
 		Multiplex:              false,
 		DiskGCThresholdPercent: 95,
 	},
+	SeedPeer: SeedPeerOption{
+		Enable: false,
+		KeepAlive: KeepAliveOption{
+			Interval: 5 * time.Second,
+		},
+	},
+	HostLoad: HostLoadOption{
+		Enable:   true,
+		Interval: 10 * time.Second,
+	},
+	Drain: DrainOption{
+		Timeout:      5 * time.Minute,
+		LeaveTimeout: time.Minute,
+		QuietPeriod:  5 * time.Second,
+	},
 }
@@ -18,7 +18,10 @@ package config
 
 import (
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -346,6 +349,9 @@ func TestPeerHostOption_Load(t *testing.T) {
 				},
 				Insecure: true,
 				Direct:   false,
+				Policy: &DownloadPolicy{
+					Priority: 2,
+				},
 			},
 			Proxies: []*Proxy{
 				{
@@ -353,6 +359,21 @@ func TestPeerHostOption_Load(t *testing.T) {
 					UseHTTPS: false,
 					Direct:   false,
 					Redirect: "d7y.io",
+					Header: &HeaderRewrite{
+						Set: []*HeaderValue{
+							{Name: "Authorization", Env: "REGISTRY_TOKEN"},
+						},
+						Remove: []string{"Cookie"},
+					},
+					TaskIDHeaders: []string{"X-Tenant"},
+					Policy: &DownloadPolicy{
+						RateLimit:         clientutil.RateLimit{Limit: 10 * 1024 * 1024},
+						DisableBackSource: true,
+						Tag:               "layer",
+						RequireDigest:     true,
+						Timeout:           clientutil.Duration{Duration: 10 * time.Minute},
+						Priority:          4,
+					},
 				},
 			},
 			HijackHTTPS: &HijackConfig{
@@ -365,6 +386,10 @@ func TestPeerHostOption_Load(t *testing.T) {
 					},
 				},
 			},
+			ManifestCache: &ManifestCacheOption{
+				TTL:        clientutil.Duration{Duration: time.Minute},
+				MaxEntries: 100,
+			},
 		},
 	}
 
@@ -375,3 +400,52 @@ func TestPeerHostOption_Load(t *testing.T) {
 
 	assert.EqualValues(peerHostOption, peerHostOptionYAML)
 }
+
+func TestProxy_Validate(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	assert.Nil((&Proxy{}).Validate())
+	assert.Nil((&Proxy{Header: &HeaderRewrite{Set: []*HeaderValue{{Name: "a", Value: "b"}}}}).Validate())
+	assert.NotNil((&Proxy{Header: &HeaderRewrite{Set: []*HeaderValue{{Name: " ", Value: "b"}}}}).Validate())
+	assert.NotNil((&Proxy{Header: &HeaderRewrite{Set: []*HeaderValue{{Name: "a"}}}}).Validate())
+	assert.NotNil((&Proxy{Header: &HeaderRewrite{Set: []*HeaderValue{{Name: "a", Value: "b", Env: "c"}}}}).Validate())
+	assert.Nil((&Proxy{Policy: &DownloadPolicy{Priority: 1}}).Validate())
+	assert.NotNil((&Proxy{Policy: &DownloadPolicy{Priority: -1}}).Validate())
+	assert.NotNil((&Proxy{Policy: &DownloadPolicy{RateLimit: clientutil.RateLimit{Limit: -1}}}).Validate())
+	assert.NotNil((&Proxy{Policy: &DownloadPolicy{Timeout: clientutil.Duration{Duration: -time.Second}}}).Validate())
+}
+
+func TestHeaderValue_Load(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	file := filepath.Join(t.TempDir(), "token")
+	assert.Nil(os.WriteFile(file, []byte(" token\n"), 0600))
+	value, err := (&HeaderValue{Name: "a", File: file}).Load()
+	assert.Nil(err)
+	assert.Equal("token", value)
+
+	t.Setenv("TEST_HEADER_VALUE", "env")
+	value, err = (&HeaderValue{Name: "a", Env: "TEST_HEADER_VALUE"}).Load()
+	assert.Nil(err)
+	assert.Equal("env", value)
+
+	_, err = (&HeaderValue{Name: "a", Env: "TEST_HEADER_VALUE_NOT_SET"}).Load()
+	assert.NotNil(err)
+
+	value, err = (&HeaderValue{Name: "a", Value: "static"}).Load()
+	assert.Nil(err)
+	assert.Equal("static", value)
+}
+
+func TestTrimHeaderDigest(t *testing.T) {
+	assert := testifyassert.New(t)
+	digest := strings.Repeat("a", 64)
+	assert.Equal("tag", TrimHeaderDigest(TagWithHeaderDigest("tag", digest)))
+	assert.Equal("", TrimHeaderDigest(TagWithHeaderDigest("", digest)))
+	assert.Equal("tag#1", TrimHeaderDigest("tag#1"), "tag with separator is kept")
+	assert.Equal("tag", TrimHeaderDigest("tag"))
+
+	rule := &PinRule{Tag: "tag"}
+	assert.True(rule.Match("http://example.com", TagWithHeaderDigest("tag", digest)))
+	assert.False(rule.Match("http://example.com", TagWithHeaderDigest("other", digest)))
+}
@@ -0,0 +1,133 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package announcer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"d7y.io/dragonfly/v2/client/daemon/hostload"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	schedulerclient "d7y.io/dragonfly/v2/pkg/rpc/scheduler/client"
+)
+
+// leaveTimeout is the timeout of leaving schedulers when announcer stops
+const leaveTimeout = 5 * time.Second
+
+// Announcer announces the host and its cached tasks to schedulers periodically,
+// and makes the host leave schedulers when it stops
+type Announcer interface {
+	Start()
+	Stop()
+}
+
+type announcer struct {
+	interval        time.Duration
+	peerHost        *scheduler.PeerHost
+	schedulerClient schedulerclient.SchedulerClient
+	storageManager  storage.Manager
+	// hostLoadSampler is nil when host load reporting is disabled
+	hostLoadSampler hostload.Sampler
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+var _ Announcer = (*announcer)(nil)
+
+// New creates an Announcer, the peer host is read when announcing,
+// so the ports of host should be set before Start
+func New(interval time.Duration, peerHost *scheduler.PeerHost, schedulerClient schedulerclient.SchedulerClient,
+	storageManager storage.Manager, hostLoadSampler hostload.Sampler) Announcer {
+	return &announcer{
+		interval:        interval,
+		peerHost:        peerHost,
+		schedulerClient: schedulerClient,
+		storageManager:  storageManager,
+		hostLoadSampler: hostLoadSampler,
+		done:            make(chan struct{}),
+	}
+}
+
+func (a *announcer) Start() {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.announce()
+		tick := time.NewTicker(a.interval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				a.announce()
+			case <-a.done:
+				logger.Infof("host announcer exited")
+				return
+			}
+		}
+	}()
+}
+
+func (a *announcer) Stop() {
+	a.once.Do(func() {
+		close(a.done)
+		a.wg.Wait()
+
+		ctx, cancel := context.WithTimeout(context.Background(), leaveTimeout)
+		defer cancel()
+		if err := a.schedulerClient.LeaveHost(ctx, &scheduler.LeaveHostRequest{Uuid: a.peerHost.Uuid}); err != nil {
+			logger.Errorf("leave host %s error: %s", a.peerHost.Uuid, err)
+			return
+		}
+		logger.Infof("host %s has left schedulers", a.peerHost.Uuid)
+	})
+}
+
+func (a *announcer) announce() {
+	req := &scheduler.AnnounceHostRequest{
+		PeerHost: a.peerHost,
+	}
+	if a.hostLoadSampler != nil {
+		req.HostLoad = a.hostLoadSampler.Load()
+	}
+	for _, task := range a.storageManager.ListTasks() {
+		// only the completed tasks can serve all pieces to other peers
+		if !task.Done {
+			continue
+		}
+		req.CachedTasks = append(req.CachedTasks, &scheduler.CachedTask{
+			TaskId:          task.TaskID,
+			PeerId:          task.PeerID,
+			ContentLength:   task.ContentLength,
+			TotalPieceCount: task.TotalPieces,
+			Url:             task.URL,
+			UrlMeta:         task.URLMeta,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.interval)
+	defer cancel()
+	if err := a.schedulerClient.AnnounceHost(ctx, req); err != nil {
+		logger.Warnf("announce host %s error: %s", a.peerHost.Uuid, err)
+		return
+	}
+	logger.Debugf("announce host %s with %d cached tasks", a.peerHost.Uuid, len(req.CachedTasks))
+}
@@ -0,0 +1,86 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package announcer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	testifyassert "github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	mock_scheduler "d7y.io/dragonfly/v2/client/daemon/test/mock/scheduler"
+	mock_storage "d7y.io/dragonfly/v2/client/daemon/test/mock/storage"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+func TestAnnouncer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	assert := testifyassert.New(t)
+
+	peerHost := &scheduler.PeerHost{Uuid: "host"}
+	storageManager := mock_storage.NewMockManager(ctrl)
+	storageManager.EXPECT().ListTasks().Return([]*storage.CachedTask{
+		{
+			PeerTaskMetadata: storage.PeerTaskMetadata{TaskID: "done", PeerID: "peer-1"},
+			ContentLength:    100,
+			TotalPieces:      2,
+			Done:             true,
+			URL:              "http://example.com/done",
+			URLMeta:          &base.UrlMeta{Tag: "tag"},
+		},
+		{
+			PeerTaskMetadata: storage.PeerTaskMetadata{TaskID: "running", PeerID: "peer-2"},
+			ContentLength:    100,
+			TotalPieces:      2,
+		},
+	}).MinTimes(1)
+
+	announced := make(chan *scheduler.AnnounceHostRequest, 1)
+	sched := mock_scheduler.NewMockSchedulerClient(ctrl)
+	sched.EXPECT().AnnounceHost(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req *scheduler.AnnounceHostRequest, opts ...grpc.CallOption) error {
+			select {
+			case announced <- req:
+			default:
+			}
+			return nil
+		}).MinTimes(1)
+	sched.EXPECT().LeaveHost(gomock.Any(), gomock.Eq(&scheduler.LeaveHostRequest{Uuid: peerHost.Uuid})).Return(nil).Times(1)
+
+	a := New(time.Hour, peerHost, sched, storageManager, nil)
+	a.Start()
+	select {
+	case req := <-announced:
+		assert.Equal(peerHost, req.PeerHost)
+		assert.Nil(req.HostLoad)
+		assert.Equal([]*scheduler.CachedTask{
+			{TaskId: "done", PeerId: "peer-1", ContentLength: 100, TotalPieceCount: 2,
+				Url: "http://example.com/done", UrlMeta: &base.UrlMeta{Tag: "tag"}},
+		}, req.CachedTasks)
+	case <-time.After(time.Second):
+		assert.Fail("host is not announced")
+	}
+	a.Stop()
+	// stop twice should not leave again
+	a.Stop()
+}
@@ -38,11 +38,14 @@ import (
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/announcer"
 	"d7y.io/dragonfly/v2/client/daemon/gc"
+	"d7y.io/dragonfly/v2/client/daemon/hostload"
 	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	"d7y.io/dragonfly/v2/client/daemon/peer"
 	"d7y.io/dragonfly/v2/client/daemon/proxy"
 	"d7y.io/dragonfly/v2/client/daemon/rpcserver"
+	"d7y.io/dragonfly/v2/client/daemon/status"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/client/daemon/upload"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
@@ -61,6 +64,8 @@ import (
 type Daemon interface {
 	Serve() error
 	Stop()
+	// Drain rejects new downloads, leaves schedulers and waits for the in-flight uploads, then stops the daemon
+	Drain()
 
 	// ExportTaskManager returns the underlay peer.TaskManager for downloading when embed dragonfly in custom binary
 	ExportTaskManager() peer.TaskManager
@@ -69,8 +74,9 @@ type Daemon interface {
 }
 
 type clientDaemon struct {
-	once *sync.Once
-	done chan bool
+	once      *sync.Once
+	drainOnce *sync.Once
+	done      chan bool
 
 	schedPeerHost *scheduler.PeerHost
 
@@ -78,14 +84,20 @@ type clientDaemon struct {
 
 	RPCManager     rpcserver.Server
 	UploadManager  upload.Manager
+	StatusManager  status.Manager
 	ProxyManager   proxy.Manager
 	StorageManager storage.Manager
 	GCManager      gc.Manager
+	// HostLoadSampler is nil when host load reporting is disabled
+	HostLoadSampler hostload.Sampler
+	// Announcer is nil when host announcement is disabled
+	Announcer announcer.Announcer
 
 	PeerTaskManager peer.TaskManager
 	PieceManager    peer.PieceManager
 
 	dynconfig       config.Dynconfig
+	managerClient   managerclient.Client
 	dfpath          dfpath.Dfpath
 	schedulers      []*manager.Scheduler
 	schedulerClient schedulerclient.SchedulerClient
@@ -110,9 +122,11 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 	var addrs []dfnet.NetAddr
 	var schedulers []*manager.Scheduler
 	var dynconfig config.Dynconfig
+	var managerClient managerclient.Client
 	if opt.Scheduler.Manager.Enable == true {
 		// New manager client
-		managerClient, err := managerclient.NewWithAddrs(opt.Scheduler.Manager.NetAddrs)
+		var err error
+		managerClient, err = managerclient.NewWithAddrs(opt.Scheduler.Manager.NetAddrs)
 		if err != nil {
 			return nil, err
 		}
@@ -160,16 +174,40 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 		return nil, err
 	}
 
+	// peers download pieces via https when tls is enabled in upload server
+	var uploadClientTLSConfig *tls.Config
+	if !opt.Upload.Security.Insecure {
+		uploadClientTLSConfig, err = loadUploadClientTLSConfig(opt.Upload.Security)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	pieceManager, err := peer.NewPieceManager(storageManager,
 		opt.Download.PieceDownloadTimeout,
 		peer.WithLimiter(rate.NewLimiter(opt.Download.TotalRateLimit.Limit, int(opt.Download.TotalRateLimit.Limit))),
 		peer.WithCalculateDigest(opt.Download.CalculateDigest), peer.WithTransportOption(opt.Download.TransportOption),
+		peer.WithTLSConfig(uploadClientTLSConfig), peer.WithConcurrentSource(opt.Download.ConcurrentSource),
 	)
 	if err != nil {
 		return nil, err
 	}
+	var hostLoadSampler hostload.Sampler
+	if opt.HostLoad.Enable {
+		dataPaths := []string{opt.Storage.DataPath}
+		if len(opt.Storage.DataPaths) > 0 {
+			dataPaths = nil
+			for _, dataPath := range opt.Storage.DataPaths {
+				dataPaths = append(dataPaths, dataPath.Path)
+			}
+		}
+		hostLoadSampler = hostload.NewSampler(opt.HostLoad.Interval, dataPaths,
+			opt.Download.TotalRateLimit.Limit+opt.Upload.RateLimit.Limit)
+	}
+
 	peerTaskManager, err := peer.NewPeerTaskManager(host, pieceManager, storageManager, sched, opt.Scheduler,
-		opt.Download.PerPeerRateLimit.Limit, opt.Storage.Multiplex, opt.Download.Prefetch, opt.Download.CalculateDigest, opt.Download.GetPiecesMaxRetry)
+		opt.Download.PerPeerRateLimit.Limit, opt.Storage.Multiplex, opt.Download.Prefetch, opt.Download.RangeFromParentTask, opt.Download.CalculateDigest, opt.Download.GetPiecesMaxRetry, opt.Download.AdaptiveConcurrency,
+		hostLoadSampler)
 	if err != nil {
 		return nil, err
 	}
@@ -191,7 +229,26 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 		}
 		peerServerOption = append(peerServerOption, grpc.Creds(tlsCredentials))
 	}
-	rpcManager, err := rpcserver.New(host, peerTaskManager, storageManager, downloadServerOption, peerServerOption)
+	// upload limiter is shared between upload server and DownloadPiece rpc
+	uploadLimiter := rate.NewLimiter(opt.Upload.RateLimit.Limit, int(opt.Upload.RateLimit.Limit))
+	var rpcServerOptions []rpcserver.Option
+	if opt.Upload.GRPC {
+		rpcServerOptions = append(rpcServerOptions, rpcserver.WithDownloadPiece(uploadLimiter))
+	}
+	// fair limiter shares the upload limiter across concurrent children, it's shared between upload server and DownloadPiece rpc
+	var fairLimiter *upload.FairLimiter
+	if opt.Upload.FairShare != nil {
+		fairLimiter = upload.NewFairLimiter(uploadLimiter, opt.Upload.FairShare.MaxWeight)
+		rpcServerOptions = append(rpcServerOptions, rpcserver.WithFairLimiter(fairLimiter))
+	}
+	if opt.SeedPeer.Enable {
+		rpcServerOptions = append(rpcServerOptions, rpcserver.WithSeeder())
+	}
+	// uploads via http and rpc are counted together to wait them finished when draining
+	inflight := upload.NewInflight()
+	rpcServerOptions = append(rpcServerOptions, rpcserver.WithInflight(inflight))
+	rpcManager, err := rpcserver.New(host, peerTaskManager, storageManager, downloadServerOption, peerServerOption,
+		rpcServerOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -203,13 +260,19 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 	}
 
 	uploadManager, err := upload.NewUploadManager(storageManager,
-		upload.WithLimiter(rate.NewLimiter(opt.Upload.RateLimit.Limit, int(opt.Upload.RateLimit.Limit))))
+		upload.WithLimiter(uploadLimiter), upload.WithFairLimiter(fairLimiter), upload.WithInflight(inflight))
 	if err != nil {
 		return nil, err
 	}
 
-	return &clientDaemon{
+	var hostAnnouncer announcer.Announcer
+	if opt.Scheduler.Announce.Enable {
+		hostAnnouncer = announcer.New(opt.Scheduler.Announce.Interval, host, sched, storageManager, hostLoadSampler)
+	}
+
+	cd := &clientDaemon{
 		once:          &sync.Once{},
+		drainOnce:     &sync.Once{},
 		done:          make(chan bool),
 		schedPeerHost: host,
 		Option:        *opt,
@@ -221,11 +284,16 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 		UploadManager:   uploadManager,
 		StorageManager:  storageManager,
 		GCManager:       gc.NewManager(opt.GCInterval.Duration),
+		HostLoadSampler: hostLoadSampler,
+		Announcer:       hostAnnouncer,
 		dynconfig:       dynconfig,
+		managerClient:   managerClient,
 		dfpath:          d,
 		schedulers:      schedulers,
 		schedulerClient: sched,
-	}, nil
+	}
+	cd.StatusManager = status.NewStatusManager(peerTaskManager, storageManager, cd.Drain)
+	return cd, nil
 }
 
 func loadGPRCTLSCredentials(opt config.SecurityOption) (credentials.TransportCredentials, error) {
@@ -262,6 +330,36 @@ func loadGPRCTLSCredentials(opt config.SecurityOption) (credentials.TransportCre
 	return credentials.NewTLS(opt.TLSConfig), nil
 }
 
+// loadUploadClientTLSConfig loads the tls config for downloading pieces from other peers' upload server,
+// the server certificate is verified with the cluster CA, and the certificate is sent when mutual tls is enabled
+func loadUploadClientTLSConfig(opt config.SecurityOption) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if opt.TLSConfig != nil {
+		tlsConfig = opt.TLSConfig.Clone()
+	}
+
+	if opt.CACert != "" {
+		caCert, err := os.ReadFile(opt.CACert)
+		if err != nil {
+			return nil, err
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to add upload CA's certificate")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	if opt.Cert != "" && opt.Key != "" {
+		cert, err := tls.LoadX509KeyPair(opt.Cert, opt.Key)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
 func (*clientDaemon) prepareTCPListener(opt config.ListenOption, withTLS bool) (net.Listener, int, error) {
 	if len(opt.TCPListen.Namespace) > 0 {
 		runtime.LockOSThread()
@@ -325,6 +423,9 @@ func (*clientDaemon) prepareTCPListener(opt config.ListenOption, withTLS bool) (
 
 func (cd *clientDaemon) Serve() error {
 	cd.GCManager.Start()
+	if cd.HostLoadSampler != nil {
+		cd.HostLoadSampler.Start()
+	}
 	// TODO remove this field, and use directly dfpath.DaemonSockPath
 	cd.Option.Download.DownloadGRPC.UnixListen.Socket = cd.dfpath.DaemonSockPath()
 	// prepare download service listen
@@ -363,6 +464,30 @@ func (cd *clientDaemon) Serve() error {
 	}
 	cd.schedPeerHost.DownPort = int32(uploadPort)
 
+	// prepare status service listen
+	_ = os.Remove(cd.dfpath.DaemonStatusSockPath())
+	statusListener, err := rpc.Listen(dfnet.NetAddr{
+		Type: dfnet.UNIX,
+		Addr: cd.dfpath.DaemonStatusSockPath(),
+	})
+	if err != nil {
+		logger.Errorf("failed to listen for status service: %v", err)
+		return err
+	}
+
+	// register to manager as a seed peer after the ports are known
+	if cd.Option.SeedPeer.Enable {
+		if err := cd.announceSeedPeer(); err != nil {
+			logger.Errorf("failed to announce seed peer: %v", err)
+			return err
+		}
+	}
+
+	// announce to schedulers after the ports are known
+	if cd.Announcer != nil {
+		cd.Announcer.Start()
+	}
+
 	g := errgroup.Group{}
 	// serve download grpc service
 	g.Go(func() error {
@@ -445,6 +570,19 @@ func (cd *clientDaemon) Serve() error {
 		return nil
 	})
 
+	// serve status service
+	g.Go(func() error {
+		defer statusListener.Close()
+		logger.Infof("serve status service at unix://%s", cd.dfpath.DaemonStatusSockPath())
+		if err := cd.StatusManager.Serve(statusListener); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("failed to serve for status service: %v", err)
+			return err
+		} else if err == http.ErrServerClosed {
+			logger.Infof("status service closed")
+		}
+		return nil
+	})
+
 	if cd.Option.AliveTime.Duration > 0 {
 		g.Go(func() error {
 			select {
@@ -504,14 +642,52 @@ func (cd *clientDaemon) Serve() error {
 	return werr
 }
 
+// announceSeedPeer registers current host to manager as a cdn, so schedulers trigger seed peer tasks via ObtainSeeds
+func (cd *clientDaemon) announceSeedPeer() error {
+	if cd.managerClient == nil {
+		return errors.New("seed peer requires manager to be enabled")
+	}
+	seedPeer, err := cd.managerClient.UpdateCDN(&manager.UpdateCDNRequest{
+		SourceType:   manager.SourceType_CDN_SOURCE,
+		HostName:     cd.schedPeerHost.HostName,
+		Ip:           cd.schedPeerHost.Ip,
+		Port:         cd.schedPeerHost.RpcPort,
+		DownloadPort: cd.schedPeerHost.DownPort,
+		Idc:          cd.schedPeerHost.Idc,
+		Location:     cd.schedPeerHost.Location,
+		CdnClusterId: uint64(cd.Option.SeedPeer.ClusterID),
+	})
+	if err != nil {
+		return err
+	}
+	logger.Infof("announce seed peer %s to manager, cluster id: %d", seedPeer, cd.Option.SeedPeer.ClusterID)
+
+	go cd.managerClient.KeepAlive(cd.Option.SeedPeer.KeepAlive.Interval, &manager.KeepAliveRequest{
+		HostName:   cd.schedPeerHost.HostName,
+		SourceType: manager.SourceType_CDN_SOURCE,
+		ClusterId:  uint64(cd.Option.SeedPeer.ClusterID),
+	})
+	return nil
+}
+
 func (cd *clientDaemon) Stop() {
 	cd.once.Do(func() {
 		close(cd.done)
+		// leave schedulers first to avoid scheduling new children to the stopping host
+		if cd.Announcer != nil {
+			cd.Announcer.Stop()
+		}
 		cd.GCManager.Stop()
+		if cd.HostLoadSampler != nil {
+			cd.HostLoadSampler.Stop()
+		}
 		cd.RPCManager.Stop()
 		if err := cd.UploadManager.Stop(); err != nil {
 			logger.Errorf("upload manager stop failed %s", err)
 		}
+		if err := cd.StatusManager.Stop(); err != nil {
+			logger.Errorf("status manager stop failed %s", err)
+		}
 
 		if cd.ProxyManager.IsEnabled() {
 			if err := cd.ProxyManager.Stop(); err != nil {
@@ -533,6 +709,52 @@ func (cd *clientDaemon) Stop() {
 	})
 }
 
+func (cd *clientDaemon) Drain() {
+	cd.drainOnce.Do(func() {
+		logger.Infof("start draining, timeout: %s, leave timeout: %s, quiet period: %s",
+			cd.Option.Drain.Timeout, cd.Option.Drain.LeaveTimeout, cd.Option.Drain.QuietPeriod)
+		if err := cd.PeerTaskManager.Stop(context.Background()); err != nil {
+			logger.Errorf("peer task manager stop failed %s", err)
+		}
+
+		// scheduler reschedules the children of the leaving peers to other parents
+		cd.leaveTasks()
+		if cd.Announcer != nil {
+			cd.Announcer.Stop()
+		}
+
+		// waiting in-flight uploads has its own budget, so that leaving many tasks does not use it up
+		ctx, cancel := context.WithTimeout(context.Background(), cd.Option.Drain.Timeout)
+		defer cancel()
+		if err := cd.UploadManager.WaitIdle(ctx, cd.Option.Drain.QuietPeriod); err != nil {
+			logger.Warnf("wait in-flight uploads failed: %s", err)
+		} else {
+			logger.Infof("all in-flight uploads are finished")
+		}
+
+		cd.Stop()
+	})
+}
+
+// leaveTasks leaves all the tasks from schedulers, it's bounded by the drain leave timeout
+func (cd *clientDaemon) leaveTasks() {
+	ctx, cancel := context.WithTimeout(context.Background(), cd.Option.Drain.LeaveTimeout)
+	defer cancel()
+
+	for _, task := range cd.StorageManager.ListTasks() {
+		if ctx.Err() != nil {
+			logger.Warnf("leave tasks not finished: %s", ctx.Err())
+			return
+		}
+		if err := cd.schedulerClient.LeaveTask(ctx, &scheduler.PeerTarget{
+			TaskId: task.TaskID,
+			PeerId: task.PeerID,
+		}); err != nil {
+			logger.Warnf("leave task %s/%s error: %v", task.TaskID, task.PeerID, err)
+		}
+	}
+}
+
 func (cd *clientDaemon) OnNotify(data *config.DynconfigData) {
 	ips := getSchedulerIPs(data.Schedulers)
 	if reflect.DeepEqual(cd.schedulers, data.Schedulers) {
@@ -0,0 +1,176 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostload
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+)
+
+// Sampler samples the cpu, memory, disk and network utilization of host periodically
+type Sampler interface {
+	Start()
+	Stop()
+	// Load returns the latest host load, it's nil before the first sampling
+	Load() *base.HostLoad
+}
+
+type sampler struct {
+	interval  time.Duration
+	dataPaths []string
+	// bandwidth is the bytes per second of network, it's used to calculate network utilization
+	bandwidth float64
+
+	lock sync.RWMutex
+	load *base.HostLoad
+
+	// network counters of the last sampling
+	lastNetBytes uint64
+	lastNetTime  time.Time
+
+	done chan struct{}
+	once sync.Once
+}
+
+var _ Sampler = (*sampler)(nil)
+
+// NewSampler creates a Sampler, the disk usage is the max usage of the data paths,
+// the network utilization is not calculated when bandwidth is unlimited
+func NewSampler(interval time.Duration, dataPaths []string, bandwidth rate.Limit) Sampler {
+	s := &sampler{
+		interval:  interval,
+		dataPaths: dataPaths,
+		done:      make(chan struct{}),
+	}
+	if bandwidth != rate.Inf && bandwidth > 0 {
+		s.bandwidth = float64(bandwidth)
+	}
+	return s
+}
+
+func (s *sampler) Start() {
+	s.sample()
+	go func() {
+		tick := time.NewTicker(s.interval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				s.sample()
+			case <-s.done:
+				logger.Infof("host load sampler exited")
+				return
+			}
+		}
+	}()
+}
+
+func (s *sampler) Stop() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *sampler) Load() *base.HostLoad {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.load
+}
+
+func (s *sampler) sample() {
+	load := &base.HostLoad{}
+
+	// the cpu usage since the last sampling
+	if percents, err := cpu.Percent(0, false); err != nil {
+		logger.Warnf("sample cpu usage error: %s", err)
+	} else if len(percents) > 0 {
+		load.CpuRatio = ratio(percents[0])
+	}
+
+	if vm, err := mem.VirtualMemory(); err != nil {
+		logger.Warnf("sample memory usage error: %s", err)
+	} else {
+		load.MemRatio = ratio(vm.UsedPercent)
+	}
+
+	for _, path := range s.dataPaths {
+		usage, err := disk.Usage(path)
+		if err != nil {
+			logger.Warnf("sample disk usage of %s error: %s", path, err)
+			continue
+		}
+		if r := ratio(usage.UsedPercent); r > load.DiskRatio {
+			load.DiskRatio = r
+		}
+	}
+
+	metrics.HostLoadRatio.WithLabelValues("cpu").Set(float64(load.CpuRatio))
+	metrics.HostLoadRatio.WithLabelValues("memory").Set(float64(load.MemRatio))
+	metrics.HostLoadRatio.WithLabelValues("disk").Set(float64(load.DiskRatio))
+	// base.HostLoad has no field for network, the network utilization is only exported by metrics
+	if r, ok := s.sampleNetwork(); ok {
+		metrics.HostLoadRatio.WithLabelValues("network").Set(float64(r))
+	}
+
+	s.lock.Lock()
+	s.load = load
+	s.lock.Unlock()
+	logger.Debugf("sample host load, cpu: %.2f, memory: %.2f, disk: %.2f", load.CpuRatio, load.MemRatio, load.DiskRatio)
+}
+
+// sampleNetwork returns the network utilization since the last sampling,
+// it returns false when bandwidth is unlimited or this is the first sampling
+func (s *sampler) sampleNetwork() (float32, bool) {
+	if s.bandwidth == 0 {
+		return 0, false
+	}
+	counters, err := net.IOCounters(false)
+	if err != nil || len(counters) == 0 {
+		logger.Warnf("sample network usage error: %v", err)
+		return 0, false
+	}
+
+	now := time.Now()
+	bytes := counters[0].BytesSent + counters[0].BytesRecv
+	lastBytes, lastTime := s.lastNetBytes, s.lastNetTime
+	s.lastNetBytes, s.lastNetTime = bytes, now
+	if lastTime.IsZero() || bytes < lastBytes {
+		return 0, false
+	}
+
+	elapsed := now.Sub(lastTime).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return ratio(float64(bytes-lastBytes) / elapsed / s.bandwidth * 100), true
+}
+
+// ratio converts a percent to a ratio between 0 and 1
+func ratio(percent float64) float32 {
+	return float32(math.Min(math.Max(percent/100, 0), 1))
+}
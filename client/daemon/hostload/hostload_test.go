@@ -0,0 +1,70 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostload
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestSampler_Load(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	s := NewSampler(time.Hour, []string{os.TempDir()}, rate.Limit(1024*1024))
+	assert.Nil(s.Load(), "load should be nil before the first sampling")
+
+	s.Start()
+	defer s.Stop()
+	load := s.Load()
+	if !assert.NotNil(load) {
+		return
+	}
+	for _, r := range []float32{load.CpuRatio, load.MemRatio, load.DiskRatio} {
+		assert.GreaterOrEqual(r, float32(0))
+		assert.LessOrEqual(r, float32(1))
+	}
+	assert.Greater(load.MemRatio, float32(0))
+	assert.Greater(load.DiskRatio, float32(0))
+}
+
+func TestSampler_sampleNetwork(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	s := NewSampler(time.Hour, nil, rate.Inf).(*sampler)
+	_, ok := s.sampleNetwork()
+	assert.False(ok, "network utilization is not calculated with unlimited bandwidth")
+
+	s = NewSampler(time.Hour, nil, rate.Limit(1024*1024)).(*sampler)
+	_, ok = s.sampleNetwork()
+	assert.False(ok, "first sampling has no previous counters")
+	time.Sleep(10 * time.Millisecond)
+	r, ok := s.sampleNetwork()
+	assert.True(ok)
+	assert.GreaterOrEqual(r, float32(0))
+	assert.LessOrEqual(r, float32(1))
+}
+
+func TestRatio(t *testing.T) {
+	assert := testifyassert.New(t)
+	assert.Equal(float32(0), ratio(-1))
+	assert.Equal(float32(0.5), ratio(50))
+	assert.Equal(float32(1), ratio(120))
+}
@@ -48,6 +48,13 @@ var (
 		Help:      "Counter of the total proxy request not via Dragonfly.",
 	})
 
+	ProxyManifestCacheCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: constants.MetricsNamespace,
+		Subsystem: constants.DfdaemonMetricsName,
+		Name:      "proxy_manifest_cache_total",
+		Help:      "Counter of the total registry manifest requests served by the proxy manifest cache.",
+	}, []string{"result"})
+
 	ProxyRequestRunningCount = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: constants.MetricsNamespace,
 		Subsystem: constants.DfdaemonMetricsName,
@@ -62,6 +69,20 @@ var (
 		Help:      "Counter of the total byte of all proxy request.",
 	}, []string{"method"})
 
+	UploadBytesCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: constants.MetricsNamespace,
+		Subsystem: constants.DfdaemonMetricsName,
+		Name:      "upload_bytes_total",
+		Help:      "Counter of the total upload bytes.",
+	})
+
+	HostLoadRatio = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: constants.MetricsNamespace,
+		Subsystem: constants.DfdaemonMetricsName,
+		Name:      "host_load_ratio",
+		Help:      "Current utilization ratio of host resources.",
+	}, []string{"resource"})
+
 	PeerTaskCount = promauto.NewCounter(prometheus.CounterOpts{
 		Namespace: constants.MetricsNamespace,
 		Subsystem: constants.DfdaemonMetricsName,
@@ -104,12 +125,33 @@ var (
 		Help:      "Counter of the total stream tasks.",
 	})
 
+	SeedTaskCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: constants.MetricsNamespace,
+		Subsystem: constants.DfdaemonMetricsName,
+		Name:      "seed_task_total",
+		Help:      "Counter of the total seed tasks triggered by scheduler.",
+	})
+
 	PeerTaskCacheHitCount = promauto.NewCounter(prometheus.CounterOpts{
 		Namespace: constants.MetricsNamespace,
 		Subsystem: constants.DfdaemonMetricsName,
 		Name:      "peer_task_cache_hit_total",
 		Help:      "Counter of the total cache hit peer tasks.",
 	})
+
+	PinnedTaskBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: constants.MetricsNamespace,
+		Subsystem: constants.DfdaemonMetricsName,
+		Name:      "pinned_task_bytes",
+		Help:      "Gauge of the total bytes of pinned tasks in storage.",
+	})
+
+	UnpinnedTaskBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: constants.MetricsNamespace,
+		Subsystem: constants.DfdaemonMetricsName,
+		Name:      "unpinned_task_bytes",
+		Help:      "Gauge of the total bytes of unpinned tasks in storage.",
+	})
 )
 
 func New(addr string) *http.Server {
@@ -0,0 +1,278 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+const (
+	defaultAdaptiveMinWorkers        = 1
+	defaultAdaptiveMaxWorkers        = 16
+	defaultAdaptiveLatencyTolerance  = 3
+	defaultAdaptiveBlacklistTimeouts = 3
+	defaultAdaptiveBlacklistDuration = time.Minute
+
+	// ewmaWeight is the weight of the latest sample in the moving average of parent statistics
+	ewmaWeight = 0.3
+)
+
+// adaptiveConcurrencyOption fills the default values of the adaptive concurrency option
+func adaptiveConcurrencyOption(opt *config.AdaptiveConcurrencyOption) *config.AdaptiveConcurrencyOption {
+	if opt == nil {
+		return nil
+	}
+	o := *opt
+	if o.MinWorkers <= 0 {
+		o.MinWorkers = defaultAdaptiveMinWorkers
+	}
+	if o.MaxWorkers <= 0 {
+		o.MaxWorkers = defaultAdaptiveMaxWorkers
+	}
+	if o.MaxWorkers < o.MinWorkers {
+		o.MaxWorkers = o.MinWorkers
+	}
+	if o.LatencyTolerance <= 1 {
+		o.LatencyTolerance = defaultAdaptiveLatencyTolerance
+	}
+	if o.BlacklistTimeouts <= 0 {
+		o.BlacklistTimeouts = defaultAdaptiveBlacklistTimeouts
+	}
+	if o.BlacklistDuration <= 0 {
+		o.BlacklistDuration = defaultAdaptiveBlacklistDuration
+	}
+	return &o
+}
+
+// concurrencyController limits the concurrent piece downloads with additive increase and multiplicative decrease,
+// the limit increases by one per window of successful downloads, and halves when parents time out or get congested
+type concurrencyController struct {
+	sync.Mutex
+	min, max float64
+	limit    float64
+	inflight int
+	// changed is closed and renewed when a slot is released or the limit changes
+	changed chan struct{}
+	// lastDecrease is used to decrease at most once per window, the window is the latest piece latency
+	lastDecrease time.Time
+	window       time.Duration
+}
+
+func newConcurrencyController(min, max int) *concurrencyController {
+	return &concurrencyController{
+		min:     float64(min),
+		max:     float64(max),
+		limit:   float64(min),
+		changed: make(chan struct{}),
+	}
+}
+
+// reset sets the current limit, it's called with the parallel count from scheduler
+func (c *concurrencyController) reset(limit int) {
+	c.Lock()
+	defer c.Unlock()
+	c.limit = c.clamp(float64(limit))
+	c.notify()
+}
+
+// tryAcquire takes a download slot, when there is no slot, the returned channel is closed when it's worth retrying
+func (c *concurrencyController) tryAcquire() (bool, <-chan struct{}) {
+	c.Lock()
+	defer c.Unlock()
+	if c.inflight < int(c.limit) {
+		c.inflight++
+		return true, nil
+	}
+	return false, c.changed
+}
+
+func (c *concurrencyController) release() {
+	c.Lock()
+	defer c.Unlock()
+	c.inflight--
+	c.notify()
+}
+
+// onSuccess increases the limit when the parent is not congested, otherwise decreases it
+func (c *concurrencyController) onSuccess(latency time.Duration, congested bool) {
+	if congested {
+		c.onCongestion(latency)
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.limit = c.clamp(c.limit + 1/c.limit)
+	c.notify()
+}
+
+// onCongestion halves the limit, it's called when a parent times out or gets slow
+func (c *concurrencyController) onCongestion(latency time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	now := time.Now()
+	if now.Sub(c.lastDecrease) < c.window {
+		return
+	}
+	c.lastDecrease = now
+	c.window = latency
+	c.limit = c.clamp(c.limit / 2)
+}
+
+func (c *concurrencyController) getLimit() int {
+	c.Lock()
+	defer c.Unlock()
+	return int(c.limit)
+}
+
+func (c *concurrencyController) clamp(limit float64) float64 {
+	if limit < c.min {
+		return c.min
+	}
+	if limit > c.max {
+		return c.max
+	}
+	return limit
+}
+
+// notify wakes up the workers waiting for slots, caller must hold the lock
+func (c *concurrencyController) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// parentStat is the measured statistics of a parent
+type parentStat struct {
+	// throughput is the moving average of bytes per second
+	throughput float64
+	// latency is the moving average of piece latency
+	latency time.Duration
+	// minLatency is the lowest piece latency, it's the baseline to detect congestion
+	minLatency time.Duration
+	// timeouts is the count of consecutive timeouts
+	timeouts       int
+	blacklistUntil time.Time
+}
+
+// parentSelector steers piece requests toward the fastest parents and blacklists parents which time out repeatedly
+type parentSelector struct {
+	sync.Mutex
+	latencyTolerance  float64
+	blacklistTimeouts int
+	blacklistDuration time.Duration
+	stats             map[string]*parentStat
+}
+
+func newParentSelector(opt *config.AdaptiveConcurrencyOption) *parentSelector {
+	return &parentSelector{
+		latencyTolerance:  opt.LatencyTolerance,
+		blacklistTimeouts: opt.BlacklistTimeouts,
+		blacklistDuration: opt.BlacklistDuration,
+		stats:             map[string]*parentStat{},
+	}
+}
+
+// recordSuccess records a piece downloaded from the parent, returns whether the parent is congested
+func (s *parentSelector) recordSuccess(parent string, size int64, latency time.Duration) (congested bool) {
+	if latency <= 0 {
+		latency = time.Microsecond
+	}
+	s.Lock()
+	defer s.Unlock()
+	st := s.stat(parent)
+	st.timeouts = 0
+	throughput := float64(size) / latency.Seconds()
+	if st.latency == 0 {
+		st.throughput, st.latency, st.minLatency = throughput, latency, latency
+		return false
+	}
+	st.throughput = ewmaWeight*throughput + (1-ewmaWeight)*st.throughput
+	st.latency = time.Duration(ewmaWeight*float64(latency) + (1-ewmaWeight)*float64(st.latency))
+	if latency < st.minLatency {
+		st.minLatency = latency
+	}
+	return float64(st.latency) > s.latencyTolerance*float64(st.minLatency)
+}
+
+// recordTimeout records a piece timed out from the parent, returns true when the parent becomes blacklisted
+func (s *parentSelector) recordTimeout(parent string) (blacklisted bool) {
+	s.Lock()
+	defer s.Unlock()
+	st := s.stat(parent)
+	st.timeouts++
+	if st.timeouts < s.blacklistTimeouts {
+		return false
+	}
+	st.timeouts = 0
+	st.blacklistUntil = time.Now().Add(s.blacklistDuration)
+	return true
+}
+
+// sort returns the parents not blacklisted, the parents never measured come first to be measured,
+// then the others in the order of throughput. All parents are returned when all of them are blacklisted.
+func (s *parentSelector) sort(parents []*scheduler.PeerPacket_DestPeer) []*scheduler.PeerPacket_DestPeer {
+	s.Lock()
+	defer s.Unlock()
+	var (
+		now       = time.Now()
+		available []*scheduler.PeerPacket_DestPeer
+		all       []*scheduler.PeerPacket_DestPeer
+	)
+	for _, p := range parents {
+		if p == nil {
+			continue
+		}
+		all = append(all, p)
+		if st, ok := s.stats[p.PeerId]; ok && now.Before(st.blacklistUntil) {
+			continue
+		}
+		available = append(available, p)
+	}
+	if len(available) == 0 {
+		available = all
+	}
+	throughput := func(p *scheduler.PeerPacket_DestPeer) (float64, bool) {
+		st, ok := s.stats[p.PeerId]
+		if !ok || st.latency == 0 {
+			return 0, false
+		}
+		return st.throughput, true
+	}
+	sort.SliceStable(available, func(i, j int) bool {
+		ti, oki := throughput(available[i])
+		tj, okj := throughput(available[j])
+		if oki != okj {
+			return !oki
+		}
+		return ti > tj
+	})
+	return available
+}
+
+// stat returns the statistics of the parent, caller must hold the lock
+func (s *parentSelector) stat(parent string) *parentStat {
+	st, ok := s.stats[parent]
+	if !ok {
+		st = &parentStat{}
+		s.stats[parent] = st
+	}
+	return st
+}
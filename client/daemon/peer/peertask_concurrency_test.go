@@ -0,0 +1,134 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+func TestConcurrencyController_AIMD(t *testing.T) {
+	assert := testifyassert.New(t)
+	c := newConcurrencyController(1, 8)
+	c.reset(2)
+	assert.Equal(2, c.getLimit())
+
+	// slots are limited
+	ok, _ := c.tryAcquire()
+	assert.True(ok)
+	ok, _ = c.tryAcquire()
+	assert.True(ok)
+	ok, changed := c.tryAcquire()
+	assert.False(ok)
+	c.release()
+	select {
+	case <-changed:
+	default:
+		assert.Fail("waiting workers should be notified when a slot is released")
+	}
+	ok, _ = c.tryAcquire()
+	assert.True(ok)
+
+	// additive increase, about one per window of successful downloads
+	for i := 0; i < 20; i++ {
+		c.onSuccess(time.Millisecond, false)
+	}
+	assert.Equal(6, c.getLimit())
+	for i := 0; i < 100; i++ {
+		c.onSuccess(time.Millisecond, false)
+	}
+	assert.Equal(8, c.getLimit(), "limit should not exceed max workers")
+
+	// multiplicative decrease, at most once per window
+	c.onCongestion(time.Hour)
+	assert.Equal(4, c.getLimit())
+	c.onCongestion(time.Hour)
+	assert.Equal(4, c.getLimit())
+	c.onSuccess(time.Hour, true)
+	assert.Equal(4, c.getLimit())
+
+	c.window = 0
+	for i := 0; i < 10; i++ {
+		c.lastDecrease = time.Time{}
+		c.onCongestion(0)
+	}
+	assert.Equal(1, c.getLimit(), "limit should not be less than min workers")
+}
+
+func TestParentSelector(t *testing.T) {
+	assert := testifyassert.New(t)
+	s := newParentSelector(adaptiveConcurrencyOption(&config.AdaptiveConcurrencyOption{
+		BlacklistTimeouts: 2,
+		BlacklistDuration: time.Hour,
+	}))
+
+	var parents []*scheduler.PeerPacket_DestPeer
+	for i := 0; i < 4; i++ {
+		parents = append(parents, &scheduler.PeerPacket_DestPeer{PeerId: fmt.Sprintf("parent-%d", i)})
+	}
+	ids := func(peers []*scheduler.PeerPacket_DestPeer) (ids []string) {
+		for _, p := range peers {
+			ids = append(ids, p.PeerId)
+		}
+		return
+	}
+
+	// parents not measured keep the order from scheduler
+	assert.Equal([]string{"parent-0", "parent-1", "parent-2", "parent-3"}, ids(s.sort(parents)))
+
+	assert.False(s.recordSuccess("parent-0", 1024, 100*time.Millisecond))
+	assert.False(s.recordSuccess("parent-1", 1024, 10*time.Millisecond))
+	assert.False(s.recordSuccess("parent-2", 1024, 50*time.Millisecond))
+	// not measured first, then the fastest
+	assert.Equal([]string{"parent-3", "parent-1", "parent-2", "parent-0"}, ids(s.sort(parents)))
+
+	// congested when the latency grows beyond the tolerance
+	var congested bool
+	for i := 0; i < 10 && !congested; i++ {
+		congested = s.recordSuccess("parent-1", 1024, 100*time.Millisecond)
+	}
+	assert.True(congested)
+
+	// blacklisted after consecutive timeouts
+	assert.False(s.recordTimeout("parent-3"))
+	assert.False(s.recordSuccess("parent-3", 1024, 10*time.Millisecond))
+	assert.False(s.recordTimeout("parent-3"))
+	assert.True(s.recordTimeout("parent-3"))
+	assert.NotContains(ids(s.sort(parents)), "parent-3")
+
+	// all parents are returned when all of them are blacklisted
+	for _, p := range parents {
+		s.recordTimeout(p.PeerId)
+		s.recordTimeout(p.PeerId)
+	}
+	assert.Len(s.sort(parents), 4)
+}
+
+func TestIsTimeoutError(t *testing.T) {
+	assert := testifyassert.New(t)
+	assert.True(isTimeoutError(context.DeadlineExceeded))
+	assert.True(isTimeoutError(&pieceDownloadError{err: fmt.Errorf("read: %w", context.DeadlineExceeded), connectionError: true}))
+	assert.False(isTimeoutError(&pieceDownloadError{err: context.Canceled, connectionError: true}))
+	assert.False(isTimeoutError(fmt.Errorf("piece not found")))
+}
@@ -37,6 +37,7 @@ import (
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/internal/dferrors"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/internal/util"
 	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
@@ -72,6 +73,12 @@ type peerTaskConductor struct {
 
 	// needBackSource indicates downloading resource from instead of other peers
 	needBackSource *atomic.Bool
+	// disableBackSource indicates not back source when p2p fails, it works like DisableAutoBackSource for this task
+	disableBackSource bool
+	// priority is sent to parents as the weight of piece requests, zero means the default weight
+	priority int32
+	// seed indicates the task is triggered by scheduler as a seed peer task, it always downloads from source
+	seed bool
 
 	// pieceManager will be used for downloading piece
 	pieceManager    PieceManager
@@ -89,6 +96,7 @@ type peerTaskConductor struct {
 	taskID          string
 	totalPiece      int32
 	digest          string
+	pieceDigestSign string
 	contentLength   *atomic.Int64
 	completedLength *atomic.Int64
 	usedTraffic     *atomic.Uint64
@@ -138,10 +146,67 @@ type peerTaskConductor struct {
 	// limiter will be used when enable per peer task rate limit
 	limiter *rate.Limiter
 
+	// pieceSize stands the size of all pieces except the last one, it's used to locate the pieces of a byte range
+	pieceSize *atomic.Uint32
+	// priorityRanges stands the byte ranges read by range stream tasks, the pieces in them are downloaded first
+	priorityRanges []*clientutil.Range
+	// priorityLock protects priorityRanges
+	priorityLock sync.RWMutex
+	// priorityPieceRequestCh holds the requests of pieces in priorityRanges, workers consume it first
+	priorityPieceRequestCh chan *DownloadPieceRequest
+
+	// partialOutputs stands the existing output files of file tasks, verified pieces in them are reused
+	partialOutputs []string
+	// partialOutputLock protects partialOutputs
+	partialOutputLock sync.RWMutex
+
+	// parentTraffic stands the traffic downloaded from each parent, key: parent peer id, value: *atomic.Uint64
+	parentTraffic sync.Map
+
+	// concurrency limits the concurrent piece downloads when adaptive concurrency is enabled
+	concurrency *concurrencyController
+	// parentSelector orders parents by measured throughput when adaptive concurrency is enabled
+	parentSelector *parentSelector
+
 	startTime time.Time
 }
 
-func (ptm *peerTaskManager) newPeerTaskConductor(ctx context.Context, request *scheduler.PeerTaskRequest, limit rate.Limit) *peerTaskConductor {
+// conductorOption is a functional option for configuring the download policy of peerTaskConductor
+type conductorOption func(pt *peerTaskConductor)
+
+// withLimit overrides the rate limit of peer task, it's ignored when limit is not positive,
+// the burst is not less than the max piece size, otherwise waiting for a piece fails when limit is lower than it
+func withLimit(limit float64) conductorOption {
+	return func(pt *peerTaskConductor) {
+		if limit > 0 {
+			burst := int(limit)
+			if burst < util.DefaultPieceSizeLimit {
+				burst = util.DefaultPieceSizeLimit
+			}
+			pt.limiter = rate.NewLimiter(rate.Limit(limit), burst)
+		}
+	}
+}
+
+// withDisableBackSource sets whether to back source when p2p fails
+func withDisableBackSource(disable bool) conductorOption {
+	return func(pt *peerTaskConductor) {
+		pt.disableBackSource = disable
+	}
+}
+
+// withPriority sets the weight of piece requests sent to parents
+func withPriority(priority int32) conductorOption {
+	return func(pt *peerTaskConductor) {
+		pt.priority = priority
+	}
+}
+
+func (ptm *peerTaskManager) newPeerTaskConductor(
+	ctx context.Context,
+	request *scheduler.PeerTaskRequest,
+	limit rate.Limit,
+	opts ...conductorOption) *peerTaskConductor {
 	// use a new context with span info
 	ctx = trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
 	ctx, span := tracer.Start(ctx, config.SpanPeerTask, trace.WithSpanKind(trace.SpanKindClient))
@@ -200,11 +265,21 @@ func (ptm *peerTaskManager) newPeerTaskConductor(ctx context.Context, request *s
 		completedLength:     atomic.NewInt64(0),
 		usedTraffic:         atomic.NewUint64(0),
 		SugaredLoggerOnWith: log,
+
+		pieceSize:              atomic.NewUint32(0),
+		priorityPieceRequestCh: make(chan *DownloadPieceRequest, config.DefaultPieceChanSize),
 	}
 	ptc.pieceTaskPoller = &pieceTaskPoller{
 		getPiecesMaxRetry: ptm.getPiecesMaxRetry,
 		peerTaskConductor: ptc,
 	}
+	if opt := ptm.adaptiveConcurrency; opt != nil {
+		ptc.concurrency = newConcurrencyController(opt.MinWorkers, opt.MaxWorkers)
+		ptc.parentSelector = newParentSelector(opt)
+	}
+	for _, opt := range opts {
+		opt(ptc)
+	}
 	return ptc
 }
 
@@ -225,28 +300,43 @@ func (pt *peerTaskConductor) register() error {
 	)
 
 	logger.Infof("step 1: peer %s start to register", pt.request.PeerId)
+	if pt.request.HostLoad == nil {
+		pt.request.HostLoad = pt.hostLoad()
+	}
 	schedulerClient := pt.peerTaskManager.schedulerClient
 
-	result, err := schedulerClient.RegisterPeerTask(regCtx, pt.request)
-	regSpan.RecordError(err)
-	regSpan.End()
-
-	if err != nil {
-		if err == context.DeadlineExceeded {
-			logger.Errorf("scheduler did not response in %s", pt.peerTaskManager.schedulerOption.ScheduleTimeout.Duration)
-		}
-		logger.Errorf("step 1: peer %s register failed: %s", pt.request.PeerId, err)
-		if pt.peerTaskManager.schedulerOption.DisableAutoBackSource {
-			logger.Errorf("register peer task failed: %s, peer id: %s, auto back source disabled", err, pt.request.PeerId)
-			pt.span.RecordError(err)
-			pt.cancel(base.Code_SchedError, err.Error())
-			return err
-		}
+	var (
+		result *scheduler.RegisterResult
+		err    error
+	)
+	if pt.seed {
+		// seed peer task is triggered by scheduler, download from source directly without registering
+		regSpan.End()
 		needBackSource = true
-		// can not detect source or scheduler error, create a new dummy scheduler client
 		schedulerClient = &dummySchedulerClient{}
 		result = &scheduler.RegisterResult{TaskId: pt.taskID}
-		logger.Warnf("register peer task failed: %s, peer id: %s, try to back source", err, pt.request.PeerId)
+	} else {
+		result, err = schedulerClient.RegisterPeerTask(regCtx, pt.request)
+		regSpan.RecordError(err)
+		regSpan.End()
+
+		if err != nil {
+			if err == context.DeadlineExceeded {
+				logger.Errorf("scheduler did not response in %s", pt.peerTaskManager.schedulerOption.ScheduleTimeout.Duration)
+			}
+			logger.Errorf("step 1: peer %s register failed: %s", pt.request.PeerId, err)
+			if pt.isBackSourceDisabled() {
+				logger.Errorf("register peer task failed: %s, peer id: %s, auto back source disabled", err, pt.request.PeerId)
+				pt.span.RecordError(err)
+				pt.cancel(base.Code_SchedError, err.Error())
+				return err
+			}
+			needBackSource = true
+			// can not detect source or scheduler error, create a new dummy scheduler client
+			schedulerClient = &dummySchedulerClient{}
+			result = &scheduler.RegisterResult{TaskId: pt.taskID}
+			logger.Warnf("register peer task failed: %s, peer id: %s, try to back source", err, pt.request.PeerId)
+		}
 	}
 
 	pt.Infof("register task success, SizeScope: %s", base.SizeScope_name[int32(result.SizeScope)])
@@ -302,11 +392,77 @@ func (pt *peerTaskConductor) start() error {
 		return err
 	}
 
+	// tiny and small tasks are cheap to download again, only resume normal tasks
+	if pt.sizeScope == base.SizeScope_NORMAL {
+		pt.resume()
+	}
+
 	go pt.broker.Start()
 	go pt.pullPieces()
 	return nil
 }
 
+// resume takes over the pieces of an unfinished task in storage, like the task interrupted by dfdaemon restart,
+// the pieces will be reported to scheduler and only the missing pieces will be downloaded
+func (pt *peerTaskConductor) resume() {
+	resumed := pt.storageManager.ResumeTask(pt.taskID, pt.peerID)
+	if resumed == nil {
+		return
+	}
+
+	pt.lock.Lock()
+	for _, piece := range resumed.Pieces {
+		pt.readyPieces.Set(piece.Num)
+		pt.requestedPieces.Set(piece.Num)
+		pt.completedLength.Add(piece.Range.Length)
+	}
+	pt.lock.Unlock()
+	if resumed.ContentLength > -1 {
+		pt.SetContentLength(resumed.ContentLength)
+	}
+	if resumed.TotalPieces > 0 {
+		pt.SetTotalPieces(resumed.TotalPieces)
+	}
+	if len(resumed.PieceMd5Sign) > 0 {
+		pt.SetPieceMd5Sign(resumed.PieceMd5Sign)
+	}
+	if len(resumed.PieceDigestSign) > 0 {
+		pt.pieceDigestSign = resumed.PieceDigestSign
+	}
+	pt.span.AddEvent("resume unfinished task",
+		trace.WithAttributes(config.AttributeResumedPieceCount.Int(len(resumed.Pieces))))
+	pt.Infof("resume unfinished task, reused pieces: %d, completed length: %d",
+		len(resumed.Pieces), pt.completedLength.Load())
+
+	// advertise the already held pieces, so scheduler can select current peer as parent for them
+	now := time.Now().UnixNano()
+	for _, piece := range resumed.Pieces {
+		err := pt.peerPacketStream.Send(&scheduler.PieceResult{
+			TaskId: pt.GetTaskID(),
+			SrcPid: pt.GetPeerID(),
+			PieceInfo: &base.PieceInfo{
+				PieceNum:        piece.Num,
+				RangeStart:      uint64(piece.Range.Start),
+				RangeSize:       uint32(piece.Range.Length),
+				PieceMd5:        piece.Md5,
+				PieceOffset:     piece.Offset,
+				PieceStyle:      piece.Style,
+				DigestAlgorithm: piece.DigestAlgorithm,
+				PieceDigest:     piece.Digest,
+			},
+			BeginTime:     uint64(now),
+			EndTime:       uint64(now),
+			Success:       true,
+			Code:          base.Code_Success,
+			FinishedCount: pt.readyPieces.Settled(),
+		})
+		if err != nil {
+			pt.Errorf("report resumed piece %d error: %s", piece.Num, err)
+			return
+		}
+	}
+}
+
 func (pt *peerTaskConductor) GetPeerID() string {
 	return pt.peerID
 }
@@ -367,7 +523,20 @@ func (pt *peerTaskConductor) cancel(code base.Code, reason string) {
 	})
 }
 
+// isBackSourceDisabled returns whether to fail instead of back source when scheduler is unavailable
+func (pt *peerTaskConductor) isBackSourceDisabled() bool {
+	return pt.disableBackSource || pt.schedulerOption.DisableAutoBackSource
+}
+
 func (pt *peerTaskConductor) backSource() {
+	// the download policy of task forbids downloading from source, even if scheduler says need back source
+	if pt.disableBackSource {
+		err := fmt.Errorf("%s by download policy", reasonBackSourceDisabled)
+		pt.span.RecordError(err)
+		pt.Errorf(err.Error())
+		pt.cancel(base.Code_ClientError, err.Error())
+		return
+	}
 	backSourceCtx, backSourceSpan := tracer.Start(pt.ctx, config.SpanBackSource)
 	defer backSourceSpan.End()
 	pt.contentLength.Store(-1)
@@ -422,6 +591,9 @@ func (pt *peerTaskConductor) storeTinyPeerTask() {
 			},
 			ContentLength: l,
 			TotalPieces:   1,
+			URL:           pt.request.Url,
+			Tag:           pt.request.UrlMeta.GetTag(),
+			URLMeta:       pt.request.UrlMeta,
 			// TODO check digest
 		})
 	pt.storage = storageDriver
@@ -645,6 +817,9 @@ func (pt *peerTaskConductor) pullSinglePiece() {
 	pt.contentLength.Store(int64(pt.singlePiece.PieceInfo.RangeSize))
 	pt.SetTotalPieces(1)
 	pt.SetPieceMd5Sign(digestutils.Sha256(pt.singlePiece.PieceInfo.PieceMd5))
+	if pt.singlePiece.PieceInfo.PieceDigest != "" {
+		pt.pieceDigestSign = digestutils.Sha256(pt.singlePiece.PieceInfo.PieceDigest)
+	}
 	if err := pt.InitStorage(); err != nil {
 		pt.cancel(base.Code_ClientError, err.Error())
 		span.RecordError(err)
@@ -654,13 +829,14 @@ func (pt *peerTaskConductor) pullSinglePiece() {
 	}
 
 	request := &DownloadPieceRequest{
-		storage: pt.GetStorage(),
-		piece:   pt.singlePiece.PieceInfo,
-		log:     pt.Log(),
-		TaskID:  pt.GetTaskID(),
-		PeerID:  pt.GetPeerID(),
-		DstPid:  pt.singlePiece.DstPid,
-		DstAddr: pt.singlePiece.DstAddr,
+		storage:  pt.GetStorage(),
+		piece:    pt.singlePiece.PieceInfo,
+		log:      pt.Log(),
+		TaskID:   pt.GetTaskID(),
+		PeerID:   pt.GetPeerID(),
+		DstPid:   pt.singlePiece.DstPid,
+		DstAddr:  pt.singlePiece.DstAddr,
+		Priority: pt.priority,
 	}
 
 	if result, err := pt.pieceManager.DownloadPiece(ctx, request); err == nil {
@@ -758,6 +934,11 @@ loop:
 			_ = pt.UpdateStorage()
 			pt.Debugf("update digest: %s", pt.digest)
 		}
+		if len(piecePacket.PieceDigestSign) > 0 && len(pt.pieceDigestSign) == 0 {
+			pt.pieceDigestSign = piecePacket.PieceDigestSign
+			_ = pt.UpdateStorage()
+			pt.Debugf("update piece digest sign: %s", pt.pieceDigestSign)
+		}
 
 		// update content length
 		if piecePacket.ContentLength > -1 {
@@ -766,6 +947,13 @@ loop:
 			pt.Debugf("update content length: %d", pt.GetContentLength())
 		}
 
+		// resumed pieces may cover the whole task already
+		if pt.completedLength.Load() > 0 && pt.isCompleted() {
+			pt.Infof("all pieces are ready, no more pieces to download")
+			pt.Done()
+			break loop
+		}
+
 		// 3. dispatch piece request to all workers
 		pt.dispatchPieceRequest(pieceRequestCh, piecePacket)
 
@@ -799,6 +987,12 @@ func (pt *peerTaskConductor) init(piecePacket *base.PiecePacket, pieceBufferSize
 		return nil, false
 	}
 	pc := pt.peerPacket.Load().(*scheduler.PeerPacket).ParallelCount
+	if pt.concurrency != nil {
+		// start with the parallel count from scheduler, the workers more than the limit wait for slots
+		pt.concurrency.reset(int(pc))
+		pc = int32(pt.peerTaskManager.adaptiveConcurrency.MaxWorkers)
+		pt.Infof("adaptive concurrency enabled, initial limit: %d, max workers: %d", pt.concurrency.getLimit(), pc)
+	}
 	pieceRequestCh := make(chan *DownloadPieceRequest, pieceBufferSize)
 	for i := int32(0); i < pc; i++ {
 		go pt.downloadPieceWorker(i, pieceRequestCh)
@@ -823,7 +1017,7 @@ func (pt *peerTaskConductor) waitFirstPeerPacket() (done bool, backSource bool)
 		pt.backSource()
 		return false, true
 	case <-time.After(pt.schedulerOption.ScheduleTimeout.Duration):
-		if pt.schedulerOption.DisableAutoBackSource {
+		if pt.isBackSourceDisabled() {
 			pt.cancel(base.Code_ClientScheduleTimeout, reasonBackSourceDisabled)
 			err := fmt.Errorf("%s, auto back source disabled", pt.failedReason)
 			pt.span.RecordError(err)
@@ -860,7 +1054,7 @@ func (pt *peerTaskConductor) waitAvailablePeerPacket() (int32, bool) {
 		// TODO optimize back source when already downloaded some pieces
 		pt.backSource()
 	case <-time.After(pt.schedulerOption.ScheduleTimeout.Duration):
-		if pt.schedulerOption.DisableAutoBackSource {
+		if pt.isBackSourceDisabled() {
 			pt.cancel(base.Code_ClientScheduleTimeout, reasonBackSourceDisabled)
 			err := fmt.Errorf("%s, auto back source disabled", pt.failedReason)
 			pt.span.RecordError(err)
@@ -892,17 +1086,24 @@ func (pt *peerTaskConductor) dispatchPieceRequest(pieceRequestCh chan *DownloadP
 		if !pt.requestedPieces.IsSet(piece.PieceNum) {
 			pt.requestedPieces.Set(piece.PieceNum)
 		}
+		pt.updatePieceSize(piece)
 		req := &DownloadPieceRequest{
-			storage: pt.GetStorage(),
-			piece:   piece,
-			log:     pt.Log(),
-			TaskID:  pt.GetTaskID(),
-			PeerID:  pt.GetPeerID(),
-			DstPid:  piecePacket.DstPid,
-			DstAddr: piecePacket.DstAddr,
+			storage:    pt.GetStorage(),
+			piece:      piece,
+			log:        pt.Log(),
+			TaskID:     pt.GetTaskID(),
+			PeerID:     pt.GetPeerID(),
+			DstPid:     piecePacket.DstPid,
+			DstAddr:    piecePacket.DstAddr,
+			DstRPCAddr: piecePacket.DstRpcAddr,
+			Priority:   pt.priority,
+		}
+		requestCh := pieceRequestCh
+		if pt.isPriorityPiece(piece.PieceNum) {
+			requestCh = pt.priorityPieceRequestCh
 		}
 		select {
-		case pieceRequestCh <- req:
+		case requestCh <- req:
 		case <-pt.successCh:
 			pt.Infof("peer task success, stop dispatch piece request")
 		case <-pt.failCh:
@@ -931,58 +1132,147 @@ func (pt *peerTaskConductor) waitFailedPiece() (int32, bool) {
 
 func (pt *peerTaskConductor) downloadPieceWorker(id int32, requests chan *DownloadPieceRequest) {
 	for {
+		if pt.concurrency != nil && !pt.waitConcurrency(id) {
+			return
+		}
+		var request *DownloadPieceRequest
+		// consume the requests of priority pieces first
 		select {
-		case request := <-requests:
-			pt.lock.RLock()
-			if pt.readyPieces.IsSet(request.piece.PieceNum) {
-				pt.lock.RUnlock()
-				pt.Log().Debugf("piece %d is already downloaded, skip", request.piece.PieceNum)
-				continue
-			}
-			pt.lock.RUnlock()
-
-			ctx, span := tracer.Start(pt.ctx, fmt.Sprintf(config.SpanDownloadPiece, request.piece.PieceNum))
-			span.SetAttributes(config.AttributePiece.Int(int(request.piece.PieceNum)))
-			span.SetAttributes(config.AttributePieceWorker.Int(int(id)))
-
-			// wait limit
-			if pt.limiter != nil && !pt.waitLimit(ctx, request) {
-				span.SetAttributes(config.AttributePieceSuccess.Bool(false))
-				span.End()
+		case request = <-pt.priorityPieceRequestCh:
+		default:
+			select {
+			case request = <-pt.priorityPieceRequestCh:
+			case request = <-requests:
+			case <-pt.successCh:
+				pt.Infof("peer task success, peer download worker #%d exit", id)
+				return
+			case <-pt.failCh:
+				pt.Errorf("peer task fail, peer download worker #%d exit", id)
 				return
 			}
+		}
+		ok := pt.downloadPiece(id, request)
+		if pt.concurrency != nil {
+			pt.concurrency.release()
+		}
+		if !ok {
+			return
+		}
+	}
+}
 
-			pt.Debugf("peer download worker #%d receive piece task, "+
-				"dest peer id: %s, piece num: %d, range start: %d, range size: %d",
-				id, request.DstPid, request.piece.PieceNum, request.piece.RangeStart, request.piece.RangeSize)
-			// download piece
-			// result is always not nil, pieceManager will report begin and end time
-			result, err := pt.pieceManager.DownloadPiece(ctx, request)
-			if err != nil {
-				// send to fail chan and retry
-				pt.failedPieceCh <- request.piece.PieceNum
-				pt.ReportPieceResult(request, result, err)
-				span.SetAttributes(config.AttributePieceSuccess.Bool(false))
-				span.End()
-				continue
-			} else {
-				// broadcast success piece
-				pt.reportSuccessResult(request, result)
-				pt.PublishPieceInfo(request.piece.PieceNum, request.piece.RangeSize)
-			}
-
-			span.SetAttributes(config.AttributePieceSuccess.Bool(true))
-			span.End()
+// waitConcurrency waits for a download slot of adaptive concurrency, returns false when the peer task is finished
+func (pt *peerTaskConductor) waitConcurrency(id int32) bool {
+	for {
+		ok, changed := pt.concurrency.tryAcquire()
+		if ok {
+			return true
+		}
+		select {
+		case <-changed:
 		case <-pt.successCh:
 			pt.Infof("peer task success, peer download worker #%d exit", id)
-			return
+			return false
 		case <-pt.failCh:
 			pt.Errorf("peer task fail, peer download worker #%d exit", id)
-			return
+			return false
 		}
 	}
 }
 
+// recordParent feeds the piece download result from the parent back to adaptive concurrency and parent selection,
+// the parent timed out repeatedly is blacklisted locally and reported to scheduler for rescheduling
+func (pt *peerTaskConductor) recordParent(request *DownloadPieceRequest, result *DownloadPieceResult, err error) {
+	if pt.concurrency == nil {
+		return
+	}
+	latency := time.Duration(result.FinishTime - result.BeginTime)
+	if err == nil {
+		congested := pt.parentSelector.recordSuccess(request.DstPid, int64(request.piece.RangeSize), latency)
+		pt.concurrency.onSuccess(latency, congested)
+		return
+	}
+	if !isTimeoutError(err) {
+		return
+	}
+	pt.concurrency.onCongestion(latency)
+	if !pt.parentSelector.recordTimeout(request.DstPid) {
+		return
+	}
+	pt.Warnf("parent %s timed out repeatedly, blacklist it, concurrency limit: %d", request.DstPid, pt.concurrency.getLimit())
+	pt.span.AddEvent("blacklist parent", trace.WithAttributes(config.AttributeTargetPeerID.String(request.DstPid)))
+	sendError := pt.peerPacketStream.Send(&scheduler.PieceResult{
+		TaskId:        pt.GetTaskID(),
+		SrcPid:        pt.GetPeerID(),
+		DstPid:        request.DstPid,
+		PieceInfo:     &base.PieceInfo{},
+		Success:       false,
+		Code:          base.Code_RequestTimeOut,
+		HostLoad:      pt.hostLoad(),
+		FinishedCount: pt.readyPieces.Settled(),
+	})
+	if sendError != nil {
+		pt.Errorf("report blacklisted parent %s error: %s", request.DstPid, sendError)
+	}
+}
+
+// hostLoad returns the latest host load, it's nil when host load reporting is disabled
+func (pt *peerTaskConductor) hostLoad() *base.HostLoad {
+	if pt.peerTaskManager.hostLoadSampler == nil {
+		return nil
+	}
+	return pt.peerTaskManager.hostLoadSampler.Load()
+}
+
+// downloadPiece downloads one piece in worker, returns false when the worker should exit
+func (pt *peerTaskConductor) downloadPiece(workerID int32, request *DownloadPieceRequest) bool {
+	pt.lock.RLock()
+	if pt.readyPieces.IsSet(request.piece.PieceNum) {
+		pt.lock.RUnlock()
+		pt.Log().Debugf("piece %d is already downloaded, skip", request.piece.PieceNum)
+		return true
+	}
+	pt.lock.RUnlock()
+
+	// reuse the verified piece in partial outputs
+	if pt.reusePartialOutput(request) {
+		return true
+	}
+
+	ctx, span := tracer.Start(pt.ctx, fmt.Sprintf(config.SpanDownloadPiece, request.piece.PieceNum))
+	span.SetAttributes(config.AttributePiece.Int(int(request.piece.PieceNum)))
+	span.SetAttributes(config.AttributePieceWorker.Int(int(workerID)))
+	defer span.End()
+
+	// wait limit
+	if pt.limiter != nil && !pt.waitLimit(ctx, request) {
+		span.SetAttributes(config.AttributePieceSuccess.Bool(false))
+		return false
+	}
+
+	pt.Debugf("peer download worker #%d receive piece task, "+
+		"dest peer id: %s, piece num: %d, range start: %d, range size: %d",
+		workerID, request.DstPid, request.piece.PieceNum, request.piece.RangeStart, request.piece.RangeSize)
+	// download piece
+	// result is always not nil, pieceManager will report begin and end time
+	result, err := pt.pieceManager.DownloadPiece(ctx, request)
+	pt.recordParent(request, result, err)
+	if err != nil {
+		// send to fail chan and retry
+		pt.failedPieceCh <- request.piece.PieceNum
+		pt.ReportPieceResult(request, result, err)
+		span.SetAttributes(config.AttributePieceSuccess.Bool(false))
+		return true
+	}
+
+	// broadcast success piece
+	pt.addParentTraffic(request.DstPid, uint64(request.piece.RangeSize))
+	pt.reportSuccessResult(request, result)
+	pt.PublishPieceInfo(request.piece.PieceNum, request.piece.RangeSize)
+	span.SetAttributes(config.AttributePieceSuccess.Bool(true))
+	return true
+}
+
 func (pt *peerTaskConductor) waitLimit(ctx context.Context, request *DownloadPieceRequest) bool {
 	_, waitSpan := tracer.Start(ctx, config.SpanWaitPieceLimit)
 	err := pt.limiter.WaitN(pt.ctx, int(request.piece.RangeSize))
@@ -1003,7 +1293,7 @@ func (pt *peerTaskConductor) waitLimit(ctx context.Context, request *DownloadPie
 		PieceInfo:     request.piece,
 		Success:       false,
 		Code:          base.Code_ClientRequestLimitFail,
-		HostLoad:      nil,
+		HostLoad:      pt.hostLoad(),
 		FinishedCount: 0, // update by peer task
 	})
 	if sendError != nil {
@@ -1022,6 +1312,10 @@ func (pt *peerTaskConductor) getNextPieceNum(cur int32) (int32, bool) {
 	if pt.isCompleted() {
 		return -1, false
 	}
+	// pieces read by range stream tasks first
+	if i, ok := pt.getNextPriorityPieceNum(); ok {
+		return i, true
+	}
 	i := cur
 	// try to find next not requested piece
 	for ; pt.requestedPieces.IsSet(i); i++ {
@@ -1037,6 +1331,84 @@ func (pt *peerTaskConductor) getNextPieceNum(cur int32) (int32, bool) {
 	return i, true
 }
 
+// updatePieceSize records the piece size from piece info, all pieces have the same size except the last one
+func (pt *peerTaskConductor) updatePieceSize(piece *base.PieceInfo) {
+	if pt.pieceSize.Load() > 0 {
+		return
+	}
+	if piece.PieceNum > 0 {
+		pt.pieceSize.CAS(0, uint32(piece.RangeStart/uint64(piece.PieceNum)))
+	} else if piece.RangeSize > 0 {
+		pt.pieceSize.CAS(0, piece.RangeSize)
+	}
+}
+
+// addPriorityRange marks the pieces in the byte range to be downloaded first,
+// the returned function removes the mark
+func (pt *peerTaskConductor) addPriorityRange(rg *clientutil.Range) func() {
+	pt.priorityLock.Lock()
+	pt.priorityRanges = append(pt.priorityRanges, rg)
+	pt.priorityLock.Unlock()
+	pt.Debugf("add priority range %s", rg.String())
+	return func() {
+		pt.priorityLock.Lock()
+		defer pt.priorityLock.Unlock()
+		for i, r := range pt.priorityRanges {
+			if r == rg {
+				pt.priorityRanges = append(pt.priorityRanges[:i], pt.priorityRanges[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// priorityPieceNums returns the first and last piece number covering the byte range
+func (pt *peerTaskConductor) priorityPieceNums(rg *clientutil.Range) (first int32, last int32, ok bool) {
+	pieceSize := int64(pt.pieceSize.Load())
+	if pieceSize == 0 || rg.Length <= 0 {
+		return -1, -1, false
+	}
+	first = int32(rg.Start / pieceSize)
+	end := rg.Start + rg.Length - 1
+	if contentLength := pt.GetContentLength(); contentLength > 0 && end >= contentLength {
+		end = contentLength - 1
+	}
+	last = int32(end / pieceSize)
+	if pt.totalPiece > 0 && last >= pt.totalPiece {
+		last = pt.totalPiece - 1
+	}
+	return first, last, first <= last
+}
+
+func (pt *peerTaskConductor) isPriorityPiece(num int32) bool {
+	pt.priorityLock.RLock()
+	defer pt.priorityLock.RUnlock()
+	for _, rg := range pt.priorityRanges {
+		if first, last, ok := pt.priorityPieceNums(rg); ok && num >= first && num <= last {
+			return true
+		}
+	}
+	return false
+}
+
+// getNextPriorityPieceNum returns the first not requested piece in priority ranges
+func (pt *peerTaskConductor) getNextPriorityPieceNum() (int32, bool) {
+	pt.priorityLock.RLock()
+	defer pt.priorityLock.RUnlock()
+	for _, rg := range pt.priorityRanges {
+		first, last, ok := pt.priorityPieceNums(rg)
+		if !ok {
+			continue
+		}
+		for i := first; i <= last; i++ {
+			if !pt.requestedPieces.IsSet(i) {
+				return i, true
+			}
+		}
+	}
+	return -1, false
+}
+
 func (pt *peerTaskConductor) recoverFromPanic() {
 	if r := recover(); r != nil {
 		pt.Errorf("recovered from panic %q. Call stack:\n%v", r, string(debug.Stack()))
@@ -1072,7 +1444,7 @@ func (pt *peerTaskConductor) reportSuccessResult(request *DownloadPieceRequest,
 			EndTime:       uint64(result.FinishTime),
 			Success:       true,
 			Code:          base.Code_Success,
-			HostLoad:      nil, // TODO(jim): update host load
+			HostLoad:      pt.hostLoad(),
 			FinishedCount: pt.readyPieces.Settled(),
 			// TODO range_start, range_size, piece_md5, piece_offset, piece_style
 		})
@@ -1097,7 +1469,7 @@ func (pt *peerTaskConductor) reportFailResult(request *DownloadPieceRequest, res
 		EndTime:       uint64(result.FinishTime),
 		Success:       false,
 		Code:          code,
-		HostLoad:      nil,
+		HostLoad:      pt.hostLoad(),
 		FinishedCount: pt.readyPieces.Settled(),
 	})
 	if err != nil {
@@ -1120,9 +1492,13 @@ func (pt *peerTaskConductor) InitStorage() (err error) {
 				PeerID: pt.GetPeerID(),
 				TaskID: pt.GetTaskID(),
 			},
-			ContentLength: pt.GetContentLength(),
-			TotalPieces:   pt.GetTotalPieces(),
-			PieceMd5Sign:  pt.GetPieceMd5Sign(),
+			ContentLength:   pt.GetContentLength(),
+			TotalPieces:     pt.GetTotalPieces(),
+			PieceMd5Sign:    pt.GetPieceMd5Sign(),
+			PieceDigestSign: pt.pieceDigestSign,
+			URL:             pt.request.Url,
+			Tag:             pt.request.UrlMeta.GetTag(),
+			URLMeta:         pt.request.UrlMeta,
 		})
 	if err != nil {
 		pt.Log().Errorf("register task to storage manager failed: %s", err)
@@ -1138,9 +1514,10 @@ func (pt *peerTaskConductor) UpdateStorage() error {
 				PeerID: pt.GetPeerID(),
 				TaskID: pt.GetTaskID(),
 			},
-			ContentLength: pt.GetContentLength(),
-			TotalPieces:   pt.GetTotalPieces(),
-			PieceMd5Sign:  pt.GetPieceMd5Sign(),
+			ContentLength:   pt.GetContentLength(),
+			TotalPieces:     pt.GetTotalPieces(),
+			PieceMd5Sign:    pt.GetPieceMd5Sign(),
+			PieceDigestSign: pt.pieceDigestSign,
 		})
 	if err != nil {
 		pt.Log().Errorf("update task to storage manager failed: %s", err)
@@ -1317,6 +1694,10 @@ func (pt *peerTaskConductor) Validate() error {
 }
 
 func (pt *peerTaskConductor) PublishPieceInfo(pieceNum int32, size uint32) {
+	// back source pieces are published in order, the first piece stands the piece size
+	if pieceNum == 0 {
+		pt.pieceSize.CAS(0, size)
+	}
 	// mark piece ready
 	pt.lock.Lock()
 	if pt.readyPieces.IsSet(pieceNum) {
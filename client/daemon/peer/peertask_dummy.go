@@ -48,6 +48,14 @@ func (d *dummySchedulerClient) LeaveTask(ctx context.Context, target *scheduler.
 	return nil
 }
 
+func (d *dummySchedulerClient) AnnounceHost(ctx context.Context, req *scheduler.AnnounceHostRequest, option ...grpc.CallOption) error {
+	return nil
+}
+
+func (d *dummySchedulerClient) LeaveHost(ctx context.Context, req *scheduler.LeaveHostRequest, option ...grpc.CallOption) error {
+	return nil
+}
+
 func (d *dummySchedulerClient) Close() error {
 	return nil
 }
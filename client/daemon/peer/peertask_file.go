@@ -38,6 +38,8 @@ type FileTaskRequest struct {
 	DisableBackSource bool
 	Pattern           string
 	Callsystem        string
+	// ResumeOutput indicates reusing the verified pieces in the existing output file
+	ResumeOutput bool
 }
 
 // FileTask represents a peer task to download a file
@@ -92,6 +94,9 @@ func (ptm *peerTaskManager) newFileTask(
 	if ptm.enablePrefetch && request.UrlMeta.Range != "" {
 		go ptm.prefetch(&request.PeerTaskRequest)
 	}
+	if request.ResumeOutput {
+		ptc.addPartialOutput(request.Output)
+	}
 	ctx, span := tracer.Start(ctx, config.SpanFileTask, trace.WithSpanKind(trace.SpanKindClient))
 
 	pt := &fileTask{
@@ -18,15 +18,18 @@ package peer
 
 import (
 	"context"
+	"errors"
 	"io"
 	"sync"
 
 	"github.com/go-http-utils/headers"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/atomic"
 	"golang.org/x/time/rate"
 
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/hostload"
 	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
@@ -47,10 +50,16 @@ type TaskManager interface {
 	// tiny stands task file is tiny and task is done
 	StartStreamTask(ctx context.Context, req *StreamTaskRequest) (
 		readCloser io.ReadCloser, attribute map[string]string, err error)
+	// StartSeedTask starts a seed peer task triggered by scheduler, it downloads from source
+	// and reports the ready pieces by the progress channel in response
+	StartSeedTask(ctx context.Context, req *SeedTaskRequest) (*SeedTaskResponse, error)
 
 	IsPeerTaskRunning(id string) bool
 
-	// Stop stops the PeerTaskManager
+	// ListRunningPeerTasks lists the status of all running peer tasks
+	ListRunningPeerTasks() []*PeerTaskStatus
+
+	// Stop stops the PeerTaskManager, new peer tasks are rejected and the running peer tasks continue
 	Stop(ctx context.Context) error
 }
 
@@ -115,12 +124,26 @@ type peerTaskManager struct {
 	enableMultiplex bool
 	// enablePrefetch indicates to prefetch the whole files of ranged requests
 	enablePrefetch bool
+	// enableRangeFromParent indicates to read ranged requests from the peer task of the whole file
+	enableRangeFromParent bool
 
 	calculateDigest bool
 
 	getPiecesMaxRetry int
+
+	// adaptiveConcurrency adapts the piece download concurrency and parent selection of peer tasks when it's set
+	adaptiveConcurrency *config.AdaptiveConcurrencyOption
+
+	// hostLoadSampler provides the host load reported to scheduler, it's nil when host load reporting is disabled
+	hostLoadSampler hostload.Sampler
+
+	// stopped indicates to reject new peer tasks
+	stopped atomic.Bool
 }
 
+// ErrTaskManagerStopped is returned when starting peer tasks after the peer task manager is stopped
+var ErrTaskManagerStopped = errors.New("peer task manager is stopped")
+
 func NewPeerTaskManager(
 	host *scheduler.PeerHost,
 	pieceManager PieceManager,
@@ -130,22 +153,28 @@ func NewPeerTaskManager(
 	perPeerRateLimit rate.Limit,
 	multiplex bool,
 	prefetch bool,
+	rangeFromParent bool,
 	calculateDigest bool,
-	getPiecesMaxRetry int) (TaskManager, error) {
+	getPiecesMaxRetry int,
+	adaptiveConcurrency *config.AdaptiveConcurrencyOption,
+	hostLoadSampler hostload.Sampler) (TaskManager, error) {
 
 	ptm := &peerTaskManager{
-		host:              host,
-		runningPeerTasks:  sync.Map{},
-		conductorLock:     &sync.Mutex{},
-		pieceManager:      pieceManager,
-		storageManager:    storageManager,
-		schedulerClient:   schedulerClient,
-		schedulerOption:   schedulerOption,
-		perPeerRateLimit:  perPeerRateLimit,
-		enableMultiplex:   multiplex,
-		enablePrefetch:    prefetch,
-		calculateDigest:   calculateDigest,
-		getPiecesMaxRetry: getPiecesMaxRetry,
+		host:                  host,
+		runningPeerTasks:      sync.Map{},
+		conductorLock:         &sync.Mutex{},
+		pieceManager:          pieceManager,
+		storageManager:        storageManager,
+		schedulerClient:       schedulerClient,
+		schedulerOption:       schedulerOption,
+		perPeerRateLimit:      perPeerRateLimit,
+		enableMultiplex:       multiplex,
+		enablePrefetch:        prefetch,
+		enableRangeFromParent: rangeFromParent,
+		calculateDigest:       calculateDigest,
+		getPiecesMaxRetry:     getPiecesMaxRetry,
+		adaptiveConcurrency:   adaptiveConcurrencyOption(adaptiveConcurrency),
+		hostLoadSampler:       hostLoadSampler,
 	}
 	return ptm, nil
 }
@@ -163,8 +192,9 @@ func (ptm *peerTaskManager) findPeerTaskConductor(taskID string) (*peerTaskCondu
 func (ptm *peerTaskManager) getPeerTaskConductor(ctx context.Context,
 	taskID string,
 	request *scheduler.PeerTaskRequest,
-	limit rate.Limit) (*peerTaskConductor, error) {
-	ptc, created, err := ptm.getOrCreatePeerTaskConductor(ctx, taskID, request, limit)
+	limit rate.Limit,
+	opts ...conductorOption) (*peerTaskConductor, error) {
+	ptc, created, err := ptm.getOrCreatePeerTaskConductor(ctx, taskID, request, limit, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -182,12 +212,13 @@ func (ptm *peerTaskManager) getOrCreatePeerTaskConductor(
 	ctx context.Context,
 	taskID string,
 	request *scheduler.PeerTaskRequest,
-	limit rate.Limit) (*peerTaskConductor, bool, error) {
+	limit rate.Limit,
+	opts ...conductorOption) (*peerTaskConductor, bool, error) {
 	if ptc, ok := ptm.findPeerTaskConductor(taskID); ok {
 		logger.Debugf("peer task found: %s/%s", ptc.taskID, ptc.peerID)
 		return ptc, false, nil
 	}
-	ptc := ptm.newPeerTaskConductor(ctx, request, limit)
+	ptc := ptm.newPeerTaskConductor(ctx, request, limit, opts...)
 
 	ptm.conductorLock.Lock()
 	// double check
@@ -203,7 +234,8 @@ func (ptm *peerTaskManager) getOrCreatePeerTaskConductor(
 	return ptc, true, nil
 }
 
-func (ptm *peerTaskManager) prefetch(request *scheduler.PeerTaskRequest) {
+// newParentPeerTaskRequest builds the peer task request of the whole file for a ranged request
+func (ptm *peerTaskManager) newParentPeerTaskRequest(request *scheduler.PeerTaskRequest) *scheduler.PeerTaskRequest {
 	req := &scheduler.PeerTaskRequest{
 		Url:         request.Url,
 		PeerId:      request.PeerId,
@@ -223,8 +255,13 @@ func (ptm *peerTaskManager) prefetch(request *scheduler.PeerTaskRequest) {
 		}
 		req.UrlMeta.Header[k] = v
 	}
-	taskID := idgen.TaskID(req.Url, req.UrlMeta)
 	req.PeerId = idgen.PeerID(req.PeerHost.Ip)
+	return req
+}
+
+func (ptm *peerTaskManager) prefetch(request *scheduler.PeerTaskRequest) {
+	req := ptm.newParentPeerTaskRequest(request)
+	taskID := idgen.TaskID(req.Url, req.UrlMeta)
 
 	var limit = rate.Inf
 	if ptm.perPeerRateLimit > 0 {
@@ -239,6 +276,9 @@ func (ptm *peerTaskManager) prefetch(request *scheduler.PeerTaskRequest) {
 }
 
 func (ptm *peerTaskManager) StartFileTask(ctx context.Context, req *FileTaskRequest) (chan *FileTaskProgress, *TinyData, error) {
+	if ptm.stopped.Load() {
+		return nil, nil, ErrTaskManagerStopped
+	}
 	if ptm.enableMultiplex {
 		progress, ok := ptm.tryReuseFilePeerTask(ctx, req)
 		if ok {
@@ -265,6 +305,9 @@ func (ptm *peerTaskManager) StartFileTask(ctx context.Context, req *FileTaskRequ
 }
 
 func (ptm *peerTaskManager) StartStreamTask(ctx context.Context, req *StreamTaskRequest) (io.ReadCloser, map[string]string, error) {
+	if ptm.stopped.Load() {
+		return nil, nil, ErrTaskManagerStopped
+	}
 	peerTaskRequest := &scheduler.PeerTaskRequest{
 		Url:         req.URL,
 		UrlMeta:     req.URLMeta,
@@ -274,6 +317,13 @@ func (ptm *peerTaskManager) StartStreamTask(ctx context.Context, req *StreamTask
 		IsMigrating: false,
 	}
 
+	// the download policy only takes effect when the peer task conductor is created by this request
+	opts := []conductorOption{
+		withLimit(req.Limit),
+		withDisableBackSource(req.DisableBackSource),
+		withPriority(req.Priority),
+	}
+
 	if ptm.enableMultiplex {
 		r, attr, ok := ptm.tryReuseStreamPeerTask(ctx, req)
 		if ok {
@@ -282,7 +332,15 @@ func (ptm *peerTaskManager) StartStreamTask(ctx context.Context, req *StreamTask
 		}
 	}
 
-	pt, err := ptm.newStreamTask(ctx, peerTaskRequest)
+	if ptm.enableRangeFromParent && req.Range != nil {
+		pt, err := ptm.newRangeStreamTask(ctx, peerTaskRequest, req.Range, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pt.Start(ctx)
+	}
+
+	pt, err := ptm.newStreamTask(ctx, peerTaskRequest, opts...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -293,7 +351,8 @@ func (ptm *peerTaskManager) StartStreamTask(ctx context.Context, req *StreamTask
 }
 
 func (ptm *peerTaskManager) Stop(ctx context.Context) error {
-	// TODO
+	ptm.stopped.Store(true)
+	logger.Infof("peer task manager stopped, new peer tasks are rejected")
 	return nil
 }
 
@@ -48,6 +48,7 @@ import (
 	"d7y.io/dragonfly/v2/internal/dferrors"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/dfnet"
+	"d7y.io/dragonfly/v2/internal/util"
 	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/pkg/rpc"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
@@ -264,6 +265,7 @@ func setupMockManager(ctrl *gomock.Controller, ts *testSpec, opt componentsOptio
 		schedulerOption: config.SchedulerOption{
 			ScheduleTimeout: scheduleTimeout,
 		},
+		adaptiveConcurrency: adaptiveConcurrencyOption(ts.adaptiveConcurrency),
 	}
 	return &mockManager{
 		testSpec:        ts,
@@ -298,6 +300,8 @@ type testSpec struct {
 	scheduleTimeout time.Duration
 	backSource      bool
 
+	adaptiveConcurrency *config.AdaptiveConcurrencyOption
+
 	mockPieceDownloader  func(ctrl *gomock.Controller, taskData []byte, pieceSize int) PieceDownloader
 	mockHTTPSourceClient func(t *testing.T, ctrl *gomock.Controller, rg *clientutil.Range, taskData []byte, url string) source.ResourceClient
 
@@ -338,6 +342,21 @@ func TestPeerTaskManager_TaskSuite(t *testing.T) {
 			mockPieceDownloader:  commonPieceDownloader,
 			mockHTTPSourceClient: nil,
 		},
+		{
+			name:                 "normal size scope - p2p - adaptive concurrency",
+			taskData:             testBytes,
+			pieceParallelCount:   4,
+			pieceSize:            1024,
+			peerID:               "normal-size-peer-adaptive",
+			url:                  "http://localhost/test/data",
+			sizeScope:            base.SizeScope_NORMAL,
+			mockPieceDownloader:  commonPieceDownloader,
+			mockHTTPSourceClient: nil,
+			adaptiveConcurrency: &config.AdaptiveConcurrencyOption{
+				MinWorkers: 1,
+				MaxWorkers: 8,
+			},
+		},
 		{
 			name:                 "small size scope - p2p",
 			taskData:             testBytes,
@@ -786,3 +805,46 @@ func (ts *testSpec) runConductorTest(assert *testifyassert.Assertions, require *
 	assert.Nil(err, "load output file should be ok")
 	assert.Equal(ts.taskData, outputBytes, "file output and desired output must match")
 }
+
+func TestPeerTaskManager_Stop(t *testing.T) {
+	assert := testifyassert.New(t)
+	ptm := &peerTaskManager{}
+	assert.Nil(ptm.Stop(context.Background()))
+
+	_, _, err := ptm.StartFileTask(context.Background(), &FileTaskRequest{})
+	assert.ErrorIs(err, ErrTaskManagerStopped)
+	_, _, err = ptm.StartStreamTask(context.Background(), &StreamTaskRequest{})
+	assert.ErrorIs(err, ErrTaskManagerStopped)
+	_, err = ptm.StartSeedTask(context.Background(), &SeedTaskRequest{})
+	assert.ErrorIs(err, ErrTaskManagerStopped)
+}
+
+func TestPeerTaskManager_ConductorOptions(t *testing.T) {
+	assert := testifyassert.New(t)
+	ptm := &peerTaskManager{
+		host:             &scheduler.PeerHost{Ip: "127.0.0.1"},
+		perPeerRateLimit: rate.Limit(1024),
+	}
+	request := &scheduler.PeerTaskRequest{
+		Url:     "http://localhost/test/data",
+		UrlMeta: &base.UrlMeta{},
+		PeerId:  "peer",
+	}
+
+	ptc := ptm.newPeerTaskConductor(context.Background(), request, ptm.perPeerRateLimit)
+	assert.Equal(rate.Limit(1024), ptc.limiter.Limit())
+	assert.False(ptc.disableBackSource)
+	assert.False(ptc.isBackSourceDisabled())
+	assert.Equal(int32(0), ptc.priority)
+
+	ptc = ptm.newPeerTaskConductor(context.Background(), request, ptm.perPeerRateLimit,
+		withLimit(0), withDisableBackSource(true), withPriority(3))
+	assert.Equal(rate.Limit(1024), ptc.limiter.Limit(), "zero limit should not override")
+	assert.True(ptc.isBackSourceDisabled())
+	assert.Equal(int32(3), ptc.priority)
+
+	ptc = ptm.newPeerTaskConductor(context.Background(), request, ptm.perPeerRateLimit, withLimit(4096))
+	assert.Equal(rate.Limit(4096), ptc.limiter.Limit())
+	// the piece larger than limit is throttled, not rejected
+	assert.Nil(ptc.limiter.WaitN(context.Background(), util.DefaultPieceSize))
+}
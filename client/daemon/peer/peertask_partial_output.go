@@ -0,0 +1,115 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+)
+
+// addPartialOutput records the existing output file, the pieces in it will be verified and reused
+func (pt *peerTaskConductor) addPartialOutput(output string) {
+	info, err := os.Stat(output)
+	if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+		return
+	}
+	pt.partialOutputLock.Lock()
+	defer pt.partialOutputLock.Unlock()
+	for _, o := range pt.partialOutputs {
+		if o == output {
+			return
+		}
+	}
+	pt.Infof("resume from partial output %s, size: %d", output, info.Size())
+	pt.partialOutputs = append(pt.partialOutputs, output)
+}
+
+// reusePartialOutput writes the piece from partial outputs when its digest matches,
+// pieces without digest are always downloaded
+func (pt *peerTaskConductor) reusePartialOutput(request *DownloadPieceRequest) bool {
+	if request.piece.PieceMd5 == "" && request.piece.PieceDigest == "" {
+		return false
+	}
+	pt.partialOutputLock.RLock()
+	outputs := pt.partialOutputs
+	pt.partialOutputLock.RUnlock()
+
+	for _, output := range outputs {
+		result, ok := pt.reusePieceFromFile(request, output)
+		if !ok {
+			continue
+		}
+		pt.Debugf("piece %d is reused from partial output %s", request.piece.PieceNum, output)
+		pt.reportSuccessResult(request, result)
+		pt.PublishPieceInfo(request.piece.PieceNum, request.piece.RangeSize)
+		return true
+	}
+	return false
+}
+
+func (pt *peerTaskConductor) reusePieceFromFile(request *DownloadPieceRequest, output string) (*DownloadPieceResult, bool) {
+	var result = &DownloadPieceResult{
+		Size:      -1,
+		BeginTime: time.Now().UnixNano(),
+	}
+	file, err := os.Open(output)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.Size() < int64(request.piece.RangeStart)+int64(request.piece.RangeSize) {
+		return nil, false
+	}
+
+	// verify the piece before writing it to storage, invalid pieces are downloaded from other peers
+	section := io.NewSectionReader(file, int64(request.piece.RangeStart), int64(request.piece.RangeSize))
+	if _, err = io.Copy(io.Discard, newPieceDigestReader(request, section)); err != nil {
+		pt.Debugf("piece %d in partial output %s is invalid: %s", request.piece.PieceNum, output, err)
+		return nil, false
+	}
+
+	result.Size, err = request.storage.WritePiece(pt.ctx, &storage.WritePieceRequest{
+		Reader: io.NewSectionReader(file, int64(request.piece.RangeStart), int64(request.piece.RangeSize)),
+		PeerTaskMetadata: storage.PeerTaskMetadata{
+			PeerID: request.PeerID,
+			TaskID: request.TaskID,
+		},
+		PieceMetadata: storage.PieceMetadata{
+			Num:    request.piece.PieceNum,
+			Md5:    request.piece.PieceMd5,
+			Offset: request.piece.PieceOffset,
+			Range: clientutil.Range{
+				Start:  int64(request.piece.RangeStart),
+				Length: int64(request.piece.RangeSize),
+			},
+			DigestAlgorithm: request.piece.DigestAlgorithm,
+			Digest:          request.piece.PieceDigest,
+		},
+	})
+	result.FinishTime = time.Now().UnixNano()
+	if err != nil {
+		pt.Warnf("write piece %d from partial output %s error: %s", request.piece.PieceNum, output, err)
+		return nil, false
+	}
+	return result, true
+}
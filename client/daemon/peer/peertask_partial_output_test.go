@@ -0,0 +1,132 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	testifyassert "github.com/stretchr/testify/assert"
+	testifyrequire "github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/client/daemon/test"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+func TestPeerTaskConductor_ReusePartialOutput(t *testing.T) {
+	assert := testifyassert.New(t)
+	require := testifyrequire.New(t)
+	testBytes, err := os.ReadFile(test.File)
+	require.Nil(err, "load test file")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		pieceSize     = 1024
+		totalPieces   = int(math.Ceil(float64(len(testBytes)) / float64(pieceSize)))
+		partialPieces = totalPieces / 2
+		invalidPiece  = 1
+		url           = "http://localhost/test/partial-output"
+		urlMeta       = &base.UrlMeta{Tag: "d7y-test"}
+		taskID        = idgen.TaskID(url, urlMeta)
+	)
+
+	// the partial output holds the first half pieces, and one of them is corrupted
+	partial := make([]byte, partialPieces*pieceSize)
+	copy(partial, testBytes)
+	partial[invalidPiece*pieceSize] ^= 0xff
+	output := filepath.Join(t.TempDir(), "output")
+	require.Nil(os.WriteFile(output, partial, 0644))
+
+	// only the missing and invalid pieces will be downloaded
+	downloader := NewMockPieceDownloader(ctrl)
+	downloader.EXPECT().DownloadPiece(gomock.Any(), gomock.Any()).Times(totalPieces - partialPieces + 1).DoAndReturn(
+		func(ctx context.Context, task *DownloadPieceRequest) (io.Reader, io.Closer, error) {
+			if task.piece.PieceNum != int32(invalidPiece) {
+				assert.GreaterOrEqual(task.piece.PieceNum, int32(partialPieces), "valid piece in partial output should not be downloaded")
+			}
+			rc := io.NopCloser(
+				bytes.NewBuffer(
+					testBytes[task.piece.RangeStart : task.piece.RangeStart+uint64(task.piece.RangeSize)],
+				))
+			return rc, rc, nil
+		})
+
+	ts := &testSpec{
+		taskData:           testBytes,
+		pieceParallelCount: 4,
+		pieceSize:          pieceSize,
+		url:                url,
+	}
+	mm := setupMockManager(ctrl, ts,
+		componentsOption{
+			taskID:             taskID,
+			contentLength:      int64(len(testBytes)),
+			pieceSize:          uint32(pieceSize),
+			pieceParallelCount: ts.pieceParallelCount,
+			pieceDownloader:    downloader,
+			content:            testBytes,
+			scope:              base.SizeScope_NORMAL,
+		})
+	defer mm.CleanUp()
+
+	ptc, created, err := mm.peerTaskManager.getOrCreatePeerTaskConductor(context.Background(), taskID,
+		&scheduler.PeerTaskRequest{
+			Url:      url,
+			UrlMeta:  urlMeta,
+			PeerId:   "partial-output-peer",
+			PeerHost: &scheduler.PeerHost{},
+		}, rate.Inf)
+	require.Nil(err)
+	require.True(created)
+	ptc.addPartialOutput(output)
+	require.Nil(ptc.start(), "peerTaskConductor start should be ok")
+
+	select {
+	case <-ptc.successCh:
+	case <-ptc.failCh:
+		require.FailNow("peer task should success", ptc.failedReason)
+	case <-time.After(10 * time.Second):
+		require.FailNow("peer task timeout")
+	}
+	assert.Equal(int32(totalPieces), ptc.readyPieces.Settled())
+
+	rc, err := mm.storageManager.ReadAllPieces(context.Background(),
+		&storage.ReadAllPiecesRequest{
+			PeerTaskMetadata: storage.PeerTaskMetadata{
+				PeerID: "partial-output-peer",
+				TaskID: taskID,
+			},
+		})
+	require.Nil(err, "read task")
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.Nil(err)
+	assert.Equal(testBytes, data, "task data should match")
+}
@@ -45,20 +45,10 @@ prepare:
 	retryCount++
 	peerPacket := ptc.peerPacket.Load().(*scheduler.PeerPacket)
 	ptc.pieceParallelCount.Store(peerPacket.ParallelCount)
-	request.DstPid = peerPacket.MainPeer.PeerId
-	pp, err = poller.preparePieceTasksByPeer(peerPacket, peerPacket.MainPeer, request)
-	if err == nil {
-		return
-	}
-	if err == errPeerPacketChanged {
-		if poller.getPiecesMaxRetry > 0 && retryCount > poller.getPiecesMaxRetry {
-			err = fmt.Errorf("get pieces max retry count reached")
-			return
+	for _, peer := range poller.candidatePeers(peerPacket) {
+		if peer != nil {
+			request.DstPid = peer.PeerId
 		}
-		goto prepare
-	}
-	for _, peer := range peerPacket.StealPeers {
-		request.DstPid = peer.PeerId
 		pp, err = poller.preparePieceTasksByPeer(peerPacket, peer, request)
 		if err == nil {
 			return
@@ -74,6 +64,16 @@ prepare:
 	return
 }
 
+// candidatePeers returns the parents to get piece tasks in order, the main peer first,
+// or the fastest first when adaptive concurrency is enabled
+func (poller *pieceTaskPoller) candidatePeers(peerPacket *scheduler.PeerPacket) []*scheduler.PeerPacket_DestPeer {
+	peers := append([]*scheduler.PeerPacket_DestPeer{peerPacket.MainPeer}, peerPacket.StealPeers...)
+	if selector := poller.peerTaskConductor.parentSelector; selector != nil {
+		return selector.sort(peers)
+	}
+	return peers
+}
+
 func (poller *pieceTaskPoller) preparePieceTasksByPeer(
 	curPeerPacket *scheduler.PeerPacket,
 	peer *scheduler.PeerPacket_DestPeer, request *base.PieceTaskRequest) (*base.PiecePacket, error) {
@@ -129,7 +129,7 @@ retry:
 		PieceInfo:     &base.PieceInfo{},
 		Success:       false,
 		Code:          code,
-		HostLoad:      nil,
+		HostLoad:      ptc.hostLoad(),
 		FinishedCount: -1,
 	})
 	// error code should be sent to scheduler and the scheduler can schedule a new peer
@@ -208,7 +208,7 @@ func (poller *pieceTaskPoller) getPieceTasksByPeer(
 			PieceInfo:     &base.PieceInfo{},
 			Success:       false,
 			Code:          base.Code_ClientWaitPieceReady,
-			HostLoad:      nil,
+			HostLoad:      ptc.hostLoad(),
 			FinishedCount: ptc.readyPieces.Settled(),
 		})
 		if sendError != nil {
@@ -0,0 +1,244 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+// SeedTaskRequest is the request of seed peer task, which is triggered by scheduler
+type SeedTaskRequest struct {
+	scheduler.PeerTaskRequest
+	// Limit is the download rate limit of the task, zero means using the per peer rate limit
+	Limit float64
+}
+
+type SeedTaskResponse struct {
+	TaskID string
+	PeerID string
+	// Progress sends the ready pieces one by one, the last progress is done or failed
+	Progress <-chan *SeedTaskProgress
+}
+
+type SeedTaskProgress struct {
+	State         *ProgressState
+	PieceInfo     *base.PieceInfo
+	ContentLength int64
+	TotalPieces   int32
+	PeerTaskDone  bool
+}
+
+// seedTask watches a peer task conductor and reports the ready pieces to the seed task caller
+type seedTask struct {
+	*logger.SugaredLoggerOnWith
+	ctx               context.Context
+	peerTaskConductor *peerTaskConductor
+	pieceCh           chan *pieceInfo
+	progressCh        chan *SeedTaskProgress
+	// sent records the pieces already reported, the broker may drop messages, so all pieces are checked again when done
+	sent map[int32]bool
+}
+
+func (ptm *peerTaskManager) StartSeedTask(ctx context.Context, req *SeedTaskRequest) (*SeedTaskResponse, error) {
+	if ptm.stopped.Load() {
+		return nil, ErrTaskManagerStopped
+	}
+	taskID := idgen.TaskID(req.Url, req.UrlMeta)
+	if resp, ok := ptm.tryReuseSeedPeerTask(ctx, taskID); ok {
+		metrics.PeerTaskCacheHitCount.Add(1)
+		return resp, nil
+	}
+
+	metrics.SeedTaskCount.Add(1)
+	var limit = rate.Inf
+	if ptm.perPeerRateLimit > 0 {
+		limit = ptm.perPeerRateLimit
+	}
+	if req.Limit > 0 {
+		limit = rate.Limit(req.Limit)
+	}
+
+	// when the task is already running, for example downloaded by a normal peer task, just watch it
+	ptc, created, err := ptm.getOrCreatePeerTaskConductor(ctx, taskID, &req.PeerTaskRequest, limit)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		ptc.seed = true
+		if err = ptc.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &seedTask{
+		SugaredLoggerOnWith: ptc.SugaredLoggerOnWith,
+		ctx:                 ctx,
+		peerTaskConductor:   ptc,
+		pieceCh:             ptc.broker.Subscribe(),
+		progressCh:          make(chan *SeedTaskProgress),
+		sent:                map[int32]bool{},
+	}
+	go s.run()
+	return &SeedTaskResponse{
+		TaskID:   ptc.taskID,
+		PeerID:   ptc.peerID,
+		Progress: s.progressCh,
+	}, nil
+}
+
+// tryReuseSeedPeerTask reports all pieces of the completed task in storage
+func (ptm *peerTaskManager) tryReuseSeedPeerTask(ctx context.Context, taskID string) (*SeedTaskResponse, bool) {
+	reuse := ptm.storageManager.FindCompletedTask(taskID)
+	if reuse == nil {
+		return nil, false
+	}
+	log := logger.With("peer", reuse.PeerID, "task", taskID, "component", "reuseSeedPeerTask")
+	packet, err := ptm.storageManager.GetPieces(ctx, &base.PieceTaskRequest{
+		TaskId:   taskID,
+		SrcPid:   reuse.PeerID,
+		DstPid:   reuse.PeerID,
+		StartNum: 0,
+		Limit:    uint32(reuse.TotalPieces),
+	})
+	if err != nil {
+		log.Errorf("get pieces error when reuse seed peer task: %s", err)
+		return nil, false
+	}
+	if int32(len(packet.PieceInfos)) != reuse.TotalPieces {
+		log.Warnf("pieces not match when reuse seed peer task, desired: %d, actual: %d", reuse.TotalPieces, len(packet.PieceInfos))
+		return nil, false
+	}
+	log.Infof("reuse completed task for seed peer task, total pieces: %d", reuse.TotalPieces)
+
+	progressCh := make(chan *SeedTaskProgress, len(packet.PieceInfos)+1)
+	for _, piece := range packet.PieceInfos {
+		progressCh <- &SeedTaskProgress{
+			State:         &ProgressState{Success: true, Code: base.Code_Success},
+			PieceInfo:     piece,
+			ContentLength: reuse.ContentLength,
+			TotalPieces:   reuse.TotalPieces,
+		}
+	}
+	progressCh <- &SeedTaskProgress{
+		State:         &ProgressState{Success: true, Code: base.Code_Success},
+		ContentLength: reuse.ContentLength,
+		TotalPieces:   reuse.TotalPieces,
+		PeerTaskDone:  true,
+	}
+	close(progressCh)
+	return &SeedTaskResponse{
+		TaskID:   taskID,
+		PeerID:   reuse.PeerID,
+		Progress: progressCh,
+	}, true
+}
+
+func (s *seedTask) run() {
+	defer close(s.progressCh)
+	ptc := s.peerTaskConductor
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.Errorf("seed task context done due to: %s", s.ctx.Err())
+			return
+		case info := <-s.pieceCh:
+			if !s.sendPieces(info.num, 1) {
+				return
+			}
+		case <-ptc.successCh:
+			total := ptc.GetTotalPieces()
+			if total < 0 {
+				total = ptc.readyPieces.Settled()
+			}
+			if !s.sendPieces(0, uint32(total)) {
+				return
+			}
+			s.send(&SeedTaskProgress{
+				State:         &ProgressState{Success: true, Code: base.Code_Success},
+				ContentLength: ptc.GetContentLength(),
+				TotalPieces:   total,
+				PeerTaskDone:  true,
+			})
+			return
+		case <-ptc.failCh:
+			s.send(&SeedTaskProgress{
+				State: &ProgressState{
+					Success: false,
+					Code:    ptc.failedCode,
+					Msg:     ptc.failedReason,
+				},
+			})
+			return
+		}
+	}
+}
+
+// sendPieces reports the ready pieces in [start, start+limit) which are not reported yet
+func (s *seedTask) sendPieces(start int32, limit uint32) bool {
+	ptc := s.peerTaskConductor
+	packet, err := ptc.GetStorage().GetPieces(s.ctx, &base.PieceTaskRequest{
+		TaskId:   ptc.taskID,
+		SrcPid:   ptc.peerID,
+		DstPid:   ptc.peerID,
+		StartNum: uint32(start),
+		Limit:    limit,
+	})
+	if err != nil {
+		s.Errorf("get pieces error: %s", err)
+		s.send(&SeedTaskProgress{
+			State: &ProgressState{
+				Success: false,
+				Code:    base.Code_ClientError,
+				Msg:     err.Error(),
+			},
+		})
+		return false
+	}
+	for _, piece := range packet.PieceInfos {
+		if s.sent[piece.PieceNum] {
+			continue
+		}
+		if !s.send(&SeedTaskProgress{
+			State:         &ProgressState{Success: true, Code: base.Code_Success},
+			PieceInfo:     piece,
+			ContentLength: packet.ContentLength,
+			TotalPieces:   packet.TotalPiece,
+		}) {
+			return false
+		}
+		s.sent[piece.PieceNum] = true
+	}
+	return true
+}
+
+func (s *seedTask) send(progress *SeedTaskProgress) bool {
+	select {
+	case s.progressCh <- progress:
+		return true
+	case <-s.ctx.Done():
+		s.Errorf("seed task context done due to: %s", s.ctx.Err())
+		return false
+	}
+}
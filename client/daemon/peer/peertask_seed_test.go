@@ -0,0 +1,150 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	testifyassert "github.com/stretchr/testify/assert"
+	testifyrequire "github.com/stretchr/testify/require"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/client/daemon/test"
+	mock_scheduler "d7y.io/dragonfly/v2/client/daemon/test/mock/scheduler"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/pkg/source"
+	"d7y.io/dragonfly/v2/pkg/source/httpprotocol"
+	sourceMock "d7y.io/dragonfly/v2/pkg/source/mock"
+)
+
+func TestPeerTaskManager_StartSeedTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	require := testifyrequire.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBytes, err := os.ReadFile(test.File)
+	require.Nil(err, "load test file")
+
+	var (
+		url       = "http://localhost/test/seed"
+		pieceSize = 1024
+		urlMeta   = &base.UrlMeta{Tag: "d7y-test"}
+	)
+
+	sourceClient := sourceMock.NewMockResourceClient(ctrl)
+	sourceClient.EXPECT().GetContentLength(source.RequestEq(url)).AnyTimes().DoAndReturn(
+		func(request *source.Request) (int64, error) {
+			return int64(len(testBytes)), nil
+		})
+	// download from source only once, the second seed task reuses the completed task
+	sourceClient.EXPECT().Download(source.RequestEq(url)).Times(1).DoAndReturn(
+		func(request *source.Request) (*source.Response, error) {
+			return source.NewResponse(io.NopCloser(bytes.NewBuffer(testBytes))), nil
+		})
+	source.UnRegister("http")
+	require.Nil(source.Register("http", sourceClient, httpprotocol.Adapter))
+	defer func() {
+		source.UnRegister("http")
+		require.Nil(source.Register("http", httpprotocol.NewHTTPSourceClient(), httpprotocol.Adapter))
+	}()
+
+	tempDir, err := os.MkdirTemp("", "d7y-test-*")
+	require.Nil(err)
+	storageManager, err := storage.NewStorageManager(
+		config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: tempDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Hour,
+			},
+		}, func(request storage.CommonTaskRequest) {})
+	require.Nil(err)
+	defer storageManager.CleanUp()
+
+	// seed peer task should not register to scheduler
+	schedulerClient := mock_scheduler.NewMockSchedulerClient(ctrl)
+	ptm := &peerTaskManager{
+		calculateDigest: true,
+		host: &scheduler.PeerHost{
+			Ip: "127.0.0.1",
+		},
+		conductorLock:    &sync.Mutex{},
+		runningPeerTasks: sync.Map{},
+		pieceManager: &pieceManager{
+			calculateDigest: true,
+			computePieceSize: func(contentLength int64) uint32 {
+				return uint32(pieceSize)
+			},
+		},
+		storageManager:  storageManager,
+		schedulerClient: schedulerClient,
+		schedulerOption: config.SchedulerOption{
+			ScheduleTimeout: clientutil.Duration{Duration: 10 * time.Minute},
+		},
+	}
+
+	totalPieces := int32(math.Ceil(float64(len(testBytes)) / float64(pieceSize)))
+	startSeedTask := func() *SeedTaskResponse {
+		resp, err := ptm.StartSeedTask(context.Background(), &SeedTaskRequest{
+			PeerTaskRequest: scheduler.PeerTaskRequest{
+				Url:      url,
+				UrlMeta:  urlMeta,
+				PeerId:   idgen.CDNPeerID("127.0.0.1"),
+				PeerHost: ptm.host,
+			},
+		})
+		require.Nil(err, "start seed task")
+		assert.Equal(idgen.TaskID(url, urlMeta), resp.TaskID)
+
+		var (
+			pieces = map[int32]bool{}
+			done   bool
+		)
+		for p := range resp.Progress {
+			require.True(p.State.Success, p.State.Msg)
+			if p.PeerTaskDone {
+				assert.Equal(int64(len(testBytes)), p.ContentLength)
+				assert.Equal(totalPieces, p.TotalPieces)
+				done = true
+				break
+			}
+			require.NotNil(p.PieceInfo)
+			assert.False(pieces[p.PieceInfo.PieceNum], "piece should be reported once")
+			pieces[p.PieceInfo.PieceNum] = true
+		}
+		assert.True(done)
+		assert.Equal(int(totalPieces), len(pieces))
+		return resp
+	}
+
+	first := startSeedTask()
+	second := startSeedTask()
+	assert.Equal(first.PeerID, second.PeerID, "completed task should be reused")
+}
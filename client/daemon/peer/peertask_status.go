@@ -0,0 +1,98 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// PeerTaskStatus is the status of a running peer task
+type PeerTaskStatus struct {
+	TaskID          string    `json:"taskID"`
+	PeerID          string    `json:"peerID"`
+	URL             string    `json:"url"`
+	ContentLength   int64     `json:"contentLength"`
+	CompletedLength int64     `json:"completedLength"`
+	TotalPieces     int32     `json:"totalPieces"`
+	ReadyPieces     int32     `json:"readyPieces"`
+	BackSource      bool      `json:"backSource"`
+	StartTime       time.Time `json:"startTime"`
+	// Rate is the average download rate in bytes per second since the task started
+	Rate int64 `json:"rate"`
+	// BackSourceTraffic is the traffic downloaded from source
+	BackSourceTraffic uint64          `json:"backSourceTraffic"`
+	Parents           []*ParentStatus `json:"parents,omitempty"`
+}
+
+// ParentStatus is the traffic downloaded from a parent peer
+type ParentStatus struct {
+	PeerID  string `json:"peerID"`
+	Traffic uint64 `json:"traffic"`
+}
+
+func (ptm *peerTaskManager) ListRunningPeerTasks() []*PeerTaskStatus {
+	var tasks []*PeerTaskStatus
+	ptm.runningPeerTasks.Range(func(key, value interface{}) bool {
+		tasks = append(tasks, value.(*peerTaskConductor).status())
+		return true
+	})
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].StartTime.Before(tasks[j].StartTime)
+	})
+	return tasks
+}
+
+func (pt *peerTaskConductor) addParentTraffic(parent string, n uint64) {
+	traffic, _ := pt.parentTraffic.LoadOrStore(parent, atomic.NewUint64(0))
+	traffic.(*atomic.Uint64).Add(n)
+}
+
+func (pt *peerTaskConductor) status() *PeerTaskStatus {
+	s := &PeerTaskStatus{
+		TaskID:            pt.taskID,
+		PeerID:            pt.peerID,
+		URL:               pt.request.Url,
+		ContentLength:     pt.GetContentLength(),
+		CompletedLength:   pt.completedLength.Load(),
+		TotalPieces:       pt.GetTotalPieces(),
+		BackSource:        pt.needBackSource.Load(),
+		StartTime:         pt.startTime,
+		BackSourceTraffic: pt.GetTraffic(),
+	}
+	pt.lock.RLock()
+	s.ReadyPieces = pt.readyPieces.Settled()
+	pt.lock.RUnlock()
+
+	if elapsed := time.Since(pt.startTime); elapsed > 0 {
+		s.Rate = int64(float64(s.CompletedLength) / elapsed.Seconds())
+	}
+
+	pt.parentTraffic.Range(func(key, value interface{}) bool {
+		s.Parents = append(s.Parents, &ParentStatus{
+			PeerID:  key.(string),
+			Traffic: value.(*atomic.Uint64).Load(),
+		})
+		return true
+	})
+	sort.Slice(s.Parents, func(i, j int) bool {
+		return s.Parents[i].Traffic > s.Parents[j].Traffic
+	})
+	return s
+}
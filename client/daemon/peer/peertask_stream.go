@@ -44,6 +44,12 @@ type StreamTaskRequest struct {
 	Range *clientutil.Range
 	// peer's id and must be global uniqueness
 	PeerID string
+	// Limit is the download rate limit of the task, per peer rate limit is used when it is zero
+	Limit float64
+	// DisableBackSource indicates not back source when p2p fails
+	DisableBackSource bool
+	// Priority is the weight of piece requests in the upload fair queue of parents, zero means the default weight
+	Priority int32
 }
 
 // StreamTask represents a peer task with stream io for reading directly without once more disk io
@@ -64,13 +70,14 @@ type streamTask struct {
 
 func (ptm *peerTaskManager) newStreamTask(
 	ctx context.Context,
-	request *scheduler.PeerTaskRequest) (*streamTask, error) {
+	request *scheduler.PeerTaskRequest,
+	opts ...conductorOption) (*streamTask, error) {
 	metrics.StreamTaskCount.Add(1)
 	var limit = rate.Inf
 	if ptm.perPeerRateLimit > 0 {
 		limit = ptm.perPeerRateLimit
 	}
-	ptc, err := ptm.getPeerTaskConductor(ctx, idgen.TaskID(request.Url, request.UrlMeta), request, limit)
+	ptc, err := ptm.getPeerTaskConductor(ctx, idgen.TaskID(request.Url, request.UrlMeta), request, limit, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,240 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/go-http-utils/headers"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+// rangeStreamTask reads a byte range from the parent peer task which downloads the whole file,
+// the pieces covering the range are downloaded first and the bytes are returned as soon as they land
+type rangeStreamTask struct {
+	*logger.SugaredLoggerOnWith
+	ctx               context.Context
+	span              trace.Span
+	peerTaskConductor *peerTaskConductor
+	pieceCh           chan *pieceInfo
+	// rangeValue is the range in url meta, like "0-1023", "1024-" and "-1024"
+	rangeValue string
+	// removePriority removes current range from the priority ranges of peerTaskConductor
+	removePriority func()
+}
+
+func (ptm *peerTaskManager) newRangeStreamTask(
+	ctx context.Context,
+	request *scheduler.PeerTaskRequest,
+	rg *clientutil.Range,
+	opts ...conductorOption) (*rangeStreamTask, error) {
+	metrics.StreamTaskCount.Add(1)
+	var limit = rate.Inf
+	if ptm.perPeerRateLimit > 0 {
+		limit = ptm.perPeerRateLimit
+	}
+	parent := ptm.newParentPeerTaskRequest(request)
+	ptc, err := ptm.getPeerTaskConductor(ctx, idgen.TaskID(parent.Url, parent.UrlMeta), parent, limit, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := tracer.Start(ctx, config.SpanStreamTask, trace.WithSpanKind(trace.SpanKindClient))
+	span.SetAttributes(config.AttributeReuseRange.String(request.UrlMeta.Range))
+	pt := &rangeStreamTask{
+		SugaredLoggerOnWith: logger.With("peer", ptc.peerID, "task", ptc.taskID, "range", request.UrlMeta.Range,
+			"component", "rangeStreamTask"),
+		ctx:               ctx,
+		span:              span,
+		peerTaskConductor: ptc,
+		pieceCh:           ptc.broker.Subscribe(),
+		rangeValue:        request.UrlMeta.Range,
+		removePriority:    func() {},
+	}
+	// suffix range can not be located before content length is known
+	if !strings.HasPrefix(pt.rangeValue, "-") {
+		pt.removePriority = ptc.addPriorityRange(&clientutil.Range{Start: rg.Start, Length: rg.Length})
+	}
+	return pt, nil
+}
+
+func (s *rangeStreamTask) Start(ctx context.Context) (io.ReadCloser, map[string]string, error) {
+	attr := map[string]string{}
+	attr[config.HeaderDragonflyTask] = s.peerTaskConductor.taskID
+	attr[config.HeaderDragonflyPeer] = s.peerTaskConductor.peerID
+	attr[config.HeaderDragonflyRange] = s.rangeValue
+
+	// wait content length and piece size to locate the range
+	if err := s.waitReady(ctx); err != nil {
+		s.removePriority()
+		s.Errorf("wait peer task ready error: %s", err)
+		s.span.RecordError(err)
+		s.span.End()
+		return nil, attr, err
+	}
+
+	contentLength := s.peerTaskConductor.GetContentLength()
+	rgs, err := clientutil.ParseRange("bytes="+s.rangeValue, contentLength)
+	if err == nil && len(rgs) != 1 {
+		err = clientutil.ErrNoOverlap
+	}
+	if err != nil {
+		s.removePriority()
+		s.Errorf("parse range with content length %d error: %s", contentLength, err)
+		s.span.RecordError(err)
+		s.span.End()
+		return nil, attr, err
+	}
+	rg := rgs[0]
+	s.removePriority()
+	s.removePriority = s.peerTaskConductor.addPriorityRange(&rg)
+
+	attr[headers.ContentLength] = fmt.Sprintf("%d", rg.Length)
+	attr[headers.ContentRange] = fmt.Sprintf("bytes %d-%d/%d", rg.Start, rg.Start+rg.Length-1, contentLength)
+
+	pr, pw := io.Pipe()
+	go s.writeToPipe(rg, pw)
+	return pr, attr, nil
+}
+
+// waitReady waits until the content length and piece size of the parent peer task are known
+func (s *rangeStreamTask) waitReady(ctx context.Context) error {
+	ptc := s.peerTaskConductor
+	for ptc.GetContentLength() < 0 || ptc.pieceSize.Load() == 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ptc.failCh:
+			return fmt.Errorf("peer task failed: %d/%s", ptc.failedCode, ptc.failedReason)
+		case <-ptc.successCh:
+			return nil
+		case <-s.pieceCh:
+		}
+	}
+	return nil
+}
+
+func (s *rangeStreamTask) writeToPipe(rg clientutil.Range, pw *io.PipeWriter) {
+	defer func() {
+		s.removePriority()
+		s.peerTaskConductor.broker.Unsubscribe(s.pieceCh)
+		s.span.End()
+	}()
+	var (
+		ptc       = s.peerTaskConductor
+		pieceSize = int64(ptc.pieceSize.Load())
+		offset    = rg.Start
+		end       = rg.Start + rg.Length
+	)
+	// the whole file is in one piece when piece size is unknown after peer task success
+	if pieceSize == 0 {
+		pieceSize = math.MaxInt64
+	}
+	for offset < end {
+		num := int32(offset / pieceSize)
+		if err := s.waitPiece(num); err != nil {
+			s.Errorf("wait piece %d error: %s", num, err)
+			s.span.RecordError(err)
+			_ = pw.CloseWithError(err)
+			return
+		}
+
+		// read from current offset to the end of the piece or range
+		length := int64(num+1)*pieceSize - offset
+		if pieceSize == math.MaxInt64 || offset+length > end {
+			length = end - offset
+		}
+		_, span := tracer.Start(s.ctx, config.SpanWriteBackPiece)
+		span.SetAttributes(config.AttributePiece.Int(int(num)))
+		n, err := s.writeRange(pw, clientutil.Range{Start: offset, Length: length})
+		span.SetAttributes(config.AttributePieceSize.Int(int(n)))
+		if err != nil {
+			span.RecordError(err)
+			span.End()
+			s.Errorf("write to pipe error: %s", err)
+			_ = pw.CloseWithError(err)
+			return
+		}
+		span.End()
+		s.Debugf("wrote piece %d to pipe, offset: %d, size %d", num, offset, n)
+		offset += n
+	}
+	s.Debugf("range %s wrote to pipe", rg.String())
+	pw.Close()
+}
+
+// waitPiece waits the piece is downloaded by the parent peer task
+func (s *rangeStreamTask) waitPiece(num int32) error {
+	ptc := s.peerTaskConductor
+	for {
+		ptc.lock.RLock()
+		ready := ptc.readyPieces.IsSet(num)
+		ptc.lock.RUnlock()
+		if ready {
+			return nil
+		}
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-ptc.failCh:
+			return fmt.Errorf("context done due to peer task fail: %d/%s", ptc.failedCode, ptc.failedReason)
+		case <-ptc.successCh:
+			return nil
+		case <-s.pieceCh:
+		}
+	}
+}
+
+func (s *rangeStreamTask) writeRange(w io.Writer, rg clientutil.Range) (int64, error) {
+	pr, pc, err := s.peerTaskConductor.GetStorage().ReadPiece(s.ctx, &storage.ReadPieceRequest{
+		PeerTaskMetadata: storage.PeerTaskMetadata{
+			PeerID: s.peerTaskConductor.peerID,
+			TaskID: s.peerTaskConductor.taskID,
+		},
+		PieceMetadata: storage.PieceMetadata{
+			// read with fixed range
+			Num:   -1,
+			Range: rg,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(w, pr)
+	if err != nil {
+		pc.Close()
+		return n, err
+	}
+	if n != rg.Length {
+		pc.Close()
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, pc.Close()
+}
@@ -0,0 +1,134 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/go-http-utils/headers"
+	"github.com/golang/mock/gomock"
+	testifyassert "github.com/stretchr/testify/assert"
+	testifyrequire "github.com/stretchr/testify/require"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/test"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+)
+
+func TestPeerTaskManager_StartStreamTask_RangeFromParent(t *testing.T) {
+	assert := testifyassert.New(t)
+	require := testifyrequire.New(t)
+	testBytes, err := os.ReadFile(test.File)
+	require.Nil(err, "load test file")
+
+	testCases := []struct {
+		name       string
+		rangeValue string
+		expected   clientutil.Range
+	}{
+		{
+			name:       "range in middle",
+			rangeValue: "2000-5999",
+			expected:   clientutil.Range{Start: 2000, Length: 4000},
+		},
+		{
+			name:       "range to end",
+			rangeValue: fmt.Sprintf("%d-", len(testBytes)-3000),
+			expected:   clientutil.Range{Start: int64(len(testBytes) - 3000), Length: 3000},
+		},
+		{
+			name:       "suffix range",
+			rangeValue: "-1500",
+			expected:   clientutil.Range{Start: int64(len(testBytes) - 1500), Length: 1500},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var (
+				pieceSize = 1024
+				url       = "http://localhost/test/range-from-parent"
+				urlMeta   = &base.UrlMeta{Tag: "d7y-test", Header: map[string]string{}}
+			)
+
+			downloader := NewMockPieceDownloader(ctrl)
+			downloader.EXPECT().DownloadPiece(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(ctx context.Context, task *DownloadPieceRequest) (io.Reader, io.Closer, error) {
+					rc := io.NopCloser(
+						bytes.NewBuffer(
+							testBytes[task.piece.RangeStart : task.piece.RangeStart+uint64(task.piece.RangeSize)],
+						))
+					return rc, rc, nil
+				})
+
+			ts := &testSpec{
+				taskData:           testBytes,
+				pieceParallelCount: 4,
+				pieceSize:          pieceSize,
+				url:                url,
+			}
+			mm := setupMockManager(ctrl, ts,
+				componentsOption{
+					taskID:             idgen.TaskID(url, urlMeta),
+					contentLength:      int64(len(testBytes)),
+					pieceSize:          uint32(pieceSize),
+					pieceParallelCount: ts.pieceParallelCount,
+					pieceDownloader:    downloader,
+					content:            testBytes,
+					scope:              base.SizeScope_NORMAL,
+				})
+			defer mm.CleanUp()
+			mm.peerTaskManager.enableRangeFromParent = true
+
+			rgs, err := clientutil.ParseRange("bytes="+tc.rangeValue, int64(len(testBytes)*2))
+			require.Nil(err)
+			rangeMeta := &base.UrlMeta{
+				Tag:    urlMeta.Tag,
+				Range:  tc.rangeValue,
+				Header: map[string]string{headers.Range: "bytes=" + tc.rangeValue},
+			}
+			rc, attr, err := mm.peerTaskManager.StartStreamTask(context.Background(), &StreamTaskRequest{
+				URL:     url,
+				URLMeta: rangeMeta,
+				Range:   &rgs[0],
+				PeerID:  "range-peer",
+			})
+			require.Nil(err, "start range stream task")
+			defer rc.Close()
+
+			// the range is read from the peer task of the whole file
+			assert.Equal(idgen.TaskID(url, urlMeta), attr[config.HeaderDragonflyTask])
+			assert.Equal(fmt.Sprintf("%d", tc.expected.Length), attr[headers.ContentLength])
+			assert.Equal(fmt.Sprintf("bytes %d-%d/%d", tc.expected.Start,
+				tc.expected.Start+tc.expected.Length-1, len(testBytes)), attr[headers.ContentRange])
+
+			data, err := io.ReadAll(rc)
+			require.Nil(err)
+			assert.Equal(testBytes[tc.expected.Start:tc.expected.Start+tc.expected.Length], data)
+		})
+	}
+}
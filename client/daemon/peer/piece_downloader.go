@@ -18,29 +18,43 @@ package peer
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/client/daemon/upload"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
+	dfclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
 	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 type DownloadPieceRequest struct {
-	piece      *base.PieceInfo
-	log        *logger.SugaredLoggerOnWith
-	storage    storage.TaskStorageDriver
-	TaskID     string
-	PeerID     string
-	DstPid     string
-	DstAddr    string
+	piece   *base.PieceInfo
+	log     *logger.SugaredLoggerOnWith
+	storage storage.TaskStorageDriver
+	TaskID  string
+	PeerID  string
+	DstPid  string
+	DstAddr string
+	// DstRPCAddr is the grpc server address of dst peer, pieces are downloaded via DownloadPiece rpc when it is set
+	DstRPCAddr string
 	CalcDigest bool
+	// Priority is the weight of request in the upload fair queue of dst peer, zero means the default weight
+	Priority int32
 }
 
 type DownloadPieceResult struct {
@@ -60,6 +74,9 @@ type PieceDownloader interface {
 type pieceDownloader struct {
 	transport  http.RoundTripper
 	httpClient *http.Client
+	// scheme is https when tls is enabled in upload server, otherwise http
+	scheme  string
+	timeout time.Duration
 }
 
 type pieceDownloadError struct {
@@ -84,6 +101,21 @@ func isPieceNotFound(err error) bool {
 	return false
 }
 
+// isTimeoutError checks whether the piece download error is caused by timeout
+func isTimeoutError(err error) bool {
+	if e, ok := err.(*pieceDownloadError); ok {
+		err = e.err
+	}
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 func (e *pieceDownloadError) Error() string {
 	if e.connectionError {
 		return fmt.Sprintf("connect with %s with error: %s", e.target, e.err)
@@ -108,7 +140,10 @@ var defaultTransport http.RoundTripper = &http.Transport{
 }
 
 func NewPieceDownloader(timeout time.Duration, opts ...func(*pieceDownloader) error) (PieceDownloader, error) {
-	pd := &pieceDownloader{}
+	pd := &pieceDownloader{
+		scheme:  "http",
+		timeout: timeout,
+	}
 
 	for _, opt := range opts {
 		if err := opt(pd); err != nil {
@@ -134,8 +169,36 @@ func WithTransport(rt http.RoundTripper) func(*pieceDownloader) error {
 	}
 }
 
+// WithTLSClientConfig downloads pieces via https, the transport is cloned from defaultTransport,
+// so it must be applied after WithTransport
+func WithTLSClientConfig(cfg *tls.Config) func(*pieceDownloader) error {
+	return func(d *pieceDownloader) error {
+		rt := d.transport
+		if rt == nil {
+			rt = defaultTransport
+		}
+		transport, ok := rt.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("tls config is not supported for transport %T", rt)
+		}
+		transport = transport.Clone()
+		transport.TLSClientConfig = cfg
+		d.transport = transport
+		d.scheme = "https"
+		return nil
+	}
+}
+
 func (p *pieceDownloader) DownloadPiece(ctx context.Context, req *DownloadPieceRequest) (io.Reader, io.Closer, error) {
-	resp, err := p.httpClient.Do(buildDownloadPieceHTTPRequest(ctx, req))
+	if req.DstRPCAddr != "" {
+		reader, closer, err := p.downloadPieceViaRPC(ctx, req)
+		// fall back to http when dst peer disables DownloadPiece rpc
+		if status.Code(err) != codes.Unimplemented {
+			return reader, closer, err
+		}
+		req.log.Warnf("dst peer %s does not support DownloadPiece rpc, fall back to http", req.DstPid)
+	}
+	resp, err := p.httpClient.Do(buildDownloadPieceHTTPRequest(ctx, p.scheme, req))
 	if err != nil {
 		logger.Errorf("task id: %s, piece num: %d, dst: %s, download piece failed: %s",
 			req.TaskID, req.piece.PieceNum, req.DstAddr, err)
@@ -148,16 +211,111 @@ func (p *pieceDownloader) DownloadPiece(ctx context.Context, req *DownloadPieceR
 	}
 	reader, closer := resp.Body.(io.Reader), resp.Body.(io.Closer)
 	if req.CalcDigest {
-		req.log.Debugf("calculate digest for piece %d, digest: %s", req.piece.PieceNum, req.piece.PieceMd5)
-		reader = digestutils.NewDigestReader(req.log, io.LimitReader(resp.Body, int64(req.piece.RangeSize)), req.piece.PieceMd5)
+		reader = newPieceDigestReader(req, io.LimitReader(resp.Body, int64(req.piece.RangeSize)))
 	}
 	return reader, closer, nil
 }
 
-func buildDownloadPieceHTTPRequest(ctx context.Context, d *DownloadPieceRequest) *http.Request {
+func (p *pieceDownloader) downloadPieceViaRPC(ctx context.Context, req *DownloadPieceRequest) (io.Reader, io.Closer, error) {
+	var cancel context.CancelFunc
+	if p.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	if req.Priority > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, config.HeaderDragonflyPriority, strconv.Itoa(int(req.Priority)))
+	}
+	stream, err := dfclient.DownloadPiece(ctx, req.DstRPCAddr, &dfdaemon.DownloadPieceRequest{
+		TaskId:   req.TaskID,
+		SrcPid:   req.PeerID,
+		DstPid:   req.DstPid,
+		PieceNum: req.piece.PieceNum,
+	})
+	if err != nil {
+		cancel()
+		logger.Errorf("task id: %s, piece num: %d, dst: %s, download piece via rpc failed: %s",
+			req.TaskID, req.piece.PieceNum, req.DstRPCAddr, err)
+		return nil, nil, &pieceDownloadError{err: err, connectionError: true, target: req.DstRPCAddr}
+	}
+	// receive the first result to check whether the piece is available in dst peer
+	result, err := stream.Recv()
+	if err != nil {
+		cancel()
+		return nil, nil, convertDownloadPieceRPCError(err, req.DstRPCAddr)
+	}
+
+	psr := &pieceStreamReader{stream: stream, buf: result.Content, cancel: cancel}
+	var reader io.Reader = psr
+	if req.CalcDigest {
+		reader = newPieceDigestReader(req, io.LimitReader(psr, int64(req.piece.RangeSize)))
+	}
+	return reader, psr, nil
+}
+
+// newPieceDigestReader validates piece content with sha256 digest when dst peer provides it,
+// otherwise falls back to md5 for old peers
+func newPieceDigestReader(req *DownloadPieceRequest, reader io.Reader) io.Reader {
+	if req.piece.DigestAlgorithm == base.DigestAlgorithm_SHA256 && req.piece.PieceDigest != "" {
+		req.log.Debugf("calculate sha256 digest for piece %d, digest: %s", req.piece.PieceNum, req.piece.PieceDigest)
+		dr, err := digestutils.NewDigestReaderWithAlgorithm(req.log, reader, digestutils.Sha256Hash, req.piece.PieceDigest)
+		if err == nil {
+			return dr
+		}
+		req.log.Warnf("create sha256 digest reader error: %s", err)
+	}
+	req.log.Debugf("calculate digest for piece %d, digest: %s", req.piece.PieceNum, req.piece.PieceMd5)
+	return digestutils.NewDigestReader(req.log, reader, req.piece.PieceMd5)
+}
+
+// convertDownloadPieceRPCError converts grpc status error to pieceDownloadError,
+// Unimplemented error is kept for falling back to http
+func convertDownloadPieceRPCError(err error, target string) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return &pieceDownloadError{err: err, connectionError: true, target: target}
+	}
+	switch st.Code() {
+	case codes.Unimplemented:
+		return err
+	case codes.NotFound:
+		return &pieceDownloadError{err: err, status: st.Message(), statusCode: http.StatusNotFound, target: target}
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return &pieceDownloadError{err: err, connectionError: true, target: target}
+	default:
+		return &pieceDownloadError{err: err, status: st.Message(), statusCode: http.StatusInternalServerError, target: target}
+	}
+}
+
+// pieceStreamReader reads piece content from the results of DownloadPiece rpc
+type pieceStreamReader struct {
+	stream dfdaemon.Daemon_DownloadPieceClient
+	buf    []byte
+	cancel context.CancelFunc
+}
+
+func (r *pieceStreamReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		result, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = result.Content
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *pieceStreamReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+func buildDownloadPieceHTTPRequest(ctx context.Context, scheme string, d *DownloadPieceRequest) *http.Request {
 	b := strings.Builder{}
-	// FIXME switch to https when tls enabled
-	b.WriteString("http://")
+	b.WriteString(scheme)
+	b.WriteString("://")
 	b.WriteString(d.DstAddr)
 	b.WriteString(upload.PeerDownloadHTTPPathPrefix)
 	b.Write([]byte(d.TaskID)[:3])
@@ -173,5 +331,8 @@ func buildDownloadPieceHTTPRequest(ctx context.Context, d *DownloadPieceRequest)
 	// TODO use string.Builder
 	req.Header.Add("Range", fmt.Sprintf("bytes=%d-%d",
 		d.piece.RangeStart, d.piece.RangeStart+uint64(d.piece.RangeSize)-1))
+	if d.Priority > 0 {
+		req.Header.Set(config.HeaderDragonflyPriority, strconv.Itoa(int(d.Priority)))
+	}
 	return req
 }
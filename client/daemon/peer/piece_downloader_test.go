@@ -18,11 +18,19 @@ package peer
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"math"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -35,6 +43,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/test"
 	"d7y.io/dragonfly/v2/client/daemon/upload"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
@@ -160,3 +169,133 @@ func TestPieceDownloader_DownloadPiece(t *testing.T) {
 		server.Close()
 	}
 }
+
+func TestPieceDownloader_DownloadPiece_MutualTLS(t *testing.T) {
+	assert := testifyassert.New(t)
+	testData, err := os.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	ca, caKey := generateTestCert(t, nil, nil)
+	serverCert, _ := generateTestCert(t, ca.Leaf, caKey)
+	clientCert, _ := generateTestCert(t, ca.Leaf, caKey)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(upload.PeerDownloadHTTPPathPrefix+"tas/"+"task-tls", r.URL.Path)
+		rg := clientutil.MustParseRange(r.Header.Get("Range"), math.MaxInt64)
+		w.Header().Set(headers.ContentLength, fmt.Sprintf("%d", rg.Length))
+		if _, err := w.Write(testData[rg.Start : rg.Start+rg.Length]); err != nil {
+			t.Error(err)
+		}
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	defer server.Close()
+	addr, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		name      string
+		tlsConfig *tls.Config
+		success   bool
+	}{
+		{
+			name: "with client certificate",
+			tlsConfig: &tls.Config{
+				RootCAs:      pool,
+				Certificates: []tls.Certificate{clientCert},
+			},
+			success: true,
+		},
+		{
+			name: "without client certificate",
+			tlsConfig: &tls.Config{
+				RootCAs: pool,
+			},
+			success: false,
+		},
+		{
+			name: "unknown server certificate",
+			tlsConfig: &tls.Config{
+				Certificates: []tls.Certificate{clientCert},
+			},
+			success: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pd, err := NewPieceDownloader(30*time.Second, WithTLSClientConfig(tt.tlsConfig))
+			assert.Nil(err)
+			hash := md5.New()
+			hash.Write(testData[:100])
+			r, c, err := pd.DownloadPiece(context.Background(), &DownloadPieceRequest{
+				TaskID:     "task-tls",
+				DstAddr:    addr.Host,
+				CalcDigest: true,
+				piece: &base.PieceInfo{
+					RangeStart: 0,
+					RangeSize:  100,
+					PieceMd5:   hex.EncodeToString(hash.Sum(nil)[:16]),
+					PieceStyle: base.PieceStyle_PLAIN,
+				},
+				log: logger.With("test", "test"),
+			})
+			if !tt.success {
+				assert.NotNil(err, "download piece should fail")
+				return
+			}
+			assert.Nil(err, "download piece should success")
+			data, err := io.ReadAll(r)
+			assert.Nil(err)
+			c.Close()
+			assert.Equal(testData[:100], data)
+		})
+	}
+}
+
+// generateTestCert generates a self-signed CA when parent is nil, otherwise a certificate for 127.0.0.1 signed by parent
+func generateTestCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (tls.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "dragonfly-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, key
+}
+
+func TestBuildDownloadPieceHTTPRequest_Priority(t *testing.T) {
+	assert := testifyassert.New(t)
+	d := &DownloadPieceRequest{
+		piece:   &base.PieceInfo{RangeStart: 0, RangeSize: 100},
+		TaskID:  "task-id",
+		DstPid:  "peer-id",
+		DstAddr: "127.0.0.1:65002",
+	}
+	req := buildDownloadPieceHTTPRequest(context.Background(), "http", d)
+	assert.Equal("bytes=0-99", req.Header.Get("Range"))
+	assert.Empty(req.Header.Get(config.HeaderDragonflyPriority))
+
+	d.Priority = 5
+	req = buildDownloadPieceHTTPRequest(context.Background(), "http", d)
+	assert.Equal("5", req.Header.Get(config.HeaderDragonflyPriority))
+}
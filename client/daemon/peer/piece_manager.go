@@ -18,6 +18,7 @@ package peer
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"math"
@@ -25,6 +26,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+	"golang.org/x/sync/errgroup"
 	"golang.org/x/time/rate"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
@@ -49,8 +53,17 @@ type pieceManager struct {
 	computePieceSize func(contentLength int64) uint32
 
 	calculateDigest bool
+	// tlsConfig is used by the default piece downloader when tls is enabled in upload server
+	tlsConfig *tls.Config
+	// concurrentSource downloads pieces from source with concurrent ranged requests when it is set
+	concurrentSource *config.ConcurrentSourceOption
 }
 
+const defaultConcurrentSourceGoroutineCount = 4
+
+// errRangeNotSupported indicates the source does not serve ranged requests, download in a single stream instead
+var errRangeNotSupported = errors.New("range not supported by source")
+
 var _ PieceManager = (*pieceManager)(nil)
 
 func NewPieceManager(s storage.TaskStorageDriver, pieceDownloadTimeout time.Duration, opts ...func(*pieceManager)) (PieceManager, error) {
@@ -64,7 +77,15 @@ func NewPieceManager(s storage.TaskStorageDriver, pieceDownloadTimeout time.Dura
 
 	// set default value
 	if pm.pieceDownloader == nil {
-		pm.pieceDownloader, _ = NewPieceDownloader(pieceDownloadTimeout)
+		var downloaderOpts []func(*pieceDownloader) error
+		if pm.tlsConfig != nil {
+			downloaderOpts = append(downloaderOpts, WithTLSClientConfig(pm.tlsConfig))
+		}
+		pd, err := NewPieceDownloader(pieceDownloadTimeout, downloaderOpts...)
+		if err != nil {
+			return nil, err
+		}
+		pm.pieceDownloader = pd
 	}
 	return pm, nil
 }
@@ -83,6 +104,29 @@ func WithLimiter(limiter *rate.Limiter) func(*pieceManager) {
 	}
 }
 
+// WithTLSConfig sets the tls config for downloading pieces from other peers' upload server
+func WithTLSConfig(cfg *tls.Config) func(*pieceManager) {
+	return func(pm *pieceManager) {
+		pm.tlsConfig = cfg
+	}
+}
+
+// WithConcurrentSource sets the option to download pieces from source with concurrent ranged requests
+func WithConcurrentSource(opt *config.ConcurrentSourceOption) func(*pieceManager) {
+	return func(pm *pieceManager) {
+		if opt == nil {
+			return
+		}
+		cs := *opt
+		if cs.GoroutineCount <= 0 {
+			cs.GoroutineCount = defaultConcurrentSourceGoroutineCount
+		}
+		logger.Infof("download from source concurrently with %d goroutines when content length exceeds %s",
+			cs.GoroutineCount, cs.ThresholdSize)
+		pm.concurrentSource = &cs
+	}
+}
+
 func WithTransportOption(opt *config.TransportOption) func(*pieceManager) {
 	return func(manager *pieceManager) {
 		if opt == nil {
@@ -132,7 +176,7 @@ func (pm *pieceManager) DownloadPiece(ctx context.Context, request *DownloadPiec
 			return result, err
 		}
 	}
-	request.CalcDigest = pm.calculateDigest && request.piece.PieceMd5 != ""
+	request.CalcDigest = pm.calculateDigest && (request.piece.PieceMd5 != "" || request.piece.PieceDigest != "")
 	span.SetAttributes(config.AttributeTargetPeerID.String(request.DstPid))
 	span.SetAttributes(config.AttributeTargetPeerAddr.String(request.DstAddr))
 	span.SetAttributes(config.AttributePiece.Int(int(request.piece.PieceNum)))
@@ -163,6 +207,8 @@ func (pm *pieceManager) DownloadPiece(ctx context.Context, request *DownloadPiec
 				Start:  int64(request.piece.RangeStart),
 				Length: int64(request.piece.RangeSize),
 			},
+			DigestAlgorithm: request.piece.DigestAlgorithm,
+			Digest:          request.piece.PieceDigest,
 		},
 	}
 
@@ -180,7 +226,7 @@ func (pm *pieceManager) DownloadPiece(ctx context.Context, request *DownloadPiec
 
 func (pm *pieceManager) processPieceFromSource(pt Task,
 	reader io.Reader, contentLength int64, pieceNum int32, pieceOffset uint64, pieceSize uint32, isLastPiece func(n int64) (int32, bool)) (
-	result *DownloadPieceResult, md5 string, err error) {
+	result *DownloadPieceResult, md5, sha256 string, err error) {
 	result = &DownloadPieceResult{
 		Size:       -1,
 		BeginTime:  time.Now().UnixNano(),
@@ -200,9 +246,13 @@ func (pm *pieceManager) processPieceFromSource(pt Task,
 	}
 	if pm.calculateDigest {
 		pt.Log().Debugf("calculate digest")
-		reader = digestutils.NewDigestReader(pt.Log(), reader)
+		// md5 is calculated for old peers
+		reader, err = digestutils.NewDigestReaderWithAlgorithm(pt.Log(), reader, digestutils.Sha256Hash, "", digestutils.Md5Hash)
+		if err != nil {
+			result.FinishTime = time.Now().UnixNano()
+			return
+		}
 	}
-	var n int64
 	result.Size, err = pt.GetStorage().WritePiece(
 		pt.Context(),
 		&storage.WritePieceRequest{
@@ -226,15 +276,16 @@ func (pm *pieceManager) processPieceFromSource(pt Task,
 		})
 
 	result.FinishTime = time.Now().UnixNano()
-	if n > 0 {
-		pt.AddTraffic(uint64(n))
+	if result.Size > 0 {
+		pt.AddTraffic(uint64(result.Size))
 	}
 	if err != nil {
-		pt.Log().Errorf("put piece to storage failed, piece num: %d, wrote: %d, error: %s", pieceNum, n, err)
+		pt.Log().Errorf("put piece to storage failed, piece num: %d, wrote: %d, error: %s", pieceNum, result.Size, err)
 		return
 	}
 	if pm.calculateDigest {
 		md5 = reader.(digestutils.DigestReader).Digest()
+		sha256 = reader.(digestutils.DigestReader).DigestOf(digestutils.Sha256Hash)
 	}
 	return
 }
@@ -277,6 +328,13 @@ func (pm *pieceManager) DownloadSource(ctx context.Context, pt Task, request *sc
 		}
 	}
 	log.Debugf("get content length: %d", contentLength)
+	if pm.shouldDownloadConcurrently(request, contentLength) {
+		err = pm.downloadConcurrentSource(ctx, pt, request, contentLength, pm.computePieceSize(contentLength))
+		if !errors.Is(err, errRangeNotSupported) {
+			return err
+		}
+		log.Warnf("%s, fall back to download in a single stream", err)
+	}
 	// 1. download piece from source
 	downloadRequest, err := source.NewRequestWithContext(ctx, request.Url, request.UrlMeta.Header)
 	if err != nil {
@@ -290,9 +348,13 @@ func (pm *pieceManager) DownloadSource(ctx context.Context, pt Task, request *sc
 	defer response.Body.Close()
 	reader := response.Body.(io.Reader)
 
-	// calc total
-	if pm.calculateDigest {
-		reader = digestutils.NewDigestReader(pt.Log(), response.Body, request.UrlMeta.Digest)
+	// calc total, the digest of the whole content can not validate ranged content
+	if request.UrlMeta.Digest != "" && request.UrlMeta.Range == "" {
+		if reader, err = newWholeDigestReader(log, response.Body, request.UrlMeta.Digest); err != nil {
+			return err
+		}
+	} else if pm.calculateDigest {
+		reader = digestutils.NewDigestReader(log, response.Body)
 	}
 	// we must calculate piece size
 	pieceSize := pm.computePieceSize(contentLength)
@@ -306,6 +368,20 @@ func (pm *pieceManager) DownloadSource(ctx context.Context, pt Task, request *sc
 	return pm.downloadKnownLengthSource(ctx, pt, contentLength, pieceSize, reader)
 }
 
+// newWholeDigestReader validates the whole content with digest in format "algorithm:encoded",
+// the digest without algorithm is treated as md5 for compatibility
+func newWholeDigestReader(log *logger.SugaredLoggerOnWith, reader io.Reader, digest string) (io.Reader, error) {
+	parsed := digestutils.Parse(digest)
+	if len(parsed) == 1 {
+		return digestutils.NewDigestReader(log, reader, parsed[0]), nil
+	}
+	algorithm, ok := digestutils.Algorithms[parsed[0]]
+	if len(parsed) != 2 || !ok {
+		return nil, errors.Errorf("invalid digest %s", digest)
+	}
+	return digestutils.NewDigestReaderWithAlgorithm(log, reader, algorithm, parsed[1])
+}
+
 func (pm *pieceManager) downloadKnownLengthSource(ctx context.Context, pt Task, contentLength int64, pieceSize uint32, reader io.Reader) error {
 	log := pt.Log()
 	pt.SetContentLength(contentLength)
@@ -320,7 +396,7 @@ func (pm *pieceManager) downloadKnownLengthSource(ctx context.Context, pt Task,
 		}
 
 		log.Debugf("download piece %d", pieceNum)
-		result, md5, err := pm.processPieceFromSource(
+		result, md5, sha256, err := pm.processPieceFromSource(
 			pt, reader, contentLength, pieceNum, offset, size,
 			func(int64) (int32, bool) {
 				return maxPieceNum, pieceNum == maxPieceNum-1
@@ -329,12 +405,14 @@ func (pm *pieceManager) downloadKnownLengthSource(ctx context.Context, pt Task,
 			TaskID: pt.GetTaskID(),
 			PeerID: pt.GetPeerID(),
 			piece: &base.PieceInfo{
-				PieceNum:    pieceNum,
-				RangeStart:  offset,
-				RangeSize:   uint32(result.Size),
-				PieceMd5:    md5,
-				PieceOffset: offset,
-				PieceStyle:  0,
+				PieceNum:        pieceNum,
+				RangeStart:      offset,
+				RangeSize:       uint32(result.Size),
+				PieceMd5:        md5,
+				PieceOffset:     offset,
+				PieceStyle:      0,
+				DigestAlgorithm: base.DigestAlgorithm_SHA256,
+				PieceDigest:     sha256,
 			},
 		}
 		if err != nil {
@@ -381,7 +459,7 @@ func (pm *pieceManager) downloadUnknownLengthSource(ctx context.Context, pt Task
 		size := pieceSize
 		offset := uint64(pieceNum) * uint64(pieceSize)
 		log.Debugf("download piece %d", pieceNum)
-		result, md5, err := pm.processPieceFromSource(
+		result, md5, sha256, err := pm.processPieceFromSource(
 			pt, reader, contentLength, pieceNum, offset, size,
 			func(n int64) (int32, bool) {
 				if n >= int64(pieceSize) {
@@ -398,12 +476,14 @@ func (pm *pieceManager) downloadUnknownLengthSource(ctx context.Context, pt Task
 			TaskID: pt.GetTaskID(),
 			PeerID: pt.GetPeerID(),
 			piece: &base.PieceInfo{
-				PieceNum:    pieceNum,
-				RangeStart:  offset,
-				RangeSize:   uint32(result.Size),
-				PieceMd5:    md5,
-				PieceOffset: offset,
-				PieceStyle:  0,
+				PieceNum:        pieceNum,
+				RangeStart:      offset,
+				RangeSize:       uint32(result.Size),
+				PieceMd5:        md5,
+				PieceOffset:     offset,
+				PieceStyle:      0,
+				DigestAlgorithm: base.DigestAlgorithm_SHA256,
+				PieceDigest:     sha256,
 			},
 		}
 		if err != nil {
@@ -452,3 +532,148 @@ func (pm *pieceManager) downloadUnknownLengthSource(ctx context.Context, pt Task
 	log.Infof("download from source ok")
 	return nil
 }
+
+// shouldDownloadConcurrently checks whether to download pieces with concurrent ranged requests,
+// ranged tasks and tasks with digest are always downloaded in a single stream,
+// because the digest of the whole content is validated when reading the stream
+func (pm *pieceManager) shouldDownloadConcurrently(request *scheduler.PeerTaskRequest, contentLength int64) bool {
+	return pm.concurrentSource != nil &&
+		contentLength > 0 &&
+		contentLength >= pm.concurrentSource.ThresholdSize.ToNumber() &&
+		request.UrlMeta.Range == "" &&
+		request.UrlMeta.Digest == ""
+}
+
+// downloadConcurrentSource downloads disjoint pieces from source with concurrent ranged requests,
+// errRangeNotSupported is returned before any piece is written when the source rejects ranged requests
+func (pm *pieceManager) downloadConcurrentSource(ctx context.Context, pt Task, request *scheduler.PeerTaskRequest, contentLength int64, pieceSize uint32) error {
+	log := pt.Log()
+	supportRequest, err := source.NewRequestWithContext(ctx, request.Url, request.UrlMeta.Header)
+	if err != nil {
+		return err
+	}
+	support, err := source.IsSupportRange(supportRequest)
+	if err != nil {
+		return errors.Wrap(errRangeNotSupported, err.Error())
+	}
+	if !support {
+		return errRangeNotSupported
+	}
+
+	pt.SetContentLength(contentLength)
+	maxPieceNum := int32(math.Ceil(float64(contentLength) / float64(pieceSize)))
+	// pieces are written out of order, total pieces is set first and
+	// the digest of all pieces is generated when the last one is written
+	err = pt.GetStorage().UpdateTask(ctx,
+		&storage.UpdateTaskRequest{
+			PeerTaskMetadata: storage.PeerTaskMetadata{
+				PeerID: pt.GetPeerID(),
+				TaskID: pt.GetTaskID(),
+			},
+			ContentLength: contentLength,
+			TotalPieces:   maxPieceNum,
+		})
+	if err != nil {
+		log.Errorf("update task failed %s", err)
+		return err
+	}
+	written := atomic.NewInt32(0)
+	genPieceDigest := func(int64) (int32, bool) {
+		return maxPieceNum, written.Inc() == maxPieceNum
+	}
+
+	// the first piece is downloaded alone to make sure the source serves ranged requests,
+	// so it's still safe to fall back to a single stream
+	if err = pm.downloadRangedPieceFromSource(ctx, pt, request, contentLength, pieceSize, 0, genPieceDigest); err != nil {
+		return err
+	}
+
+	pieceNums := make(chan int32, maxPieceNum)
+	for pieceNum := int32(1); pieceNum < maxPieceNum; pieceNum++ {
+		pieceNums <- pieceNum
+	}
+	close(pieceNums)
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	for i := 0; i < pm.concurrentSource.GoroutineCount; i++ {
+		eg.Go(func() error {
+			for pieceNum := range pieceNums {
+				err := pm.downloadRangedPieceFromSource(egCtx, pt, request, contentLength, pieceSize, pieceNum, genPieceDigest)
+				if errors.Is(err, errRangeNotSupported) {
+					// some pieces are already written, can not fall back any more
+					return fmt.Errorf("download piece %d: %s", pieceNum, err)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if err = eg.Wait(); err != nil {
+		return err
+	}
+
+	log.Infof("download from source concurrently ok")
+	return nil
+}
+
+// downloadRangedPieceFromSource downloads one piece from source with a ranged request, then reports and publishes it
+func (pm *pieceManager) downloadRangedPieceFromSource(ctx context.Context, pt Task, request *scheduler.PeerTaskRequest,
+	contentLength int64, pieceSize uint32, pieceNum int32, genPieceDigest func(n int64) (int32, bool)) error {
+	log := pt.Log()
+	size := pieceSize
+	offset := uint64(pieceNum) * uint64(pieceSize)
+	// calculate piece size for last piece
+	if int64(offset)+int64(size) > contentLength {
+		size = uint32(contentLength - int64(offset))
+	}
+
+	downloadRequest, err := source.NewRequestWithContext(ctx, request.Url, request.UrlMeta.Header)
+	if err != nil {
+		return err
+	}
+	downloadRequest.Header.Set(source.Range, fmt.Sprintf("%d-%d", offset, offset+uint64(size)-1))
+	response, err := source.Download(downloadRequest)
+	if err != nil {
+		log.Errorf("download piece %d from source error: %s", pieceNum, err)
+		return err
+	}
+	defer response.Body.Close()
+	// the response without expected length is the whole content when the source ignores range
+	if response.ContentLength != int64(size) && response.StatusCode != http.StatusPartialContent {
+		return errors.Wrapf(errRangeNotSupported, "status: %d, content length: %d, desired: %d",
+			response.StatusCode, response.ContentLength, size)
+	}
+
+	log.Debugf("download piece %d", pieceNum)
+	result, md5, sha256, err := pm.processPieceFromSource(
+		pt, response.Body, contentLength, pieceNum, offset, size, genPieceDigest)
+	pieceRequest := &DownloadPieceRequest{
+		TaskID: pt.GetTaskID(),
+		PeerID: pt.GetPeerID(),
+		piece: &base.PieceInfo{
+			PieceNum:        pieceNum,
+			RangeStart:      offset,
+			RangeSize:       uint32(result.Size),
+			PieceMd5:        md5,
+			PieceOffset:     offset,
+			PieceStyle:      0,
+			DigestAlgorithm: base.DigestAlgorithm_SHA256,
+			PieceDigest:     sha256,
+		},
+	}
+	if err != nil {
+		log.Errorf("download piece %d error: %s", pieceNum, err)
+		pt.ReportPieceResult(pieceRequest, result, err)
+		return err
+	}
+	if result.Size != int64(size) {
+		log.Errorf("download piece %d size not match, desired: %d, actual: %d", pieceNum, size, result.Size)
+		pt.ReportPieceResult(pieceRequest, result, storage.ErrShortRead)
+		return storage.ErrShortRead
+	}
+	pt.ReportPieceResult(pieceRequest, result, nil)
+	pt.PublishPieceInfo(pieceNum, uint32(result.Size))
+	return nil
+}
@@ -44,6 +44,8 @@ import (
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
 	"d7y.io/dragonfly/v2/pkg/source"
 	"d7y.io/dragonfly/v2/pkg/source/httpprotocol"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 func TestPieceManager_DownloadSource(t *testing.T) {
@@ -74,37 +76,56 @@ func TestPieceManager_DownloadSource(t *testing.T) {
 	hash := md5.New()
 	hash.Write(testBytes)
 	digest := hex.EncodeToString(hash.Sum(nil)[:16])
+	sha256Digest := "sha256:" + digestutils.Sha256(string(testBytes))
 
 	testCases := []struct {
 		name              string
 		pieceSize         uint32
 		withContentLength bool
-		checkDigest       bool
+		digest            string
+		expectErr         bool
 	}{
 		{
 			name:              "multiple pieces with content length, check digest",
 			pieceSize:         1024,
-			checkDigest:       true,
+			digest:            digest,
 			withContentLength: true,
 		},
 		{
 			name:              "multiple pieces with content length",
 			pieceSize:         1024,
-			checkDigest:       false,
 			withContentLength: true,
 		},
 		{
 			name:              "multiple pieces without content length, check digest",
 			pieceSize:         1024,
-			checkDigest:       true,
+			digest:            digest,
 			withContentLength: false,
 		},
 		{
 			name:              "multiple pieces without content length",
 			pieceSize:         1024,
-			checkDigest:       false,
 			withContentLength: false,
 		},
+		{
+			name:              "multiple pieces with content length, check sha256 digest",
+			pieceSize:         1024,
+			digest:            sha256Digest,
+			withContentLength: true,
+		},
+		{
+			name:              "multiple pieces without content length, check sha256 digest",
+			pieceSize:         1024,
+			digest:            sha256Digest,
+			withContentLength: false,
+		},
+		{
+			name:              "multiple pieces with content length, sha256 digest not match",
+			pieceSize:         1024,
+			digest:            "sha256:" + digestutils.Sha256("not match"),
+			withContentLength: true,
+			expectErr:         true,
+		},
 		{
 			name:              "one pieces with content length case 1",
 			pieceSize:         uint32(len(testBytes)),
@@ -206,18 +227,164 @@ func TestPieceManager_DownloadSource(t *testing.T) {
 			request := &scheduler.PeerTaskRequest{
 				Url: ts.URL,
 				UrlMeta: &base.UrlMeta{
-					Digest: "",
+					Digest: tc.digest,
 					Range:  "",
 					Header: nil,
 				},
 			}
-			if tc.checkDigest {
-				request.UrlMeta.Digest = digest
+
+			err = pm.DownloadSource(context.Background(), mockPeerTask, request)
+			if tc.expectErr {
+				assert.NotNil(err)
+				return
+			}
+			assert.Nil(err)
+
+			err = storageManager.Store(context.Background(),
+				&storage.StoreRequest{
+					CommonTaskRequest: storage.CommonTaskRequest{
+						PeerID:      peerID,
+						TaskID:      taskID,
+						Destination: output,
+					},
+				})
+			assert.Nil(err)
+
+			outputBytes, err := os.ReadFile(output)
+			assert.Nil(err, "load output file")
+			assert.Equal(testBytes, outputBytes, "output and desired output must match")
+		})
+	}
+}
+
+func TestPieceManager_DownloadConcurrentSource(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	source.UnRegister("http")
+	require.Nil(t, source.Register("http", httpprotocol.NewHTTPSourceClient(), httpprotocol.Adapter))
+	defer source.UnRegister("http")
+	testBytes, err := os.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var (
+		peerID    = "peer0"
+		taskID    = "task0"
+		output    = "../test/testdata/test.output"
+		pieceSize = uint32(1024)
+	)
+
+	storageManager, _ := storage.NewStorageManager(
+		config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: t.TempDir(),
+			TaskExpireTime: clientutil.Duration{
+				Duration: -1 * time.Second,
+			},
+		}, func(request storage.CommonTaskRequest) {})
+
+	testCases := []struct {
+		name          string
+		supportRange  bool
+		thresholdSize int64
+		rangeRequests bool
+	}{
+		{
+			name:          "source supports range",
+			supportRange:  true,
+			rangeRequests: true,
+		},
+		{
+			name:          "source does not support range",
+			supportRange:  false,
+			rangeRequests: false,
+		},
+		{
+			name:          "content length less than threshold",
+			supportRange:  true,
+			thresholdSize: int64(len(testBytes)) + 1,
+			rangeRequests: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			/********** prepare test start **********/
+			mockPeerTask := NewMockTask(ctrl)
+			var (
+				taskStorage storage.TaskStorageDriver
+				published   = &atomic.Int32{}
+			)
+			mockPeerTask.EXPECT().SetContentLength(gomock.Any()).AnyTimes()
+			mockPeerTask.EXPECT().GetPeerID().AnyTimes().Return(peerID)
+			mockPeerTask.EXPECT().GetTaskID().AnyTimes().Return(taskID)
+			mockPeerTask.EXPECT().GetStorage().AnyTimes().DoAndReturn(
+				func() storage.TaskStorageDriver {
+					return taskStorage
+				})
+			mockPeerTask.EXPECT().AddTraffic(gomock.Any()).AnyTimes()
+			mockPeerTask.EXPECT().ReportPieceResult(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(_ *DownloadPieceRequest, _ *DownloadPieceResult, err error) {
+					assert.Nil(err)
+				})
+			mockPeerTask.EXPECT().PublishPieceInfo(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(pieceNum int32, size uint32) {
+					published.Inc()
+				})
+			mockPeerTask.EXPECT().Context().AnyTimes().Return(context.Background())
+			mockPeerTask.EXPECT().Log().AnyTimes().Return(logger.With("test case", tc.name))
+			taskStorage, err = storageManager.RegisterTask(context.Background(),
+				storage.RegisterTaskRequest{
+					CommonTaskRequest: storage.CommonTaskRequest{
+						PeerID:      peerID,
+						TaskID:      taskID,
+						Destination: output,
+					},
+					ContentLength: int64(len(testBytes)),
+				})
+			assert.Nil(err)
+			defer storageManager.CleanUp()
+			defer os.Remove(output)
+			/********** prepare test end **********/
+			rangeRequests := &atomic.Int32{}
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !tc.supportRange {
+					w.Header().Set("Content-Length", fmt.Sprintf("%d", len(testBytes)))
+					_, _ = w.Write(testBytes)
+					return
+				}
+				if r.Header.Get("Range") != "" {
+					rangeRequests.Inc()
+				}
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(testBytes))
+			}))
+			defer ts.Close()
+
+			pm, err := NewPieceManager(storageManager, 30*time.Second,
+				WithConcurrentSource(&config.ConcurrentSourceOption{
+					GoroutineCount: 3,
+					ThresholdSize:  unit.Bytes(tc.thresholdSize),
+				}))
+			assert.Nil(err)
+			pm.(*pieceManager).computePieceSize = func(length int64) uint32 {
+				return pieceSize
 			}
 
+			request := &scheduler.PeerTaskRequest{
+				Url:     ts.URL,
+				UrlMeta: &base.UrlMeta{},
+			}
 			err = pm.DownloadSource(context.Background(), mockPeerTask, request)
 			assert.Nil(err)
 
+			totalPieces := int32((len(testBytes) + int(pieceSize) - 1) / int(pieceSize))
+			assert.Equal(totalPieces, published.Load())
+			// the ranged request to check range support is counted too
+			if tc.rangeRequests {
+				assert.Equal(totalPieces+1, rangeRequests.Load())
+			} else {
+				assert.LessOrEqual(rangeRequests.Load(), int32(1))
+			}
+			assert.Nil(storageManager.ValidateDigest(&storage.PeerTaskMetadata{PeerID: peerID, TaskID: taskID}))
+
 			err = storageManager.Store(context.Background(),
 				&storage.StoreRequest{
 					CommonTaskRequest: storage.CommonTaskRequest{
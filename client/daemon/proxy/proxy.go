@@ -101,6 +101,9 @@ type Proxy struct {
 
 	// dumpHTTPContent indicates to dump http request header and response header
 	dumpHTTPContent bool
+
+	// manifestCache is shared by all transports of the proxy, it's nil when disabled
+	manifestCache *transport.ManifestCache
 }
 
 // Option is a functional option for configuring the proxy
@@ -214,6 +217,14 @@ func WithDumpHTTPContent(dump bool) Option {
 	}
 }
 
+// WithManifestCache enables the cache for registry manifests
+func WithManifestCache(opt *config.ManifestCacheOption) Option {
+	return func(p *Proxy) *Proxy {
+		p.manifestCache = transport.NewManifestCache(opt.TTL.Duration, opt.MaxEntries)
+		return p
+	}
+}
+
 // NewProxy returns a new transparent proxy from the given options
 func NewProxy(options ...Option) (*Proxy, error) {
 	return NewProxyWithOptions(options...)
@@ -465,6 +476,7 @@ func (proxy *Proxy) newTransport(tlsConfig *tls.Config) http.RoundTripper {
 		transport.WithDefaultFilter(proxy.defaultFilter),
 		transport.WithDefaultBiz(bizTag),
 		transport.WithDumpHTTPContent(proxy.dumpHTTPContent),
+		transport.WithManifestCache(proxy.manifestCache),
 	)
 	return rt
 }
@@ -479,6 +491,8 @@ func (proxy *Proxy) mirrorRegistry(w http.ResponseWriter, r *http.Request) {
 		transport.WithDefaultFilter(proxy.defaultFilter),
 		transport.WithDefaultBiz(bizTag),
 		transport.WithDumpHTTPContent(proxy.dumpHTTPContent),
+		transport.WithManifestCache(proxy.manifestCache),
+		transport.WithDefaultPolicy(proxy.registry.Policy),
 	)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to get transport: %v", err), http.StatusInternalServerError)
@@ -548,9 +562,13 @@ func (proxy *Proxy) checkWhiteList(r *http.Request) bool {
 
 // shouldUseDragonfly returns whether we should use dragonfly to proxy a request. It
 // also change the scheme of the given request if the matched rule has
-// UseHTTPS = true
+// UseHTTPS = true, rewrites the headers of the request and attaches the download
+// policy by the matched rule.
+// The rules are applied to GET and HEAD requests, only GET requests are downloaded with dragonfly,
+// the request is sent directly when its headers can not be rewritten, so that the task id is not
+// computed from the headers which are not set by the rule
 func (proxy *Proxy) shouldUseDragonfly(req *http.Request) bool {
-	if req.Method != http.MethodGet {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
 		return false
 	}
 
@@ -572,7 +590,22 @@ func (proxy *Proxy) shouldUseDragonfly(req *http.Request) bool {
 				req.URL.Host = rule.Redirect
 				req.Host = rule.Redirect
 			}
-			return !rule.Direct
+			if rule.Header != nil {
+				if err := rule.Header.Rewrite(req.Header); err != nil {
+					logger.Errorf("failed to rewrite header, request %s directly: %s", req.URL, err)
+					return false
+				}
+			}
+			if req.Method != http.MethodGet || rule.Direct {
+				return false
+			}
+			if len(rule.TaskIDHeaders) > 0 {
+				req.Header.Set(config.HeaderDragonflyTaskIDHeaders, strings.Join(rule.TaskIDHeaders, ","))
+			}
+			if rule.Policy != nil {
+				transport.SetDownloadPolicy(req, rule.Policy)
+			}
+			return true
 		}
 	}
 	return false
@@ -78,6 +78,11 @@ func NewProxyManager(peerHost *scheduler.PeerHost, peerTaskManager peer.TaskMana
 		options = append(options, WithRegistryMirror(registry))
 	}
 
+	if opts.ManifestCache != nil {
+		logger.Infof("registry manifest cache enabled, ttl: %s", opts.ManifestCache.TTL.Duration)
+		options = append(options, WithManifestCache(opts.ManifestCache))
+	}
+
 	if len(proxies) > 0 {
 		logger.Infof("load %d proxy rules", len(proxies))
 		for i, r := range proxies {
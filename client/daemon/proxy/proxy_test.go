@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -235,3 +237,61 @@ func TestMatchWithRedirect(t *testing.T) {
 		TestMirror(t)
 
 }
+
+func TestMatchWithHeader(t *testing.T) {
+	a := assert.New(t)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	a.Nil(os.WriteFile(tokenFile, []byte("file-token\n"), 0600))
+	t.Setenv("TEST_PROXY_API_KEY", "env-key")
+
+	rule, err := config.NewProxy("^http://artifacts/", false, false, "")
+	a.Nil(err)
+	rule.Header = &config.HeaderRewrite{
+		Set: []*config.HeaderValue{
+			{Name: "Authorization", Value: "Bearer static"},
+			{Name: "X-Token", File: tokenFile},
+			{Name: "X-Api-Key", Env: "TEST_PROXY_API_KEY"},
+		},
+		Remove: []string{"Cookie", "Authorization"},
+	}
+	rule.TaskIDHeaders = []string{"X-Tenant"}
+	directRule, err := config.NewProxy("^http://direct/", false, true, "")
+	a.Nil(err)
+	directRule.Header = &config.HeaderRewrite{Remove: []string{"Cookie"}}
+	directRule.TaskIDHeaders = []string{"X-Tenant"}
+
+	tp, err := NewProxy(WithRules([]*config.Proxy{rule, directRule}))
+	a.Nil(err)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://artifacts/a", nil)
+	req.Header.Set("Cookie", "c")
+	req.Header.Set("Authorization", "Basic user")
+	a.True(tp.shouldUseDragonfly(req))
+	a.Empty(req.Header.Get("Cookie"))
+	a.Equal("Bearer static", req.Header.Get("Authorization"))
+	a.Equal("file-token", req.Header.Get("X-Token"))
+	a.Equal("env-key", req.Header.Get("X-Api-Key"))
+	a.Equal("X-Tenant", req.Header.Get(config.HeaderDragonflyTaskIDHeaders))
+
+	// headers of HEAD requests are rewritten, but they are not downloaded with dragonfly
+	req, _ = http.NewRequest(http.MethodHead, "http://artifacts/a", nil)
+	a.False(tp.shouldUseDragonfly(req))
+	a.Equal("Bearer static", req.Header.Get("Authorization"))
+	a.Empty(req.Header.Get(config.HeaderDragonflyTaskIDHeaders))
+
+	req, _ = http.NewRequest(http.MethodGet, "http://direct/a", nil)
+	req.Header.Set("Cookie", "c")
+	a.False(tp.shouldUseDragonfly(req))
+	a.Empty(req.Header.Get("Cookie"))
+	a.Empty(req.Header.Get(config.HeaderDragonflyTaskIDHeaders))
+
+	// request is sent directly without any header set when a header value can not be loaded
+	a.Nil(os.Remove(tokenFile))
+	req, _ = http.NewRequest(http.MethodGet, "http://artifacts/a", nil)
+	req.Header.Set("Cookie", "c")
+	a.False(tp.shouldUseDragonfly(req))
+	a.Empty(req.Header.Get("Cookie"))
+	a.Empty(req.Header.Get("Authorization"))
+	a.Empty(req.Header.Get("X-Token"))
+	a.Empty(req.Header.Get(config.HeaderDragonflyTaskIDHeaders))
+}
@@ -19,21 +19,29 @@ package rpcserver
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"os"
 
 	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcpeer "google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	"d7y.io/dragonfly/v2/client/daemon/peer"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/client/daemon/upload"
 	"d7y.io/dragonfly/v2/internal/dferrors"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	cdnserver "d7y.io/dragonfly/v2/pkg/rpc/cdnsystem/server"
 	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
 	dfdaemonserver "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/server"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
@@ -55,26 +63,86 @@ type server struct {
 	downloadServer *grpc.Server
 	peerServer     *grpc.Server
 	uploadAddr     string
+
+	// enableDownloadPiece indicates other peers can download pieces via DownloadPiece rpc
+	enableDownloadPiece bool
+	// uploadLimiter is shared with upload manager, the burst size must be bigger than piece size
+	uploadLimiter *rate.Limiter
+	// fairLimiter is shared with upload manager, it's used instead of uploadLimiter when it's set
+	fairLimiter *upload.FairLimiter
+	rpcAddr     string
+
+	// enableSeeder indicates to serve the Seeder service in peer grpc server as a seed peer
+	enableSeeder bool
+
+	// inflight counts in-flight DownloadPiece and ObtainSeeds calls, it's shared with upload manager
+	inflight *upload.Inflight
 }
 
-func New(peerHost *scheduler.PeerHost, peerTaskManager peer.TaskManager, storageManager storage.Manager, downloadOpts []grpc.ServerOption, peerOpts []grpc.ServerOption) (Server, error) {
+// Option is a functional option for configuring the rpc server
+type Option func(*server)
+
+// downloadPieceChunkSize is the max content size of one DownloadPieceResult
+const downloadPieceChunkSize = 256 * 1024
+
+func New(peerHost *scheduler.PeerHost, peerTaskManager peer.TaskManager, storageManager storage.Manager, downloadOpts []grpc.ServerOption, peerOpts []grpc.ServerOption,
+	opts ...Option) (Server, error) {
 	svr := &server{
 		KeepAlive:       clientutil.NewKeepAlive("rpc server"),
 		peerHost:        peerHost,
 		peerTaskManager: peerTaskManager,
 		storageManager:  storageManager,
+		inflight:        upload.NewInflight(),
+	}
+	for _, opt := range opts {
+		opt(svr)
 	}
 	svr.downloadServer = dfdaemonserver.New(svr, downloadOpts...)
 	svr.peerServer = dfdaemonserver.New(svr, peerOpts...)
+	if svr.enableSeeder {
+		cdnserver.Register(svr.peerServer, &seeder{server: svr})
+	}
 	return svr, nil
 }
 
+// WithDownloadPiece enables transferring pieces via DownloadPiece rpc, the limiter limits the upload rate
+func WithDownloadPiece(limiter *rate.Limiter) Option {
+	return func(s *server) {
+		s.enableDownloadPiece = true
+		s.uploadLimiter = limiter
+	}
+}
+
+// WithFairLimiter shares the upload rate limit of DownloadPiece rpc across concurrent children with weighted fair queuing
+func WithFairLimiter(limiter *upload.FairLimiter) Option {
+	return func(s *server) {
+		s.fairLimiter = limiter
+	}
+}
+
+// WithInflight shares the in-flight upload counter with upload manager
+func WithInflight(inflight *upload.Inflight) Option {
+	return func(s *server) {
+		s.inflight = inflight
+	}
+}
+
+// WithSeeder serves the Seeder service, scheduler triggers seed peer tasks via ObtainSeeds like cdn
+func WithSeeder() Option {
+	return func(s *server) {
+		s.enableSeeder = true
+	}
+}
+
 func (m *server) ServeDownload(listener net.Listener) error {
 	return m.downloadServer.Serve(listener)
 }
 
 func (m *server) ServePeer(listener net.Listener) error {
 	m.uploadAddr = fmt.Sprintf("%s:%d", m.peerHost.Ip, m.peerHost.DownPort)
+	if m.enableDownloadPiece {
+		m.rpcAddr = fmt.Sprintf("%s:%d", m.peerHost.Ip, m.peerHost.RpcPort)
+	}
 	return m.peerServer.Serve(listener)
 }
 
@@ -114,6 +182,7 @@ func (m *server) GetPieceTasks(ctx context.Context, request *base.PieceTaskReque
 			TaskId:        request.TaskId,
 			DstPid:        request.DstPid,
 			DstAddr:       m.uploadAddr,
+			DstRpcAddr:    m.rpcAddr,
 			PieceInfos:    nil,
 			TotalPiece:    -1,
 			ContentLength: -1,
@@ -124,9 +193,118 @@ func (m *server) GetPieceTasks(ctx context.Context, request *base.PieceTaskReque
 	logger.Debugf("receive get piece tasks request, task id: %s, src peer: %s, dst peer: %s, piece num: %d, limit: %d, length: %d",
 		request.TaskId, request.SrcPid, request.DstPid, request.StartNum, request.Limit, len(p.PieceInfos))
 	p.DstAddr = m.uploadAddr
+	p.DstRpcAddr = m.rpcAddr
 	return p, nil
 }
 
+func (m *server) DownloadPiece(req *dfdaemongrpc.DownloadPieceRequest, stream dfdaemongrpc.Daemon_DownloadPieceServer) error {
+	m.Keep()
+	if !m.enableDownloadPiece {
+		return status.Error(codes.Unimplemented, "download piece via rpc is disabled")
+	}
+	defer m.inflight.Track()()
+	ctx := stream.Context()
+	log := logger.With("task", req.TaskId, "peer", req.DstPid, "component", "downloadPieceService")
+
+	p, err := m.storageManager.GetPieces(ctx, &base.PieceTaskRequest{
+		TaskId:   req.TaskId,
+		SrcPid:   req.SrcPid,
+		DstPid:   req.DstPid,
+		StartNum: uint32(req.PieceNum),
+		Limit:    1,
+	})
+	if err != nil {
+		if err == storage.ErrTaskNotFound {
+			return status.Error(codes.NotFound, err.Error())
+		}
+		log.Errorf("get piece %d error: %s", req.PieceNum, err)
+		return status.Error(codes.Internal, err.Error())
+	}
+	if len(p.PieceInfos) == 0 || p.PieceInfos[0].PieceNum != req.PieceNum {
+		return status.Errorf(codes.NotFound, "piece %d not found", req.PieceNum)
+	}
+	piece := p.PieceInfos[0]
+
+	reader, closer, err := m.storageManager.ReadPiece(ctx, &storage.ReadPieceRequest{
+		PeerTaskMetadata: storage.PeerTaskMetadata{
+			TaskID: req.TaskId,
+			PeerID: req.DstPid,
+		},
+		PieceMetadata: storage.PieceMetadata{
+			Num: req.PieceNum,
+		},
+	})
+	if err != nil {
+		if err == storage.ErrPieceNotFound || err == storage.ErrTaskNotFound {
+			return status.Error(codes.NotFound, err.Error())
+		}
+		log.Errorf("read piece %d error: %s", req.PieceNum, err)
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer closer.Close()
+
+	host := "unknown"
+	if pe, ok := grpcpeer.FromContext(ctx); ok {
+		host = pe.Addr.String()
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+	if m.fairLimiter != nil {
+		var weight = 1
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if priority := md.Get(config.HeaderDragonflyPriority); len(priority) > 0 {
+				weight = upload.ParseWeight(priority[0])
+			}
+		}
+		if err = m.fairLimiter.WaitN(ctx, host, req.TaskId, weight, int(piece.RangeSize)); err != nil {
+			log.Errorf("get fair limit failed: %s", err)
+			return status.Error(codes.Internal, err.Error())
+		}
+	} else if m.uploadLimiter != nil {
+		if err = m.uploadLimiter.WaitN(ctx, int(piece.RangeSize)); err != nil {
+			log.Errorf("get limit failed: %s", err)
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	var (
+		sent int64
+		buf  = make([]byte, downloadPieceChunkSize)
+		// piece info is only set in the first result
+		pieceInfo = piece
+	)
+	defer func() {
+		metrics.UploadBytesCount.Add(float64(sent))
+	}()
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if n > 0 || pieceInfo != nil {
+			if sendErr := stream.Send(&dfdaemongrpc.DownloadPieceResult{
+				PieceInfo: pieceInfo,
+				Content:   buf[:n],
+			}); sendErr != nil {
+				log.Errorf("send piece %d error: %s", req.PieceNum, sendErr)
+				return sendErr
+			}
+			pieceInfo = nil
+			sent += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			log.Errorf("read piece %d error: %s", req.PieceNum, err)
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	if sent != int64(piece.RangeSize) {
+		log.Errorf("transferred piece %d length not match, desired: %d, transferred: %d", req.PieceNum, piece.RangeSize, sent)
+		return status.Errorf(codes.Internal, "piece %d length not match", req.PieceNum)
+	}
+	return nil
+}
+
 func (m *server) CheckHealth(context.Context) error {
 	m.Keep()
 	return nil
@@ -148,6 +326,7 @@ func (m *server) Download(ctx context.Context,
 		DisableBackSource: req.DisableBackSource,
 		Pattern:           req.Pattern,
 		Callsystem:        req.Callsystem,
+		ResumeOutput:      req.ResumeOutput,
 	}
 	log := logger.With("peer", peerTask.PeerId, "component", "downloadService")
 
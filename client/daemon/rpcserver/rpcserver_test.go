@@ -17,6 +17,7 @@
 package rpcserver
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -29,11 +30,15 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/phayes/freeport"
 	testifyassert "github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/client/daemon/peer"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
 	mock_peer "d7y.io/dragonfly/v2/client/daemon/test/mock/peer"
 	mock_storage "d7y.io/dragonfly/v2/client/daemon/test/mock/storage"
+	"d7y.io/dragonfly/v2/client/daemon/upload"
 	"d7y.io/dragonfly/v2/internal/dfnet"
 	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
@@ -254,3 +259,101 @@ func TestDownloadManager_ServePeer(t *testing.T) {
 		assert.Equal(tc.responsePieceSize, len(response.PieceInfos))
 	}
 }
+
+func TestDownloadManager_DownloadPiece(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		pieceNum  int32 = 2
+		pieceSize       = downloadPieceChunkSize + 1024
+		content         = bytes.Repeat([]byte("a"), pieceSize)
+		inflight        = upload.NewInflight()
+	)
+	mockStorageManger := mock_storage.NewMockManager(ctrl)
+	mockStorageManger.EXPECT().GetPieces(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, req *base.PieceTaskRequest) (*base.PiecePacket, error) {
+		var pieces []*base.PieceInfo
+		if int32(req.StartNum) == pieceNum {
+			pieces = append(pieces, &base.PieceInfo{
+				PieceNum:    pieceNum,
+				RangeStart:  uint64(pieceNum) * uint64(pieceSize),
+				RangeSize:   uint32(pieceSize),
+				PieceOffset: uint64(pieceNum) * uint64(pieceSize),
+				PieceStyle:  base.PieceStyle_PLAIN,
+			})
+		}
+		return &base.PiecePacket{
+			TaskId:     req.TaskId,
+			DstPid:     req.DstPid,
+			PieceInfos: pieces,
+		}, nil
+	})
+	mockStorageManger.EXPECT().ReadPiece(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, req *storage.ReadPieceRequest) (io.Reader, io.Closer, error) {
+		assert.Equal(pieceNum, req.Num)
+		assert.Equal(int64(1), inflight.Count(), "download piece should be counted as in-flight upload")
+		return bytes.NewBuffer(content), io.NopCloser(nil), nil
+	})
+
+	m := &server{
+		KeepAlive:      clientutil.NewKeepAlive("test"),
+		peerHost:       &scheduler.PeerHost{},
+		storageManager: mockStorageManger,
+	}
+	WithDownloadPiece(nil)(m)
+	WithInflight(inflight)(m)
+	m.peerServer = dfdaemonserver.New(m)
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	assert.Nil(err, "get free port should be ok")
+	go func() {
+		if err := m.ServePeer(ln); err != nil {
+			t.Error(err)
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	request := &dfdaemongrpc.DownloadPieceRequest{
+		TaskId:   idgen.TaskID("http://www.test.com", &base.UrlMeta{}),
+		SrcPid:   idgen.PeerID(iputils.IPv4),
+		DstPid:   idgen.PeerID(iputils.IPv4),
+		PieceNum: pieceNum,
+	}
+	stream, err := dfclient.DownloadPiece(context.Background(), addr, request)
+	assert.Nil(err, "client download piece grpc call should be ok")
+
+	var (
+		results  int
+		received []byte
+	)
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(err)
+		if results == 0 {
+			assert.NotNil(result.PieceInfo)
+			assert.Equal(pieceNum, result.PieceInfo.PieceNum)
+		} else {
+			assert.Nil(result.PieceInfo)
+		}
+		results++
+		received = append(received, result.Content...)
+	}
+	assert.Equal(2, results, "large piece should be split")
+	assert.Equal(content, received)
+	assert.Equal(int64(0), inflight.Count())
+
+	// piece not found
+	request.PieceNum = pieceNum + 1
+	stream, err = dfclient.DownloadPiece(context.Background(), addr, request)
+	assert.Nil(err)
+	_, err = stream.Recv()
+	assert.Equal(codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,108 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpcserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc/peer"
+
+	dfpeer "d7y.io/dragonfly/v2/client/daemon/peer"
+	"d7y.io/dragonfly/v2/internal/dferrors"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/base/common"
+	"d7y.io/dragonfly/v2/pkg/rpc/cdnsystem"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+// seeder serves the Seeder service of cdn, so dfdaemon can be triggered by scheduler as a seed peer
+type seeder struct {
+	server *server
+}
+
+func (s *seeder) GetPieceTasks(ctx context.Context, request *base.PieceTaskRequest) (*base.PiecePacket, error) {
+	return s.server.GetPieceTasks(ctx, request)
+}
+
+func (s *seeder) ObtainSeeds(ctx context.Context, req *cdnsystem.SeedRequest, psc chan<- *cdnsystem.PieceSeed) error {
+	s.server.Keep()
+	defer s.server.inflight.Track()()
+	clientAddr := "unknown"
+	if pe, ok := peer.FromContext(ctx); ok {
+		clientAddr = pe.Addr.String()
+	}
+	log := logger.With("task", req.TaskId, "component", "seedService")
+	log.Infof("trigger obtain seeds for url: %s, from: %s", req.Url, clientAddr)
+
+	if req.UrlMeta == nil {
+		req.UrlMeta = &base.UrlMeta{}
+	}
+	resp, err := s.server.peerTaskManager.StartSeedTask(ctx, &dfpeer.SeedTaskRequest{
+		PeerTaskRequest: scheduler.PeerTaskRequest{
+			Url:      req.Url,
+			UrlMeta:  req.UrlMeta,
+			PeerId:   idgen.CDNPeerID(s.server.peerHost.Ip),
+			PeerHost: s.server.peerHost,
+		},
+	})
+	if err != nil {
+		log.Errorf("start seed task error: %s", err)
+		return dferrors.New(base.Code_CDNTaskRegistryFail, err.Error())
+	}
+
+	// scheduler finds the seed host by the host id of cdn, which is generated from hostname and rpc port
+	hostID := idgen.CDNHostID(s.server.peerHost.HostName, s.server.peerHost.RpcPort)
+	// begin piece
+	psc <- &cdnsystem.PieceSeed{
+		PeerId:   resp.PeerID,
+		HostUuid: hostID,
+		PieceInfo: &base.PieceInfo{
+			PieceNum: common.BeginOfPiece,
+		},
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Errorf("context done due to %s", ctx.Err())
+			return ctx.Err()
+		case p, ok := <-resp.Progress:
+			if !ok {
+				log.Errorf("seed task progress closed unexpected")
+				return dferrors.New(base.Code_CDNTaskDownloadFail, "progress closed unexpected")
+			}
+			if !p.State.Success {
+				log.Errorf("seed task %s/%s failed: %d/%s", resp.TaskID, resp.PeerID, p.State.Code, p.State.Msg)
+				return dferrors.New(base.Code_CDNTaskDownloadFail, p.State.Msg)
+			}
+			psc <- &cdnsystem.PieceSeed{
+				PeerId:          resp.PeerID,
+				HostUuid:        hostID,
+				PieceInfo:       p.PieceInfo,
+				Done:            p.PeerTaskDone,
+				ContentLength:   p.ContentLength,
+				TotalPieceCount: p.TotalPieces,
+			}
+			if p.PeerTaskDone {
+				log.Infof("seed task %s/%s done, total pieces: %d", resp.TaskID, resp.PeerID, p.TotalPieces)
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpcserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/phayes/freeport"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/daemon/peer"
+	mock_peer "d7y.io/dragonfly/v2/client/daemon/test/mock/peer"
+	mock_storage "d7y.io/dragonfly/v2/client/daemon/test/mock/storage"
+	"d7y.io/dragonfly/v2/client/daemon/upload"
+	"d7y.io/dragonfly/v2/internal/dfnet"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/base/common"
+	"d7y.io/dragonfly/v2/pkg/rpc/cdnsystem"
+	cdnclient "d7y.io/dragonfly/v2/pkg/rpc/cdnsystem/client"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+func TestSeeder_ObtainSeeds(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		totalPieces   int32 = 10
+		pieceSize     int32 = 1024
+		contentLength       = int64(totalPieces * pieceSize)
+		// peer id of the running or completed task, it's used in seeds instead of the one in request
+		peerID   = idgen.CDNPeerID("127.0.0.1")
+		inflight = upload.NewInflight()
+	)
+	mockPeerTaskManager := mock_peer.NewMockTaskManager(ctrl)
+	mockPeerTaskManager.EXPECT().StartSeedTask(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req *peer.SeedTaskRequest) (*peer.SeedTaskResponse, error) {
+			assert.Equal(int64(1), inflight.Count(), "obtain seeds should be counted as in-flight upload")
+			ch := make(chan *peer.SeedTaskProgress)
+			go func() {
+				defer close(ch)
+				for i := int32(0); i <= totalPieces; i++ {
+					p := &peer.SeedTaskProgress{
+						State:         &peer.ProgressState{Success: true},
+						ContentLength: contentLength,
+						TotalPieces:   totalPieces,
+						PeerTaskDone:  i == totalPieces,
+					}
+					if i < totalPieces {
+						p.PieceInfo = &base.PieceInfo{
+							PieceNum:   i,
+							RangeStart: uint64(i * pieceSize),
+							RangeSize:  uint32(pieceSize),
+						}
+					}
+					ch <- p
+				}
+			}()
+			return &peer.SeedTaskResponse{
+				TaskID:   idgen.TaskID(req.Url, req.UrlMeta),
+				PeerID:   peerID,
+				Progress: ch,
+			}, nil
+		})
+
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peerHost := &scheduler.PeerHost{
+		Ip:       "127.0.0.1",
+		RpcPort:  int32(port),
+		HostName: "seed-peer",
+	}
+	m, err := New(peerHost, mockPeerTaskManager, mock_storage.NewMockManager(ctrl), nil, nil, WithSeeder(), WithInflight(inflight))
+	assert.Nil(err)
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	assert.Nil(err, "get free port should be ok")
+	go func() {
+		if err := m.ServePeer(ln); err != nil {
+			t.Error(err)
+		}
+	}()
+	defer m.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	client, err := cdnclient.GetClientByAddr([]dfnet.NetAddr{
+		{
+			Type: dfnet.TCP,
+			Addr: fmt.Sprintf("127.0.0.1:%d", port),
+		},
+	})
+	assert.Nil(err, "grpc dial should be ok")
+
+	url := "http://localhost/test/seed"
+	stream, err := client.ObtainSeeds(context.Background(), &cdnsystem.SeedRequest{
+		TaskId:  idgen.TaskID(url, nil),
+		Url:     url,
+		UrlMeta: &base.UrlMeta{},
+	})
+	assert.Nil(err, "obtain seeds should be ok")
+
+	var seeds []*cdnsystem.PieceSeed
+	for {
+		seed, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if !assert.Nil(err) {
+			return
+		}
+		seeds = append(seeds, seed)
+	}
+
+	// begin piece, all pieces and done
+	if !assert.Len(seeds, int(totalPieces)+2) {
+		return
+	}
+	hostID := idgen.CDNHostID(peerHost.HostName, peerHost.RpcPort)
+	for _, seed := range seeds {
+		assert.Equal(peerID, seed.PeerId)
+		assert.Equal(hostID, seed.HostUuid)
+	}
+	assert.Equal(common.BeginOfPiece, seeds[0].PieceInfo.PieceNum)
+	for i := int32(0); i < totalPieces; i++ {
+		assert.Equal(i, seeds[i+1].PieceInfo.PieceNum)
+	}
+	last := seeds[len(seeds)-1]
+	assert.True(last.Done)
+	assert.Equal(contentLength, last.ContentLength)
+	assert.Equal(totalPieces, last.TotalPieceCount)
+}
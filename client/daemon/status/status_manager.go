@@ -0,0 +1,191 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/gorilla/mux"
+
+	"d7y.io/dragonfly/v2/client/daemon/peer"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+)
+
+const (
+	TasksHTTPPath   = "/tasks"
+	PinTaskHTTPPath = "/tasks/{taskID}/pin"
+	DrainHTTPPath   = "/drain"
+)
+
+// Status is the snapshot of the tasks in dfdaemon
+type Status struct {
+	Time    time.Time              `json:"time"`
+	Running []*peer.PeerTaskStatus `json:"running"`
+	Cached  []*storage.CachedTask  `json:"cached"`
+}
+
+type Manager interface {
+	Serve(lis net.Listener) error
+	Stop() error
+}
+
+type statusManager struct {
+	*http.Server
+	peerTaskManager peer.TaskManager
+	storageManager  storage.Manager
+	// drain drains dfdaemon and stops it, the status server is stopped too
+	drain func()
+}
+
+var _ Manager = (*statusManager)(nil)
+
+func NewStatusManager(peerTaskManager peer.TaskManager, storageManager storage.Manager, drain func()) Manager {
+	s := &statusManager{
+		Server:          &http.Server{},
+		peerTaskManager: peerTaskManager,
+		storageManager:  storageManager,
+		drain:           drain,
+	}
+	s.initRouter()
+	return s
+}
+
+func (sm *statusManager) initRouter() {
+	r := mux.NewRouter()
+	r.HandleFunc(TasksHTTPPath, sm.handleTasks).Methods("GET")
+	r.HandleFunc(PinTaskHTTPPath, sm.handlePinTask).Methods("PUT", "DELETE")
+	r.HandleFunc(DrainHTTPPath, sm.handleDrain).Methods("PUT")
+	sm.Server.Handler = r
+}
+
+func (sm *statusManager) Serve(lis net.Listener) error {
+	return sm.Server.Serve(lis)
+}
+
+func (sm *statusManager) Stop() error {
+	return sm.Server.Shutdown(context.Background())
+}
+
+// handleTasks returns the running peer tasks and the tasks cached in local storage
+func (sm *statusManager) handleTasks(w http.ResponseWriter, r *http.Request) {
+	status := &Status{
+		Time:    time.Now(),
+		Running: sm.peerTaskManager.ListRunningPeerTasks(),
+		Cached:  sm.storageManager.ListTasks(),
+	}
+	w.Header().Set(headers.ContentType, "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logger.Errorf("encode status failed: %s", err)
+	}
+}
+
+// handlePinTask pins the task with PUT method and unpins it with DELETE method
+func (sm *statusManager) handlePinTask(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["taskID"]
+	pinned := r.Method == http.MethodPut
+	if err := sm.storageManager.PinTask(taskID, pinned); err != nil {
+		logger.Errorf("pin task %s to %t failed: %s", taskID, pinned, err)
+		code := http.StatusInternalServerError
+		if err == storage.ErrTaskNotFound {
+			code = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	logger.Infof("task %s pinned: %t", taskID, pinned)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleDrain starts draining dfdaemon in background, the response returns before dfdaemon exits
+// because the status server is stopped after draining
+func (sm *statusManager) handleDrain(w http.ResponseWriter, r *http.Request) {
+	logger.Infof("drain dfdaemon requested")
+	go sm.drain()
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetStatus queries the status of dfdaemon via the status unix socket
+func GetStatus(ctx context.Context, sockPath string) (*Status, error) {
+	resp, err := do(ctx, sockPath, http.MethodGet, TasksHTTPPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	status := &Status{}
+	if err = json.NewDecoder(resp.Body).Decode(status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// PinTask pins or unpins the task via the status unix socket
+func PinTask(ctx context.Context, sockPath, taskID string, pinned bool) error {
+	method := http.MethodPut
+	if !pinned {
+		method = http.MethodDelete
+	}
+	resp, err := do(ctx, sockPath, method, strings.Replace(PinTaskHTTPPath, "{taskID}", url.PathEscape(taskID), 1))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Drain drains dfdaemon via the status unix socket, it returns once draining is started
+func Drain(ctx context.Context, sockPath string) error {
+	resp, err := do(ctx, sockPath, http.MethodPut, DrainHTTPPath)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func do(ctx context.Context, sockPath, method, path string) (*http.Response, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", sockPath)
+			},
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, method, "http://unix"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d, message: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
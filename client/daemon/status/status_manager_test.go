@@ -0,0 +1,137 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/daemon/peer"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	mock_peer "d7y.io/dragonfly/v2/client/daemon/test/mock/peer"
+	mock_storage "d7y.io/dragonfly/v2/client/daemon/test/mock/storage"
+)
+
+func TestStatusManager_Tasks(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lastAccess := time.Now().Truncate(time.Second)
+	running := []*peer.PeerTaskStatus{
+		{
+			TaskID:          "task-1",
+			PeerID:          "peer-1",
+			ContentLength:   1024,
+			CompletedLength: 512,
+			TotalPieces:     2,
+			ReadyPieces:     1,
+			Parents: []*peer.ParentStatus{
+				{PeerID: "parent-1", Traffic: 512},
+			},
+		},
+	}
+	cached := []*storage.CachedTask{
+		{
+			PeerTaskMetadata: storage.PeerTaskMetadata{TaskID: "task-2", PeerID: "peer-2"},
+			ContentLength:    2048,
+			TotalPieces:      1,
+			Done:             true,
+			LastAccess:       lastAccess,
+		},
+	}
+
+	peerTaskManager := mock_peer.NewMockTaskManager(ctrl)
+	peerTaskManager.EXPECT().ListRunningPeerTasks().Return(running)
+	storageManager := mock_storage.NewMockManager(ctrl)
+	storageManager.EXPECT().ListTasks().Return(cached)
+
+	sockPath := filepath.Join(t.TempDir(), "status.sock")
+	lis, err := net.Listen("unix", sockPath)
+	assert.Nil(err)
+
+	sm := NewStatusManager(peerTaskManager, storageManager, func() {})
+	go sm.Serve(lis)
+	defer sm.Stop()
+
+	s, err := GetStatus(context.Background(), sockPath)
+	assert.Nil(err)
+	assert.Equal(running, s.Running)
+	assert.Len(s.Cached, 1)
+	assert.Equal(cached[0].PeerTaskMetadata, s.Cached[0].PeerTaskMetadata)
+	assert.Equal(cached[0].ContentLength, s.Cached[0].ContentLength)
+	assert.True(s.Cached[0].Done)
+	assert.True(lastAccess.Equal(s.Cached[0].LastAccess))
+}
+
+func TestStatusManager_PinTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	peerTaskManager := mock_peer.NewMockTaskManager(ctrl)
+	storageManager := mock_storage.NewMockManager(ctrl)
+	storageManager.EXPECT().PinTask("task-1", true).Return(nil)
+	storageManager.EXPECT().PinTask("task-1", false).Return(nil)
+	storageManager.EXPECT().PinTask("task-2", true).Return(storage.ErrTaskNotFound)
+
+	sockPath := filepath.Join(t.TempDir(), "status.sock")
+	lis, err := net.Listen("unix", sockPath)
+	assert.Nil(err)
+
+	sm := NewStatusManager(peerTaskManager, storageManager, func() {})
+	go sm.Serve(lis)
+	defer sm.Stop()
+
+	assert.Nil(PinTask(context.Background(), sockPath, "task-1", true))
+	assert.Nil(PinTask(context.Background(), sockPath, "task-1", false))
+	err = PinTask(context.Background(), sockPath, "task-2", true)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "404")
+	}
+}
+
+func TestStatusManager_Drain(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	peerTaskManager := mock_peer.NewMockTaskManager(ctrl)
+	storageManager := mock_storage.NewMockManager(ctrl)
+
+	sockPath := filepath.Join(t.TempDir(), "status.sock")
+	lis, err := net.Listen("unix", sockPath)
+	assert.Nil(err)
+
+	drained := make(chan struct{})
+	sm := NewStatusManager(peerTaskManager, storageManager, func() { close(drained) })
+	go sm.Serve(lis)
+	defer sm.Stop()
+
+	assert.Nil(Drain(context.Background(), sockPath))
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		assert.Fail("dfdaemon is not drained")
+	}
+}
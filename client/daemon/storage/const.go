@@ -18,6 +18,7 @@ package storage
 
 import (
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -28,6 +29,9 @@ const (
 
 	defaultFileMode      = os.FileMode(0644)
 	defaultDirectoryMode = os.FileMode(0755)
+
+	// metadataPersistInterval is the minimal interval to save metadata of an unfinished task
+	metadataPersistInterval = time.Second
 )
 
 var (
@@ -0,0 +1,275 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"go.uber.org/atomic"
+
+	"d7y.io/dragonfly/v2/client/config"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+)
+
+const (
+	// diskProbeFile is written and removed to check whether a data path is writable
+	diskProbeFile = ".probe"
+)
+
+// dataDisk is a data path to store task data, a failing data path is taken out of rotation
+// until it passes the health check again
+type dataDisk struct {
+	config.DataPathOption
+	stat    *syscall.Stat_t
+	healthy atomic.Bool
+	// loaded is true when the tasks in the data path are reloaded, the data path unhealthy
+	// at startup is reloaded when it passes the health check
+	loaded atomic.Bool
+}
+
+// diskError indicates an error caused by the data path, not by the task itself
+type diskError struct {
+	disk *dataDisk
+	err  error
+}
+
+func (e *diskError) Error() string {
+	return fmt.Sprintf("data path %s error: %s", e.disk.Path, e.err)
+}
+
+func (e *diskError) Unwrap() error {
+	return e.err
+}
+
+// initDisks initializes the data paths in storage option, the data paths which can not be initialized
+// are marked as unhealthy, an error is returned only when none of them is available
+func initDisks(opt *config.StorageOption) ([]*dataDisk, error) {
+	options := opt.DataPaths
+	if len(options) == 0 {
+		options = []*config.DataPathOption{{Path: opt.DataPath}}
+	}
+
+	var (
+		disks   []*dataDisk
+		healthy int
+	)
+	for _, o := range options {
+		d := &dataDisk{DataPathOption: *o}
+		if d.DiskGCThreshold == 0 {
+			d.DiskGCThreshold = opt.DiskGCThreshold
+		}
+		if d.DiskGCThresholdPercent == 0 {
+			d.DiskGCThresholdPercent = opt.DiskGCThresholdPercent
+		}
+		if !path.IsAbs(d.Path) {
+			abs, err := filepath.Abs(d.Path)
+			if err != nil {
+				return nil, err
+			}
+			d.Path = abs
+		}
+		disks = append(disks, d)
+
+		if err := d.init(); err != nil {
+			logger.Errorf("init data path %s error: %s", d.Path, err)
+			continue
+		}
+		d.healthy.Store(true)
+		healthy++
+	}
+	// keep DataPath same with the first data path for compatibility
+	opt.DataPath = disks[0].Path
+	if healthy == 0 {
+		return nil, fmt.Errorf("no available data path")
+	}
+	return disks, nil
+}
+
+func (d *dataDisk) init() error {
+	if err := os.MkdirAll(d.Path, defaultDirectoryMode); err != nil {
+		return err
+	}
+	stat, err := os.Stat(d.Path)
+	if err != nil {
+		return err
+	}
+	d.stat = stat.Sys().(*syscall.Stat_t)
+	return nil
+}
+
+// check probes the data path and updates the health status, returns true when the data path recovers
+func (d *dataDisk) check() bool {
+	var err error
+	if d.stat == nil {
+		err = d.init()
+	}
+	if err == nil {
+		err = d.probe()
+	}
+	if err != nil {
+		if d.healthy.CAS(true, false) {
+			logger.Errorf("data path %s is unhealthy, take it out of rotation: %s", d.Path, err)
+		}
+		return false
+	}
+	if d.healthy.CAS(false, true) {
+		logger.Infof("data path %s is healthy again, put it back into rotation", d.Path)
+		return true
+	}
+	return false
+}
+
+func (d *dataDisk) probe() error {
+	probe := path.Join(d.Path, diskProbeFile)
+	if err := os.WriteFile(probe, []byte("probe"), defaultFileMode); err != nil {
+		return err
+	}
+	return os.Remove(probe)
+}
+
+// markUnhealthy takes the data path out of rotation, it will be put back by the next successful check
+func (d *dataDisk) markUnhealthy(err error) {
+	if d.healthy.CAS(true, false) {
+		logger.Errorf("data path %s is unhealthy, take it out of rotation: %s", d.Path, err)
+	}
+}
+
+// fits checks whether the task with the content length can be placed in the data path,
+// the task with unknown content length can be placed anywhere
+func (d *dataDisk) fits(contentLength int64) bool {
+	return d.MaxTaskSize == 0 || contentLength < 0 || contentLength <= d.MaxTaskSize.ToNumber()
+}
+
+// free returns the bytes can be used before reaching the gc thresholds
+func (d *dataDisk) free(used int64) int64 {
+	free := int64(math.MaxInt64)
+	if d.DiskGCThreshold > 0 {
+		free = d.DiskGCThreshold.ToNumber() - used
+	}
+	usage, err := disk.Usage(d.Path)
+	if err != nil {
+		logger.Warnf("get %s disk usage error: %s", d.Path, err)
+		return free
+	}
+	diskFree := int64(usage.Free)
+	if d.DiskGCThresholdPercent > 0 {
+		diskFree = int64(usage.Total)*int64(d.DiskGCThresholdPercent*100)/10000 - int64(usage.Used)
+	}
+	if diskFree < free {
+		free = diskFree
+	}
+	return free
+}
+
+// usageExceed returns the bytes exceed the disk usage threshold
+func (d *dataDisk) usageExceed() (exceed bool, bytes int64) {
+	if d.DiskGCThresholdPercent <= 0 {
+		return false, 0
+	}
+	usage, err := disk.Usage(d.Path)
+	if err != nil {
+		logger.Warnf("get %s disk usage error: %s", d.Path, err)
+		return false, 0
+	}
+	logger.Debugf("disk usage: %#v", usage)
+	if usage.UsedPercent < d.DiskGCThresholdPercent {
+		return false, 0
+	}
+
+	bs := (usage.UsedPercent - d.DiskGCThresholdPercent) / 100 * float64(usage.Total)
+	logger.Infof("disk %s used percent %f, exceed threshold percent %f, %d bytes to reclaim",
+		d.Path, usage.UsedPercent, d.DiskGCThresholdPercent, int64(bs))
+	return true, int64(bs)
+}
+
+// placeDisks returns the healthy data paths which the new task can be placed in, the preferred first
+func (s *storageManager) placeDisks(contentLength int64) []*dataDisk {
+	var disks []*dataDisk
+	for _, d := range s.disks {
+		if d.healthy.Load() && d.fits(contentLength) {
+			disks = append(disks, d)
+		}
+	}
+	if len(disks) <= 1 {
+		return disks
+	}
+
+	switch s.storeOption.Placement {
+	case config.SizePlacementPolicy:
+		// best fit, the data path with the smallest max task size is preferred
+		sort.SliceStable(disks, func(i, j int) bool {
+			si, sj := disks[i].MaxTaskSize, disks[j].MaxTaskSize
+			if si == 0 || sj == 0 {
+				return sj == 0 && si != 0
+			}
+			return si < sj
+		})
+	case config.RoundRobinPlacementPolicy:
+		n := int(s.placementCount.Inc() % uint64(len(disks)))
+		disks = append(disks[n:], disks[:n]...)
+	default:
+		used := s.diskUsedBytes()
+		free := make(map[*dataDisk]int64, len(disks))
+		for _, d := range disks {
+			free[d] = d.free(used[d])
+		}
+		sort.SliceStable(disks, func(i, j int) bool {
+			return free[disks[i]] > free[disks[j]]
+		})
+	}
+	return disks
+}
+
+// diskUsedBytes returns the bytes of tasks in each data path
+func (s *storageManager) diskUsedBytes() map[*dataDisk]int64 {
+	used := map[*dataDisk]int64{}
+	s.tasks.Range(func(key, val interface{}) bool {
+		t := val.(*localTaskStore)
+		if t.ContentLength > 0 {
+			used[t.disk] += t.ContentLength
+		}
+		return true
+	})
+	return used
+}
+
+// checkDisks checks all data paths, the tasks in the recovered data path are reloaded if they were not
+func (s *storageManager) checkDisks() {
+	for _, d := range s.disks {
+		if d.check() && !d.loaded.Load() {
+			s.reloadRecoveredDisk(d)
+		}
+	}
+}
+
+func (s *storageManager) reloadRecoveredDisk(d *dataDisk) {
+	s.indexRWMutex.Lock()
+	loadErrs, loadErrDirs := s.reloadDisk(d, s.gcCallback)
+	s.indexRWMutex.Unlock()
+	d.loaded.Store(true)
+	removeLoadErrDirs(loadErrDirs)
+	for _, err := range loadErrs {
+		logger.Warnf("reload tasks in data path %s error: %s", d.Path, err)
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/unit"
+)
+
+func newMultiDiskStorageManager(t *testing.T, placement config.PlacementPolicy, dataPaths ...*config.DataPathOption) *storageManager {
+	opt := &config.StorageOption{
+		TaskExpireTime: clientutil.Duration{
+			Duration: time.Hour,
+		},
+		DataPaths: dataPaths,
+		Placement: placement,
+	}
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy, opt, func(request CommonTaskRequest) {},
+		WithGCInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sm.(*storageManager)
+}
+
+func registerTestTask(t *testing.T, sm *storageManager, taskID string, contentLength int64) *localTaskStore {
+	ts, err := sm.RegisterTask(context.Background(),
+		RegisterTaskRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: "peer-" + taskID,
+				TaskID: taskID,
+			},
+			ContentLength: contentLength,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts.(*localTaskStore)
+}
+
+func TestStorageManager_Placement(t *testing.T) {
+	assert := testifyassert.New(t)
+	nvme, hdd := t.TempDir(), t.TempDir()
+
+	sm := newMultiDiskStorageManager(t, config.RoundRobinPlacementPolicy,
+		&config.DataPathOption{Path: nvme}, &config.DataPathOption{Path: hdd})
+	var placed []string
+	for i := 0; i < 4; i++ {
+		placed = append(placed, registerTestTask(t, sm, fmt.Sprintf("task-rr-%d", i), 1024).disk.Path)
+	}
+	assert.NotEqual(placed[0], placed[1], "tasks should be placed in turn")
+	assert.Equal(placed[0], placed[2], "tasks should be placed in turn")
+	assert.Equal(placed[1], placed[3], "tasks should be placed in turn")
+	sm.CleanUp()
+
+	sm = newMultiDiskStorageManager(t, config.SizePlacementPolicy,
+		&config.DataPathOption{Path: hdd}, &config.DataPathOption{Path: nvme, MaxTaskSize: unit.MB})
+	assert.Equal(nvme, registerTestTask(t, sm, "task-small", 1024).disk.Path, "small task should be placed in nvme")
+	assert.Equal(hdd, registerTestTask(t, sm, "task-large", 2*unit.MB.ToNumber()).disk.Path, "large task should be placed in hdd")
+	sm.CleanUp()
+
+	sm = newMultiDiskStorageManager(t, config.FreeSpacePlacementPolicy,
+		&config.DataPathOption{Path: nvme, DiskGCThreshold: unit.MB}, &config.DataPathOption{Path: hdd, DiskGCThreshold: 2 * unit.MB})
+	assert.Equal(hdd, registerTestTask(t, sm, "task-1", unit.MB.ToNumber()).disk.Path, "task should be placed in the freer data path")
+	assert.Equal(nvme, registerTestTask(t, sm, "task-2", 1024).disk.Path, "task should be placed in the freer data path")
+	sm.CleanUp()
+}
+
+func TestStorageManager_FailingDisk(t *testing.T) {
+	assert := testifyassert.New(t)
+	bad, good := path.Join(t.TempDir(), "bad"), t.TempDir()
+
+	sm := newMultiDiskStorageManager(t, config.RoundRobinPlacementPolicy,
+		&config.DataPathOption{Path: bad}, &config.DataPathOption{Path: good})
+	defer sm.CleanUp()
+
+	// simulate a failing disk
+	assert.Nil(os.RemoveAll(bad))
+	assert.Nil(os.WriteFile(bad, []byte("broken"), defaultFileMode))
+	for i := 0; i < 3; i++ {
+		assert.Equal(good, registerTestTask(t, sm, fmt.Sprintf("task-%d", i), 1024).disk.Path,
+			"task should not be placed in the failing data path")
+	}
+	assert.False(sm.disks[0].healthy.Load(), "failing data path should be taken out of rotation")
+
+	// recover the disk
+	assert.Nil(os.Remove(bad))
+	assert.Nil(os.MkdirAll(bad, defaultDirectoryMode))
+	_, err := sm.TryGC()
+	assert.Nil(err)
+	assert.True(sm.disks[0].healthy.Load(), "recovered data path should be put back into rotation")
+
+	var placed []string
+	for i := 3; i < 5; i++ {
+		placed = append(placed, registerTestTask(t, sm, fmt.Sprintf("task-%d", i), 1024).disk.Path)
+	}
+	assert.ElementsMatch([]string{bad, good}, placed)
+
+	sm.disks[0].markUnhealthy(fmt.Errorf("mock error"))
+	sm.disks[1].markUnhealthy(fmt.Errorf("mock error"))
+	_, err = sm.CreateTask(RegisterTaskRequest{CommonTaskRequest: CommonTaskRequest{PeerID: "peer", TaskID: "task"}})
+	assert.Equal(ErrNoAvailableDisk, err)
+}
+
+func TestStorageManager_UnhealthyDiskTasks(t *testing.T) {
+	assert := testifyassert.New(t)
+	bad, good := t.TempDir(), t.TempDir()
+	sm := newMultiDiskStorageManager(t, config.RoundRobinPlacementPolicy,
+		&config.DataPathOption{Path: bad}, &config.DataPathOption{Path: good})
+	var task *localTaskStore
+	for i := 0; task == nil || task.disk.Path != bad; i++ {
+		task = registerTestTask(t, sm, fmt.Sprintf("task-%d", i), 1024)
+	}
+	task.Done = true
+	assert.Nil(task.saveMetadata())
+	assert.NotNil(sm.FindCompletedTask(task.TaskID))
+
+	// the completed task in unhealthy data path is not reused or uploaded
+	sm.disks[0].markUnhealthy(fmt.Errorf("mock error"))
+	assert.Nil(sm.FindCompletedTask(task.TaskID))
+	_, err := sm.GetPieces(context.Background(), &base.PieceTaskRequest{TaskId: task.TaskID, DstPid: task.PeerID})
+	assert.Equal(ErrTaskNotFound, err)
+	for _, cached := range sm.ListTasks() {
+		assert.NotEqual(task.TaskID, cached.TaskID)
+	}
+
+	// the data path unhealthy at startup is reloaded when it recovers
+	assert.Nil(os.Rename(bad, bad+".bak"))
+	assert.Nil(os.WriteFile(bad, []byte("broken"), defaultFileMode))
+	sm = newMultiDiskStorageManager(t, config.RoundRobinPlacementPolicy,
+		&config.DataPathOption{Path: bad}, &config.DataPathOption{Path: good})
+	defer sm.CleanUp()
+	assert.False(sm.disks[0].healthy.Load())
+
+	assert.Nil(os.Remove(bad))
+	assert.Nil(os.Rename(bad+".bak", bad))
+	_, err = sm.TryGC()
+	assert.Nil(err)
+	assert.True(sm.disks[0].healthy.Load())
+	assert.NotNil(sm.FindCompletedTask(task.TaskID), "task in recovered data path should be reloaded")
+}
+
+func TestStorageManager_TryGCPerDisk(t *testing.T) {
+	assert := testifyassert.New(t)
+	small, large := t.TempDir(), t.TempDir()
+
+	sm := newMultiDiskStorageManager(t, config.SizePlacementPolicy,
+		&config.DataPathOption{Path: small, DiskGCThreshold: 3 * unit.KB, MaxTaskSize: 2 * unit.KB},
+		&config.DataPathOption{Path: large, DiskGCThreshold: 100 * unit.KB})
+	defer sm.CleanUp()
+
+	now := time.Now()
+	var smallTasks []*localTaskStore
+	for i := 0; i < 4; i++ {
+		ts := registerTestTask(t, sm, fmt.Sprintf("task-small-%d", i), unit.KB.ToNumber())
+		ts.Done = true
+		ts.lastAccess.Store(now.Add(time.Duration(i-10) * time.Minute).UnixNano())
+		smallTasks = append(smallTasks, ts)
+	}
+	largeTask := registerTestTask(t, sm, "task-large", 10*unit.KB.ToNumber())
+	largeTask.Done = true
+	largeTask.lastAccess.Store(now.Add(-20 * time.Minute).UnixNano())
+
+	_, err := sm.TryGC()
+	assert.Nil(err)
+	assert.True(smallTasks[0].reclaimMarked.Load(), "the oldest task in the exceeded data path should be reclaimed")
+	for _, ts := range smallTasks[1:] {
+		assert.False(ts.reclaimMarked.Load())
+	}
+	assert.False(largeTask.reclaimMarked.Load(), "task in other data path should not be reclaimed")
+}
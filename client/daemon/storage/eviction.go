@@ -0,0 +1,344 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"sync"
+
+	"d7y.io/dragonfly/v2/client/config"
+)
+
+// EvictionCandidate is a task which can be reclaimed when disk gc threshold reached
+type EvictionCandidate struct {
+	PeerTaskMetadata
+	ContentLength int64
+	// Hits is the reused count of the task
+	Hits int64
+	// LastAccess is the last access time of the task in unix nano
+	LastAccess int64
+}
+
+// EvictionPolicy decides the order to reclaim tasks when disk gc threshold reached, the state of policy
+// is kept by task id, so that it is carried over when a reclaimed task is downloaded again by a new peer
+type EvictionPolicy interface {
+	// Access records an access of the task, it's called when the task is created, reloaded or reused
+	Access(candidate *EvictionCandidate)
+	// Evict records that the task is reclaimed
+	Evict(candidate *EvictionCandidate)
+	// Remove forgets the task without treating it as reclaimed, eg: the task is taken over by a new peer
+	Remove(candidate *EvictionCandidate)
+	// Sort sorts the candidates, the former will be reclaimed first
+	Sort(candidates []*EvictionCandidate)
+}
+
+func newEvictionPolicy(policy config.EvictionPolicy) (EvictionPolicy, error) {
+	switch policy {
+	case config.LRUEvictionPolicy, config.EvictionPolicy(""):
+		return &lruPolicy{}, nil
+	case config.LFUEvictionPolicy:
+		return newAgingPolicy(func(c *EvictionCandidate) float64 {
+			return float64(c.Hits + 1)
+		}), nil
+	case config.GDSFEvictionPolicy:
+		return newAgingPolicy(func(c *EvictionCandidate) float64 {
+			size := c.ContentLength
+			if size < 1 {
+				size = 1
+			}
+			// frequency per MiB, the cost of fetching any task is treated as the same
+			return float64(c.Hits+1) * (1 << 20) / float64(size)
+		}), nil
+	case config.ARCEvictionPolicy:
+		return newARCPolicy(), nil
+	default:
+		return nil, fmt.Errorf("not support eviction policy: %s", policy)
+	}
+}
+
+// lruPolicy reclaims the least recently used tasks first
+type lruPolicy struct{}
+
+func (p *lruPolicy) Access(*EvictionCandidate) {}
+
+func (p *lruPolicy) Evict(*EvictionCandidate) {}
+
+func (p *lruPolicy) Remove(*EvictionCandidate) {}
+
+func (p *lruPolicy) Sort(candidates []*EvictionCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].LastAccess < candidates[j].LastAccess
+	})
+}
+
+// agingPolicy reclaims the tasks with the lowest priority first, the priority of a task is
+// the inflation value when it's accessed plus its weight, the inflation value is raised to the
+// priority of the last reclaimed task, so that tasks which were hot long ago age out eventually.
+// LFU with dynamic aging uses frequency as weight, GDSF uses frequency divided by size as weight.
+type agingPolicy struct {
+	sync.Mutex
+	weight    func(*EvictionCandidate) float64
+	inflation float64
+	priority  map[string]float64
+}
+
+func newAgingPolicy(weight func(*EvictionCandidate) float64) *agingPolicy {
+	return &agingPolicy{
+		weight:   weight,
+		priority: map[string]float64{},
+	}
+}
+
+func (p *agingPolicy) Access(c *EvictionCandidate) {
+	p.Lock()
+	defer p.Unlock()
+	p.priority[c.TaskID] = p.inflation + p.weight(c)
+}
+
+func (p *agingPolicy) Evict(c *EvictionCandidate) {
+	p.Lock()
+	defer p.Unlock()
+	if priority, ok := p.priority[c.TaskID]; ok {
+		if priority > p.inflation {
+			p.inflation = priority
+		}
+		delete(p.priority, c.TaskID)
+	}
+}
+
+func (p *agingPolicy) Remove(c *EvictionCandidate) {
+	p.Lock()
+	defer p.Unlock()
+	delete(p.priority, c.TaskID)
+}
+
+func (p *agingPolicy) Sort(candidates []*EvictionCandidate) {
+	p.Lock()
+	priority := make(map[string]float64, len(candidates))
+	for _, c := range candidates {
+		pr, ok := p.priority[c.TaskID]
+		if !ok {
+			pr = p.inflation + p.weight(c)
+		}
+		priority[c.TaskID] = pr
+	}
+	p.Unlock()
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		pi, pj := priority[candidates[i].TaskID], priority[candidates[j].TaskID]
+		if pi != pj {
+			return pi < pj
+		}
+		return candidates[i].LastAccess < candidates[j].LastAccess
+	})
+}
+
+const (
+	arcRecent = iota
+	arcFrequent
+	arcRecentGhost
+	arcFrequentGhost
+)
+
+type arcEntry struct {
+	key  string
+	size int64
+	list int
+}
+
+// arcPolicy is an adaptive replacement cache policy measured in bytes, tasks accessed once
+// are kept in the recent list, tasks accessed more than once are kept in the frequent list,
+// reclaimed tasks are remembered in the ghost lists to adapt the target size of the recent list.
+type arcPolicy struct {
+	sync.Mutex
+	// target is the target bytes of the recent list
+	target  int64
+	lists   [4]*list.List
+	sizes   [4]int64
+	entries map[string]*list.Element
+}
+
+func newARCPolicy() *arcPolicy {
+	p := &arcPolicy{
+		entries: map[string]*list.Element{},
+	}
+	for i := range p.lists {
+		p.lists[i] = list.New()
+	}
+	return p
+}
+
+func (p *arcPolicy) Access(c *EvictionCandidate) {
+	p.Lock()
+	defer p.Unlock()
+	elem, ok := p.entries[c.TaskID]
+	if !ok {
+		// reloaded task which was reused before is treated as frequent
+		l := arcRecent
+		if c.Hits > 0 {
+			l = arcFrequent
+		}
+		p.push(l, &arcEntry{key: c.TaskID, size: c.ContentLength})
+		return
+	}
+
+	e := p.remove(elem)
+	e.size = c.ContentLength
+	switch e.list {
+	case arcRecentGhost:
+		// the recent list is too small, grow it
+		delta := e.size
+		if p.sizes[arcFrequentGhost] > p.sizes[arcRecentGhost] && p.sizes[arcRecentGhost] > 0 {
+			delta = e.size * p.sizes[arcFrequentGhost] / p.sizes[arcRecentGhost]
+		}
+		p.target = min64(p.target+delta, p.sizes[arcRecent]+p.sizes[arcFrequent]+e.size)
+	case arcFrequentGhost:
+		// the frequent list is too small, shrink the recent list
+		delta := e.size
+		if p.sizes[arcRecentGhost] > p.sizes[arcFrequentGhost] && p.sizes[arcFrequentGhost] > 0 {
+			delta = e.size * p.sizes[arcRecentGhost] / p.sizes[arcFrequentGhost]
+		}
+		p.target = max64(p.target-delta, 0)
+	}
+	p.push(arcFrequent, e)
+}
+
+func (p *arcPolicy) Evict(c *EvictionCandidate) {
+	p.Lock()
+	defer p.Unlock()
+	elem, ok := p.entries[c.TaskID]
+	if !ok {
+		return
+	}
+	e := p.remove(elem)
+	switch e.list {
+	case arcRecent:
+		p.push(arcRecentGhost, e)
+	case arcFrequent:
+		p.push(arcFrequentGhost, e)
+	}
+	p.trimGhosts()
+}
+
+func (p *arcPolicy) Remove(c *EvictionCandidate) {
+	p.Lock()
+	defer p.Unlock()
+	if elem, ok := p.entries[c.TaskID]; ok {
+		p.remove(elem)
+	}
+}
+
+func (p *arcPolicy) Sort(candidates []*EvictionCandidate) {
+	p.Lock()
+	defer p.Unlock()
+	var (
+		recent, frequent []*EvictionCandidate
+		rank             = map[string]int{}
+	)
+	// rank tasks from the least recently used in each list
+	for _, l := range []*list.List{p.lists[arcRecent], p.lists[arcFrequent]} {
+		n := 0
+		for elem := l.Back(); elem != nil; elem = elem.Prev() {
+			rank[elem.Value.(*arcEntry).key] = n
+			n++
+		}
+	}
+	for _, c := range candidates {
+		elem, ok := p.entries[c.TaskID]
+		if ok && elem.Value.(*arcEntry).list == arcFrequent {
+			frequent = append(frequent, c)
+		} else {
+			recent = append(recent, c)
+		}
+	}
+	byRank := func(cs []*EvictionCandidate) {
+		sort.SliceStable(cs, func(i, j int) bool {
+			ri, oki := rank[cs[i].TaskID]
+			rj, okj := rank[cs[j].TaskID]
+			// unknown tasks are reclaimed first
+			if oki != okj {
+				return !oki
+			}
+			if ri != rj {
+				return ri < rj
+			}
+			return cs[i].LastAccess < cs[j].LastAccess
+		})
+	}
+	byRank(recent)
+	byRank(frequent)
+
+	// reclaim from the recent list when it's larger than the target, otherwise from the frequent list
+	recentSize := p.sizes[arcRecent]
+	i := 0
+	for len(recent) > 0 || len(frequent) > 0 {
+		var c *EvictionCandidate
+		if len(frequent) == 0 || (len(recent) > 0 && recentSize > p.target) {
+			c, recent = recent[0], recent[1:]
+			recentSize -= c.ContentLength
+		} else {
+			c, frequent = frequent[0], frequent[1:]
+		}
+		candidates[i] = c
+		i++
+	}
+}
+
+func (p *arcPolicy) push(l int, e *arcEntry) {
+	e.list = l
+	p.entries[e.key] = p.lists[l].PushFront(e)
+	p.sizes[l] += e.size
+}
+
+func (p *arcPolicy) remove(elem *list.Element) *arcEntry {
+	e := p.lists[elem.Value.(*arcEntry).list].Remove(elem).(*arcEntry)
+	p.sizes[e.list] -= e.size
+	delete(p.entries, e.key)
+	return e
+}
+
+// trimGhosts keeps the ghost lists no larger than the cached tasks
+func (p *arcPolicy) trimGhosts() {
+	capacity := p.sizes[arcRecent] + p.sizes[arcFrequent]
+	for p.sizes[arcRecentGhost]+p.sizes[arcFrequentGhost] > capacity {
+		l := arcFrequentGhost
+		if p.sizes[arcRecentGhost] > p.sizes[arcFrequentGhost] {
+			l = arcRecentGhost
+		}
+		elem := p.lists[l].Back()
+		if elem == nil {
+			return
+		}
+		p.remove(elem)
+	}
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
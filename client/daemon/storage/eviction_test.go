@@ -0,0 +1,251 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/unit"
+)
+
+type traceAccess struct {
+	key  string
+	size int64
+}
+
+// replayTrace replays the accesses against a cache with the given capacity in bytes, returns the hit ratio
+func replayTrace(t *testing.T, policy config.EvictionPolicy, capacity int64, trace []traceAccess) float64 {
+	p, err := newEvictionPolicy(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		resident = map[string]*EvictionCandidate{}
+		used     int64
+		hits     int
+	)
+	for i, access := range trace {
+		c, ok := resident[access.key]
+		if ok {
+			hits++
+			c.Hits++
+		} else {
+			// the reclaimed task is downloaded again by a new peer
+			c = &EvictionCandidate{
+				PeerTaskMetadata: PeerTaskMetadata{TaskID: access.key, PeerID: fmt.Sprintf("peer-%d", i)},
+				ContentLength:    access.size,
+			}
+			resident[access.key] = c
+			used += access.size
+		}
+		c.LastAccess = int64(i)
+		p.Access(c)
+
+		for used > capacity {
+			var candidates []*EvictionCandidate
+			for _, c := range resident {
+				// the current task is downloading, can not be reclaimed
+				if c.TaskID != access.key {
+					candidates = append(candidates, c)
+				}
+			}
+			p.Sort(candidates)
+			victim := candidates[0]
+			p.Evict(victim)
+			delete(resident, victim.TaskID)
+			used -= victim.ContentLength
+		}
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+const (
+	mib = int64(1 << 20)
+	gib = 1024 * mib
+)
+
+// mixedTrace generates many small hot layers accessed repeatedly, interleaved with a few huge datasets accessed once
+func mixedTrace() []traceAccess {
+	r := rand.New(rand.NewSource(1))
+	var trace []traceAccess
+	dataset := 0
+	for i := 0; i < 5000; i++ {
+		if i%50 == 0 {
+			trace = append(trace, traceAccess{key: fmt.Sprintf("dataset-%d", dataset), size: 4 * gib})
+			dataset++
+			continue
+		}
+		layer := r.Intn(200)
+		trace = append(trace, traceAccess{key: fmt.Sprintf("layer-%d", layer), size: 20 * mib})
+	}
+	return trace
+}
+
+// scanTrace generates a skewed working set which is polluted by a sequential scan periodically
+func scanTrace() []traceAccess {
+	r := rand.New(rand.NewSource(2))
+	var trace []traceAccess
+	scan := 0
+	for round := 0; round < 50; round++ {
+		for i := 0; i < 200; i++ {
+			// 80% of accesses hit 20% of tasks
+			var task int
+			if r.Intn(10) < 8 {
+				task = r.Intn(20)
+			} else {
+				task = 20 + r.Intn(80)
+			}
+			trace = append(trace, traceAccess{key: fmt.Sprintf("task-%d", task), size: 100 * mib})
+		}
+		for i := 0; i < 60; i++ {
+			trace = append(trace, traceAccess{key: fmt.Sprintf("scan-%d", scan), size: 100 * mib})
+			scan++
+		}
+	}
+	return trace
+}
+
+func TestEvictionPolicy_MixedTrace(t *testing.T) {
+	assert := testifyassert.New(t)
+	trace := mixedTrace()
+	capacity := 8 * gib
+
+	lru := replayTrace(t, config.LRUEvictionPolicy, capacity, trace)
+	lfu := replayTrace(t, config.LFUEvictionPolicy, capacity, trace)
+	gdsf := replayTrace(t, config.GDSFEvictionPolicy, capacity, trace)
+	arc := replayTrace(t, config.ARCEvictionPolicy, capacity, trace)
+	t.Logf("mixed trace hit ratio, lru: %.3f, lfu: %.3f, gdsf: %.3f, arc: %.3f", lru, lfu, gdsf, arc)
+
+	assert.Greater(gdsf, lru, "size aware policy should keep small hot layers")
+	assert.Greater(lfu, lru, "lfu should keep frequently used layers")
+	assert.Greater(arc, lru, "arc should keep frequently used layers")
+}
+
+func TestEvictionPolicy_ScanTrace(t *testing.T) {
+	assert := testifyassert.New(t)
+	trace := scanTrace()
+	capacity := 30 * 100 * mib
+
+	lru := replayTrace(t, config.LRUEvictionPolicy, capacity, trace)
+	lfu := replayTrace(t, config.LFUEvictionPolicy, capacity, trace)
+	arc := replayTrace(t, config.ARCEvictionPolicy, capacity, trace)
+	t.Logf("scan trace hit ratio, lru: %.3f, lfu: %.3f, arc: %.3f", lru, lfu, arc)
+
+	assert.Greater(lfu, lru, "lfu should resist scan")
+	assert.Greater(arc, lru, "arc should resist scan")
+}
+
+func TestEvictionPolicy_LRU(t *testing.T) {
+	assert := testifyassert.New(t)
+	p, err := newEvictionPolicy(config.EvictionPolicy(""))
+	assert.Nil(err)
+
+	candidates := []*EvictionCandidate{
+		{PeerTaskMetadata: PeerTaskMetadata{TaskID: "a"}, LastAccess: 3, Hits: 10},
+		{PeerTaskMetadata: PeerTaskMetadata{TaskID: "b"}, LastAccess: 1},
+		{PeerTaskMetadata: PeerTaskMetadata{TaskID: "c"}, LastAccess: 2},
+	}
+	p.Sort(candidates)
+	var order []string
+	for _, c := range candidates {
+		order = append(order, c.TaskID)
+	}
+	assert.Equal([]string{"b", "c", "a"}, order)
+}
+
+func TestEvictionPolicy_LFUAging(t *testing.T) {
+	assert := testifyassert.New(t)
+	p, err := newEvictionPolicy(config.LFUEvictionPolicy)
+	assert.Nil(err)
+
+	// the old task was hot long ago
+	old := &EvictionCandidate{PeerTaskMetadata: PeerTaskMetadata{TaskID: "old"}, Hits: 5}
+	p.Access(old)
+
+	// new tasks are accessed once and reclaimed one by one, the inflation value grows
+	for i := 0; i < 10; i++ {
+		c := &EvictionCandidate{PeerTaskMetadata: PeerTaskMetadata{TaskID: fmt.Sprintf("task-%d", i)}}
+		p.Access(c)
+		c.Hits++
+		p.Access(c)
+		candidates := []*EvictionCandidate{old, c}
+		p.Sort(candidates)
+		p.Evict(candidates[0])
+		if candidates[0] == old {
+			assert.GreaterOrEqual(i, 2, "hot task should survive for a while")
+			return
+		}
+	}
+	t.Fatal("the old hot task should be aged out")
+}
+
+func TestEvictionPolicy_ReclaimedTaskWithNewPeer(t *testing.T) {
+	assert := testifyassert.New(t)
+	reclaimed := &EvictionCandidate{PeerTaskMetadata: PeerTaskMetadata{TaskID: "task", PeerID: "peer-0"}, ContentLength: 100}
+	// the reclaimed task is downloaded again by a new peer
+	again := &EvictionCandidate{PeerTaskMetadata: PeerTaskMetadata{TaskID: "task", PeerID: "peer-1"}, ContentLength: 100}
+
+	arc := newARCPolicy()
+	// the ghost lists are no larger than the cached tasks
+	arc.Access(&EvictionCandidate{PeerTaskMetadata: PeerTaskMetadata{TaskID: "other", PeerID: "peer-0"}, ContentLength: 100})
+	arc.Access(reclaimed)
+	arc.Evict(reclaimed)
+	assert.Equal(arcRecentGhost, arc.entries["task"].Value.(*arcEntry).list)
+	arc.Access(again)
+	assert.Equal(arcFrequent, arc.entries["task"].Value.(*arcEntry).list, "ghost hit should promote the task")
+	assert.Greater(arc.target, int64(0), "ghost hit should grow the target of recent list")
+
+	aging := newAgingPolicy(func(*EvictionCandidate) float64 { return 1 })
+	aging.Access(reclaimed)
+	aging.Access(again)
+	assert.Len(aging.priority, 1)
+}
+
+func TestEvictionPolicy_Unknown(t *testing.T) {
+	_, err := newEvictionPolicy(config.EvictionPolicy("unknown"))
+	testifyassert.NotNil(t, err)
+}
+
+func TestStorageManager_EvictionPolicyRemove(t *testing.T) {
+	assert := testifyassert.New(t)
+	sm := newMultiDiskStorageManager(t, config.SizePlacementPolicy, &config.DataPathOption{Path: t.TempDir()})
+	aging, arc := newAgingPolicy(func(*EvictionCandidate) float64 { return 1 }), newARCPolicy()
+	for name, policy := range map[string]EvictionPolicy{"aging": aging, "arc": arc} {
+		sm.evictionPolicy = policy
+		broken := registerTestTask(t, sm, "task-broken-"+name, unit.KB.ToNumber())
+		registerTestTask(t, sm, "task-ok-"+name, unit.KB.ToNumber())
+
+		key := PeerTaskMetadata{TaskID: broken.TaskID, PeerID: broken.PeerID}
+		// the data directory is not empty, reclaim fails
+		assert.Nil(os.WriteFile(path.Join(broken.dataDir, "unknown"), []byte("unknown"), defaultFileMode))
+		sm.markedReclaimTasks = []PeerTaskMetadata{key}
+		_, err := sm.TryGC()
+		assert.Nil(err)
+		_, ok := sm.tasks.Load(key)
+		assert.False(ok)
+		sm.CleanUp()
+	}
+	assert.Empty(aging.priority, "removed tasks should be forgotten")
+	assert.Empty(arc.entries, "removed tasks should be forgotten")
+}
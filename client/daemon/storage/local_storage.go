@@ -38,6 +38,8 @@ type localTaskStore struct {
 
 	sync.RWMutex
 
+	// disk is the data path which the task is placed in
+	disk    *dataDisk
 	dataDir string
 
 	metadataFile     *os.File
@@ -45,6 +47,7 @@ type localTaskStore struct {
 
 	expireTime    time.Duration
 	lastAccess    atomic.Int64
+	lastPersist   atomic.Int64
 	reclaimMarked atomic.Bool
 	gcCallback    func(CommonTaskRequest)
 
@@ -130,6 +133,16 @@ func (t *localTaskStore) WritePiece(ctx context.Context, req *WritePieceRequest)
 			t.Debugf("reader is not a DigestReader")
 		}
 	}
+	// the same as md5, try to get sha256 digest from reader
+	if req.PieceMetadata.Digest == "" {
+		if get, ok := req.Reader.(digestutils.DigestReader); ok {
+			if digest := get.DigestOf(digestutils.Sha256Hash); digest != "" {
+				req.PieceMetadata.DigestAlgorithm = base.DigestAlgorithm_SHA256
+				req.PieceMetadata.Digest = digest
+				t.Debugf("read sha256 from reader, value: %s", digest)
+			}
+		}
+	}
 
 	t.Debugf("wrote %d bytes to file %s, piece %d, start %d, length: %d",
 		n, t.DataFilePath, req.Num, req.Range.Start, req.Range.Length)
@@ -141,9 +154,44 @@ func (t *localTaskStore) WritePiece(ctx context.Context, req *WritePieceRequest)
 	}
 	t.Pieces[req.Num] = req.PieceMetadata
 	t.genDigest(n, req)
+	t.persistPieces()
 	return n, nil
 }
 
+// persistPieces saves the metadata periodically, the written pieces of an unfinished task
+// will be resumed and the hits will be kept after dfdaemon restarted, caller must hold the lock
+func (t *localTaskStore) persistPieces() {
+	now := time.Now().UnixNano()
+	if now-t.lastPersist.Load() < int64(metadataPersistInterval) {
+		return
+	}
+	t.lastPersist.Store(now)
+	if err := t.writeMetadata(); err != nil {
+		t.Warnf("persist pieces metadata error: %s", err)
+	}
+}
+
+// rebind moves an unfinished task to the work directory of a new peer
+func (t *localTaskStore) rebind(peerID string) error {
+	t.Lock()
+	defer t.Unlock()
+	dataDir := path.Join(path.Dir(t.dataDir), peerID)
+	if err := os.Rename(t.dataDir, dataDir); err != nil {
+		return err
+	}
+	// data file of simple strategy is in the work directory,
+	// data file of advance strategy is linked by the work directory, just keep it
+	if t.DataFilePath == path.Join(t.dataDir, taskData) {
+		t.DataFilePath = path.Join(dataDir, taskData)
+	}
+	t.Infof("rebind task work directory from %s to %s", t.dataDir, dataDir)
+	t.dataDir = dataDir
+	t.metadataFilePath = path.Join(dataDir, taskMetadata)
+	t.PeerID = peerID
+	t.SugaredLoggerOnWith = logger.With("task", t.TaskID, "peer", peerID, "component", "localTaskStore")
+	return t.writeMetadata()
+}
+
 func (t *localTaskStore) genDigest(n int64, req *WritePieceRequest) {
 	if req.GenPieceDigest == nil || t.PieceMd5Sign != "" {
 		return
@@ -163,6 +211,25 @@ func (t *localTaskStore) genDigest(n int64, req *WritePieceRequest) {
 	digest := digestutils.Sha256(pieceDigests...)
 	t.PieceMd5Sign = digest
 	t.Infof("generated digest: %s", digest)
+
+	if digest, ok := t.genPieceDigestSign(); ok {
+		t.PieceDigestSign = digest
+		t.Infof("generated piece digest sign: %s", digest)
+	}
+}
+
+// genPieceDigestSign generates the sha256 of all piece digests,
+// it fails when any piece has no digest, caller must hold the lock
+func (t *localTaskStore) genPieceDigestSign() (string, bool) {
+	var pieceDigests []string
+	for i := int32(0); i < t.TotalPieces; i++ {
+		piece, ok := t.Pieces[i]
+		if !ok || piece.Digest == "" {
+			return "", false
+		}
+		pieceDigests = append(pieceDigests, piece.Digest)
+	}
+	return digestutils.Sha256(pieceDigests...), true
 }
 
 func (t *localTaskStore) UpdateTask(ctx context.Context, req *UpdateTaskRequest) error {
@@ -178,13 +245,19 @@ func (t *localTaskStore) UpdateTask(ctx context.Context, req *UpdateTaskRequest)
 		t.PieceMd5Sign = req.PieceMd5Sign
 		t.Debugf("update piece md5 sign: %s", t.PieceMd5Sign)
 	}
+	if len(t.PieceDigestSign) == 0 && len(req.PieceDigestSign) > 0 {
+		t.PieceDigestSign = req.PieceDigestSign
+		t.Debugf("update piece digest sign: %s", t.PieceDigestSign)
+	}
 	return nil
 }
 
+// ValidateDigest validates all pieces with PieceDigestSign when it is set and all pieces have digest,
+// otherwise with PieceMd5Sign, the pieces from old peers only have md5
 func (t *localTaskStore) ValidateDigest(*PeerTaskMetadata) error {
 	t.Lock()
 	defer t.Unlock()
-	if t.persistentMetadata.PieceMd5Sign == "" {
+	if t.persistentMetadata.PieceMd5Sign == "" && t.persistentMetadata.PieceDigestSign == "" {
 		t.invalid.Store(true)
 		return ErrDigestNotSet
 	}
@@ -194,6 +267,24 @@ func (t *localTaskStore) ValidateDigest(*PeerTaskMetadata) error {
 		return ErrPieceCountNotSet
 	}
 
+	if t.PieceDigestSign != "" {
+		digest, ok := t.genPieceDigestSign()
+		if ok && digest != t.PieceDigestSign {
+			t.Errorf("invalid piece digest sign, desired: %s, actual: %s", t.PieceDigestSign, digest)
+			t.invalid.Store(true)
+			return ErrInvalidDigest
+		}
+		if ok {
+			return nil
+		}
+		if t.PieceMd5Sign == "" {
+			t.Errorf("some pieces without digest and piece md5 sign not set")
+			t.invalid.Store(true)
+			return ErrInvalidDigest
+		}
+		t.Warnf("some pieces without digest, validate with piece md5 sign")
+	}
+
 	var pieceDigests []string
 	for i := int32(0); i < t.TotalPieces; i++ {
 		pieceDigests = append(pieceDigests, t.Pieces[i].Md5)
@@ -345,11 +436,12 @@ func (t *localTaskStore) GetPieces(ctx context.Context, req *base.PieceTaskReque
 	defer t.RUnlock()
 	t.touch()
 	piecePacket := &base.PiecePacket{
-		TaskId:        req.TaskId,
-		DstPid:        t.PeerID,
-		TotalPiece:    t.TotalPieces,
-		ContentLength: t.ContentLength,
-		PieceMd5Sign:  t.PieceMd5Sign,
+		TaskId:          req.TaskId,
+		DstPid:          t.PeerID,
+		TotalPiece:      t.TotalPieces,
+		ContentLength:   t.ContentLength,
+		PieceMd5Sign:    t.PieceMd5Sign,
+		PieceDigestSign: t.PieceDigestSign,
 	}
 	if t.TotalPieces > -1 && int32(req.StartNum) >= t.TotalPieces {
 		t.Warnf("invalid start num: %d", req.StartNum)
@@ -357,12 +449,14 @@ func (t *localTaskStore) GetPieces(ctx context.Context, req *base.PieceTaskReque
 	for i := int32(0); i < int32(req.Limit); i++ {
 		if piece, ok := t.Pieces[int32(req.StartNum)+i]; ok {
 			piecePacket.PieceInfos = append(piecePacket.PieceInfos, &base.PieceInfo{
-				PieceNum:    piece.Num,
-				RangeStart:  uint64(piece.Range.Start),
-				RangeSize:   uint32(piece.Range.Length),
-				PieceMd5:    piece.Md5,
-				PieceOffset: piece.Offset,
-				PieceStyle:  piece.Style,
+				PieceNum:        piece.Num,
+				RangeStart:      uint64(piece.Range.Start),
+				RangeSize:       uint32(piece.Range.Length),
+				PieceMd5:        piece.Md5,
+				PieceOffset:     piece.Offset,
+				PieceStyle:      piece.Style,
+				DigestAlgorithm: piece.DigestAlgorithm,
+				PieceDigest:     piece.Digest,
 			})
 		}
 	}
@@ -370,12 +464,65 @@ func (t *localTaskStore) GetPieces(ctx context.Context, req *base.PieceTaskReque
 }
 
 func (t *localTaskStore) CanReclaim() bool {
+	if t.isPinned() {
+		t.Debugf("reclaim check, task is pinned")
+		return false
+	}
 	access := time.Unix(0, t.lastAccess.Load())
 	reclaim := access.Add(t.expireTime).Before(time.Now())
 	t.Debugf("reclaim check, last access: %v, reclaim: %v", access, reclaim)
 	return reclaim
 }
 
+// hit increases the reused count and persists it periodically
+func (t *localTaskStore) hit() {
+	t.Lock()
+	defer t.Unlock()
+	t.Hits++
+	t.persistPieces()
+}
+
+func (t *localTaskStore) evictionCandidate() *EvictionCandidate {
+	t.RLock()
+	defer t.RUnlock()
+	return &EvictionCandidate{
+		PeerTaskMetadata: PeerTaskMetadata{
+			PeerID: t.PeerID,
+			TaskID: t.TaskID,
+		},
+		ContentLength: t.ContentLength,
+		Hits:          t.Hits,
+		LastAccess:    t.lastAccess.Load(),
+	}
+}
+
+// diskHealthy returns whether the data path of task is healthy, the task in unhealthy data path can not be reused
+func (t *localTaskStore) diskHealthy() bool {
+	return t.disk == nil || t.disk.healthy.Load()
+}
+
+func (t *localTaskStore) isPinned() bool {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Pinned
+}
+
+// pin updates the pinned flag and persists it, pinned task will be skipped by gc
+func (t *localTaskStore) pin(pinned bool) error {
+	t.Lock()
+	defer t.Unlock()
+	if t.Pinned == pinned {
+		return nil
+	}
+	t.Pinned = pinned
+	if pinned {
+		// the task marked by the last gc is kept, it is skipped when reclaiming marked tasks
+		t.reclaimMarked.Store(false)
+	}
+	t.Infof("task pinned: %t", pinned)
+	return t.writeMetadata()
+}
+
 // MarkReclaim will try to invoke gcCallback (normal leave peer task)
 func (t *localTaskStore) MarkReclaim() {
 	if t.reclaimMarked.Load() {
@@ -474,6 +621,10 @@ func (t *localTaskStore) reclaimMeta() error {
 func (t *localTaskStore) saveMetadata() error {
 	t.Lock()
 	defer t.Unlock()
+	return t.writeMetadata()
+}
+
+func (t *localTaskStore) writeMetadata() error {
 	data, err := json.Marshal(t.persistentMetadata)
 	if err != nil {
 		return err
@@ -485,8 +636,10 @@ func (t *localTaskStore) saveMetadata() error {
 	_, err = t.metadataFile.Write(data)
 	if err != nil {
 		t.Errorf("save metadata error: %s", err)
+		return err
 	}
-	return err
+	// metadata may be shorter than the previous one
+	return t.metadataFile.Truncate(int64(len(data)))
 }
 
 // limitedReadFile implements io optimize for zero copy
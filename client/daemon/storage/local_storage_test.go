@@ -26,6 +26,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -37,6 +38,7 @@ import (
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	_ "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/server"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 func TestMain(m *testing.M) {
@@ -215,6 +217,182 @@ func TestLocalTaskStore_ReloadPersistentTask_Simple(t *testing.T) {
 
 }
 
+func TestLocalTaskStore_ResumeTask_Simple(t *testing.T) {
+	assert := testifyassert.New(t)
+	testBytes, err := os.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	dataDir, err := os.MkdirTemp("", "dragonfly-resume-test-")
+	assert.Nil(err, "create data dir")
+	defer os.RemoveAll(dataDir)
+
+	var (
+		taskID    = "task-d4bb1c273a9889fea14abd4651994fe8"
+		peerID    = "peer-d4bb1c273a9889fea14abd4651994fe8"
+		newPeerID = "peer-1f5a7a8d8f7ca2df6a1b4e0e6a9a1d2c"
+		pieceSize = 512
+		opt       = &config.StorageOption{
+			DataPath: dataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+		}
+	)
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy, opt, func(request CommonTaskRequest) {})
+	assert.Nil(err, "create storage manager")
+
+	ts, err := sm.RegisterTask(context.Background(),
+		RegisterTaskRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			ContentLength: int64(len(testBytes)),
+		})
+	assert.Nil(err, "register task")
+
+	// write first 3 pieces, the last one will flush all pieces to metadata
+	for i := 0; i < 3; i++ {
+		if i == 2 {
+			ts.(*localTaskStore).lastPersist.Store(0)
+		}
+		_, err = ts.WritePiece(context.Background(), &WritePieceRequest{
+			PieceMetadata: PieceMetadata{
+				Num:    int32(i),
+				Offset: uint64(i * pieceSize),
+				Range: clientutil.Range{
+					Start:  int64(i * pieceSize),
+					Length: int64(pieceSize),
+				},
+			},
+			Reader: bytes.NewBuffer(testBytes[i*pieceSize : (i+1)*pieceSize]),
+		})
+		assert.Nil(err, "put piece")
+	}
+	// simulate dfdaemon restart
+	assert.Nil(ts.(*localTaskStore).metadataFile.Close())
+
+	sm, err = NewStorageManager(config.SimpleLocalTaskStoreStrategy, opt, func(request CommonTaskRequest) {})
+	assert.Nil(err, "reload storage manager")
+	assert.Nil(sm.FindCompletedTask(taskID), "unfinished task should not be reused")
+
+	resumed := sm.ResumeTask(taskID, newPeerID)
+	if !assert.NotNil(resumed, "unfinished task should be resumed") {
+		return
+	}
+	assert.Equal(newPeerID, resumed.PeerID)
+	assert.Equal(int64(len(testBytes)), resumed.ContentLength)
+	assert.Len(resumed.Pieces, 3)
+	for i, piece := range resumed.Pieces {
+		assert.Equal(int32(i), piece.Num)
+	}
+	assert.Nil(sm.ResumeTask(taskID, newPeerID), "task can be resumed only once")
+
+	s := sm.(*storageManager)
+	_, ok := s.LoadTask(PeerTaskMetadata{PeerID: peerID, TaskID: taskID})
+	assert.False(ok, "previous peer should be removed")
+	ts, ok = s.LoadTask(PeerTaskMetadata{PeerID: newPeerID, TaskID: taskID})
+	assert.True(ok, "task should be stored with new peer")
+	assert.Equal(path.Join(dataDir, taskID, newPeerID, taskData), ts.(*localTaskStore).DataFilePath)
+
+	rd, cl, err := ts.ReadPiece(context.Background(), &ReadPieceRequest{
+		PieceMetadata: PieceMetadata{
+			Num: 1,
+		},
+	})
+	assert.Nil(err, "read resumed piece")
+	data, err := io.ReadAll(rd)
+	cl.Close()
+	assert.Nil(err, "read resumed piece")
+	assert.Equal(testBytes[pieceSize:2*pieceSize], data, "piece data should match")
+
+	sm.CleanUp()
+}
+
+func TestLocalTaskStore_PinTask(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	dataDir, err := os.MkdirTemp("", "dragonfly-pin-test-")
+	assert.Nil(err, "create data dir")
+	defer os.RemoveAll(dataDir)
+
+	exp, err := config.NewRegexp("^http://example.com/pinned/")
+	assert.Nil(err)
+	opt := &config.StorageOption{
+		DataPath: dataDir,
+		TaskExpireTime: clientutil.Duration{
+			Duration: time.Millisecond,
+		},
+		PinRules: []*config.PinRule{
+			{Tag: "critical"},
+			{Regx: exp},
+		},
+	}
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy, opt, func(request CommonTaskRequest) {})
+	assert.Nil(err, "create storage manager")
+
+	register := func(taskID, url, tag string) *localTaskStore {
+		ts, err := sm.RegisterTask(context.Background(),
+			RegisterTaskRequest{
+				CommonTaskRequest: CommonTaskRequest{
+					PeerID: "peer-" + taskID,
+					TaskID: taskID,
+				},
+				ContentLength: 1024,
+				URL:           url,
+				Tag:           tag,
+			})
+		assert.Nil(err, "register task")
+		ts.(*localTaskStore).lastAccess.Store(0)
+		return ts.(*localTaskStore)
+	}
+	tagPinned := register("task-tag", "http://example.com/a", "critical")
+	// the digest of task id headers is appended to tag by proxy
+	headerTagPinned := register("task-header-tag", "http://example.com/a", config.TagWithHeaderDigest("critical", digestutils.Sha256("X-Tenant=a")))
+	urlPinned := register("task-url", "http://example.com/pinned/b", "")
+	manualPinned := register("task-manual", "http://example.com/c", "")
+	unpinned := register("task-unpinned", "http://example.com/d", "")
+
+	assert.True(tagPinned.isPinned(), "task should be pinned by tag rule")
+	assert.True(headerTagPinned.isPinned(), "task should be pinned by tag rule without the digest of headers")
+	assert.True(urlPinned.isPinned(), "task should be pinned by url rule")
+	assert.False(manualPinned.isPinned())
+	assert.Nil(sm.PinTask("task-manual", true))
+	assert.True(manualPinned.isPinned(), "task should be pinned manually")
+	assert.Equal(ErrTaskNotFound, sm.PinTask("task-not-exist", true))
+
+	_, err = sm.(*storageManager).TryGC()
+	assert.Nil(err)
+	assert.False(tagPinned.reclaimMarked.Load(), "pinned task should not be reclaimed")
+	assert.False(urlPinned.reclaimMarked.Load(), "pinned task should not be reclaimed")
+	assert.False(manualPinned.reclaimMarked.Load(), "pinned task should not be reclaimed")
+	assert.True(unpinned.reclaimMarked.Load(), "unpinned task should be reclaimed")
+
+	// the task pinned after marked is kept by the next gc
+	assert.Nil(sm.PinTask("task-unpinned", true))
+	assert.False(unpinned.reclaimMarked.Load(), "pinned task should not be marked")
+	_, err = sm.(*storageManager).TryGC()
+	assert.Nil(err)
+	_, ok := sm.(*storageManager).LoadTask(PeerTaskMetadata{PeerID: "peer-task-unpinned", TaskID: "task-unpinned"})
+	assert.True(ok, "task pinned after marked should not be reclaimed")
+	assert.FileExists(unpinned.DataFilePath)
+
+	// pinned flag should survive restart
+	assert.Nil(manualPinned.metadataFile.Close())
+	sm, err = NewStorageManager(config.SimpleLocalTaskStoreStrategy, opt, func(request CommonTaskRequest) {})
+	assert.Nil(err, "reload storage manager")
+	ts, ok := sm.(*storageManager).LoadTask(PeerTaskMetadata{PeerID: "peer-task-manual", TaskID: "task-manual"})
+	if assert.True(ok, "pinned task should be reloaded") {
+		assert.True(ts.(*localTaskStore).isPinned())
+		assert.Equal("http://example.com/c", ts.(*localTaskStore).URL, "url should survive restart")
+	}
+
+	assert.Nil(sm.PinTask("task-manual", false))
+	assert.False(ts.(*localTaskStore).isPinned(), "task should be unpinned")
+
+	sm.CleanUp()
+}
+
 func TestLocalTaskStore_PutAndGetPiece_Advance(t *testing.T) {
 	assert := testifyassert.New(t)
 	testBytes, err := os.ReadFile(test.File)
@@ -366,3 +544,101 @@ func calcFileMd5(filePath string) (string, error) {
 	md5String = hex.EncodeToString(hashInBytes)
 	return md5String, nil
 }
+
+func TestLocalTaskStore_ValidateDigest_Sha256(t *testing.T) {
+	assert := testifyassert.New(t)
+	testBytes := bytes.Repeat([]byte("hello dragonfly"), 100)
+
+	var (
+		taskID    = "task-5d2cfd1cd4b9bfd2f6db4dc1a5a07e5d"
+		peerID    = "peer-5d2cfd1cd4b9bfd2f6db4dc1a5a07e5d"
+		pieceSize = 512
+	)
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: test.DataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+		}, func(request CommonTaskRequest) {
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := sm.(*storageManager).CreateTask(
+		RegisterTaskRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			ContentLength: int64(len(testBytes)),
+		})
+	assert.Nil(err, "create task storage")
+	defer ts.(Reclaimer).Reclaim()
+
+	totalPieces := int32((len(testBytes) + pieceSize - 1) / pieceSize)
+	for i := int32(0); i < totalPieces; i++ {
+		start := int(i) * pieceSize
+		end := start + pieceSize
+		if end > len(testBytes) {
+			end = len(testBytes)
+		}
+		reader, err := digestutils.NewDigestReaderWithAlgorithm(logger.With("test", "test"),
+			bytes.NewBuffer(testBytes[start:end]), digestutils.Sha256Hash, "", digestutils.Md5Hash)
+		assert.Nil(err)
+		num := i
+		_, err = ts.WritePiece(context.Background(), &WritePieceRequest{
+			PeerTaskMetadata: PeerTaskMetadata{
+				TaskID: taskID,
+			},
+			PieceMetadata: PieceMetadata{
+				Num:    num,
+				Offset: uint64(start),
+				Range: clientutil.Range{
+					Start:  int64(start),
+					Length: int64(end - start),
+				},
+			},
+			Reader: reader,
+			GenPieceDigest: func(n int64) (int32, bool) {
+				return totalPieces, num == totalPieces-1
+			},
+		})
+		assert.Nil(err, "put piece")
+	}
+
+	lts := ts.(*localTaskStore)
+	for i := int32(0); i < totalPieces; i++ {
+		assert.Equal(base.DigestAlgorithm_SHA256, lts.Pieces[i].DigestAlgorithm)
+		assert.Len(lts.Pieces[i].Digest, 64)
+		assert.Len(lts.Pieces[i].Md5, 32)
+	}
+	assert.NotEmpty(lts.PieceMd5Sign)
+	assert.NotEmpty(lts.PieceDigestSign)
+
+	packet, err := ts.GetPieces(context.Background(), &base.PieceTaskRequest{
+		TaskId: taskID,
+		Limit:  uint32(totalPieces),
+	})
+	assert.Nil(err)
+	assert.Equal(lts.PieceDigestSign, packet.PieceDigestSign)
+	assert.Equal(lts.Pieces[0].Digest, packet.PieceInfos[0].PieceDigest)
+
+	assert.Nil(ts.ValidateDigest(&PeerTaskMetadata{TaskID: taskID, PeerID: peerID}))
+
+	// piece digest sign is preferred, tampered piece digest should fail even if md5 matches
+	piece := lts.Pieces[0]
+	piece.Digest = strings.Repeat("0", 64)
+	lts.Pieces[0] = piece
+	assert.Equal(ErrInvalidDigest, ts.ValidateDigest(&PeerTaskMetadata{TaskID: taskID, PeerID: peerID}))
+
+	// pieces from old peers only have md5, validate with piece md5 sign
+	piece.Digest = ""
+	lts.Pieces[0] = piece
+	assert.Nil(ts.ValidateDigest(&PeerTaskMetadata{TaskID: taskID, PeerID: peerID}))
+
+	piece.Md5 = strings.Repeat("0", 32)
+	lts.Pieces[0] = piece
+	assert.Equal(ErrInvalidDigest, ts.ValidateDigest(&PeerTaskMetadata{TaskID: taskID, PeerID: peerID}))
+}
@@ -18,22 +18,30 @@ package storage
 
 import (
 	"io"
+	"time"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 )
 
 type persistentMetadata struct {
-	StoreStrategy string                  `json:"storeStrategy"`
-	TaskID        string                  `json:"taskID"`
-	TaskMeta      map[string]string       `json:"taskMeta"`
-	ContentLength int64                   `json:"contentLength"`
-	TotalPieces   int32                   `json:"totalPieces"`
-	PeerID        string                  `json:"peerID"`
-	Pieces        map[int32]PieceMetadata `json:"pieces"`
-	PieceMd5Sign  string                  `json:"pieceMd5Sign"`
-	DataFilePath  string                  `json:"dataFilePath"`
-	Done          bool                    `json:"done"`
+	StoreStrategy   string                  `json:"storeStrategy"`
+	TaskID          string                  `json:"taskID"`
+	TaskMeta        map[string]string       `json:"taskMeta"`
+	ContentLength   int64                   `json:"contentLength"`
+	TotalPieces     int32                   `json:"totalPieces"`
+	PeerID          string                  `json:"peerID"`
+	Pieces          map[int32]PieceMetadata `json:"pieces"`
+	PieceMd5Sign    string                  `json:"pieceMd5Sign"`
+	PieceDigestSign string                  `json:"pieceDigestSign,omitempty"`
+	DataFilePath    string                  `json:"dataFilePath"`
+	Done            bool                    `json:"done"`
+	Pinned          bool                    `json:"pinned,omitempty"`
+	// Hits is the reused count of the task, it's used by eviction policies
+	Hits int64 `json:"hits,omitempty"`
+	// URL and URLMeta are announced to scheduler, so that the task can be created by the cached one
+	URL     string        `json:"url,omitempty"`
+	URLMeta *base.UrlMeta `json:"urlMeta,omitempty"`
 }
 
 type PeerTaskMetadata struct {
@@ -42,11 +50,13 @@ type PeerTaskMetadata struct {
 }
 
 type PieceMetadata struct {
-	Num    int32            `json:"num,omitempty"`
-	Md5    string           `json:"md5,omitempty"`
-	Offset uint64           `json:"offset,omitempty"`
-	Range  clientutil.Range `json:"range,omitempty"`
-	Style  base.PieceStyle  `json:"style,omitempty"`
+	Num             int32                `json:"num,omitempty"`
+	Md5             string               `json:"md5,omitempty"`
+	Offset          uint64               `json:"offset,omitempty"`
+	Range           clientutil.Range     `json:"range,omitempty"`
+	Style           base.PieceStyle      `json:"style,omitempty"`
+	DigestAlgorithm base.DigestAlgorithm `json:"digestAlgorithm,omitempty"`
+	Digest          string               `json:"digest,omitempty"`
 }
 
 type CommonTaskRequest struct {
@@ -57,9 +67,14 @@ type CommonTaskRequest struct {
 
 type RegisterTaskRequest struct {
 	CommonTaskRequest
-	ContentLength int64
-	TotalPieces   int32
-	PieceMd5Sign  string
+	ContentLength   int64
+	TotalPieces     int32
+	PieceMd5Sign    string
+	PieceDigestSign string
+	// URL and Tag are used to match the pin rules
+	URL     string
+	Tag     string
+	URLMeta *base.UrlMeta
 }
 
 type WritePieceRequest struct {
@@ -89,9 +104,32 @@ type ReadAllPiecesRequest struct {
 
 type UpdateTaskRequest struct {
 	PeerTaskMetadata
-	ContentLength int64
-	TotalPieces   int32
-	PieceMd5Sign  string
+	ContentLength   int64
+	TotalPieces     int32
+	PieceMd5Sign    string
+	PieceDigestSign string
 }
 
 type ReusePeerTask = UpdateTaskRequest
+
+// CachedTask stands a task stored in local storage
+type CachedTask struct {
+	PeerTaskMetadata
+	ContentLength int64         `json:"contentLength"`
+	TotalPieces   int32         `json:"totalPieces"`
+	Done          bool          `json:"done"`
+	Pinned        bool          `json:"pinned"`
+	LastAccess    time.Time     `json:"lastAccess"`
+	URL           string        `json:"url,omitempty"`
+	URLMeta       *base.UrlMeta `json:"urlMeta,omitempty"`
+}
+
+// ResumePeerTask stands an unfinished task whose pieces are already written to disk
+type ResumePeerTask struct {
+	PeerTaskMetadata
+	ContentLength   int64
+	TotalPieces     int32
+	PieceMd5Sign    string
+	PieceDigestSign string
+	Pieces          []PieceMetadata
+}
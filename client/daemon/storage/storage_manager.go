@@ -23,7 +23,6 @@ import (
 	"io"
 	"os"
 	"path"
-	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -32,13 +31,14 @@ import (
 
 	"github.com/docker/go-units"
 	"github.com/pkg/errors"
-	"github.com/shirou/gopsutil/v3/disk"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/atomic"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/gc"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 )
@@ -87,6 +87,12 @@ type Manager interface {
 	RegisterTask(ctx context.Context, req RegisterTaskRequest) (TaskStorageDriver, error)
 	// FindCompletedTask try to find a completed task for fast path
 	FindCompletedTask(taskID string) *ReusePeerTask
+	// ResumeTask try to take over an unfinished task with the new peer id, the written pieces can be reused
+	ResumeTask(taskID, peerID string) *ResumePeerTask
+	// ListTasks lists all tasks in storage, sorted by last access time, the latest first
+	ListTasks() []*CachedTask
+	// PinTask pins or unpins all the local tasks with the task id, pinned tasks are skipped by gc
+	PinTask(taskID string, pinned bool) error
 	// CleanUp cleans all storage data
 	CleanUp()
 }
@@ -97,6 +103,7 @@ var (
 	ErrPieceCountNotSet = errors.New("total piece count not set")
 	ErrDigestNotSet     = errors.New("digest not set")
 	ErrInvalidDigest    = errors.New("invalid digest")
+	ErrNoAvailableDisk  = errors.New("no available data path")
 )
 
 const (
@@ -116,11 +123,13 @@ type storageManager struct {
 	storeOption        *config.StorageOption
 	tasks              sync.Map
 	markedReclaimTasks []PeerTaskMetadata
-	dataPathStat       *syscall.Stat_t
+	disks              []*dataDisk
+	placementCount     atomic.Uint64
 	gcCallback         func(CommonTaskRequest)
 	gcInterval         time.Duration
 	indexRWMutex       sync.RWMutex
 	indexTask2PeerTask map[string][]*localTaskStore // key: task id, value: slice of localTaskStore
+	evictionPolicy     EvictionPolicy
 }
 
 var _ gc.GC = (*storageManager)(nil)
@@ -129,23 +138,6 @@ var _ Manager = (*storageManager)(nil)
 type GCCallback func(request CommonTaskRequest)
 
 func NewStorageManager(storeStrategy config.StoreStrategy, opt *config.StorageOption, gcCallback GCCallback, moreOpts ...func(*storageManager) error) (Manager, error) {
-	if !path.IsAbs(opt.DataPath) {
-		abs, err := filepath.Abs(opt.DataPath)
-		if err != nil {
-			return nil, err
-		}
-		opt.DataPath = abs
-	}
-	stat, err := os.Stat(opt.DataPath)
-	if os.IsNotExist(err) {
-		if err := os.MkdirAll(opt.DataPath, defaultDirectoryMode); err != nil {
-			return nil, err
-		}
-		stat, err = os.Stat(opt.DataPath)
-	}
-	if err != nil {
-		return nil, err
-	}
 	switch storeStrategy {
 	case config.SimpleLocalTaskStoreStrategy, config.AdvanceLocalTaskStoreStrategy:
 	case config.StoreStrategy(""):
@@ -158,7 +150,6 @@ func NewStorageManager(storeStrategy config.StoreStrategy, opt *config.StorageOp
 		KeepAlive:          clientutil.NewKeepAlive("storage manager"),
 		storeStrategy:      storeStrategy,
 		storeOption:        opt,
-		dataPathStat:       stat.Sys().(*syscall.Stat_t),
 		gcCallback:         gcCallback,
 		gcInterval:         time.Minute,
 		indexTask2PeerTask: map[string][]*localTaskStore{},
@@ -170,6 +161,15 @@ func NewStorageManager(storeStrategy config.StoreStrategy, opt *config.StorageOp
 		}
 	}
 
+	var err error
+	if s.disks, err = initDisks(s.storeOption); err != nil {
+		return nil, err
+	}
+
+	if s.evictionPolicy, err = newEvictionPolicy(s.storeOption.EvictionPolicy); err != nil {
+		return nil, err
+	}
+
 	if err := s.ReloadPersistentTask(gcCallback); err != nil {
 		logger.Warnf("reload tasks error: %s", err)
 	}
@@ -276,6 +276,10 @@ func (s *storageManager) GetPieces(ctx context.Context, req *base.PieceTaskReque
 	if !ok {
 		return nil, ErrTaskNotFound
 	}
+	// the pieces in unhealthy data path can not be read, let the children find other parents
+	if !t.(*localTaskStore).diskHealthy() {
+		return nil, ErrTaskNotFound
+	}
 	return t.(TaskStorageDriver).GetPieces(ctx, req)
 }
 
@@ -302,21 +306,43 @@ func (s *storageManager) UpdateTask(ctx context.Context, req *UpdateTaskRequest)
 
 func (s *storageManager) CreateTask(req RegisterTaskRequest) (TaskStorageDriver, error) {
 	s.Keep()
-	logger.Debugf("init local task storage, peer id: %s, task id: %s", req.PeerID, req.TaskID)
+	for _, d := range s.placeDisks(req.ContentLength) {
+		t, err := s.createTask(req, d)
+		if err == nil {
+			return t, nil
+		}
+		var de *diskError
+		if !errors.As(err, &de) {
+			return nil, err
+		}
+		// try next data path
+		d.markUnhealthy(de.err)
+		_ = os.RemoveAll(path.Join(d.Path, req.TaskID, req.PeerID))
+	}
+	return nil, ErrNoAvailableDisk
+}
+
+func (s *storageManager) createTask(req RegisterTaskRequest, disk *dataDisk) (*localTaskStore, error) {
+	logger.Debugf("init local task storage in %s, peer id: %s, task id: %s", disk.Path, req.PeerID, req.TaskID)
 
-	dataDir := path.Join(s.storeOption.DataPath, req.TaskID, req.PeerID)
+	dataDir := path.Join(disk.Path, req.TaskID, req.PeerID)
 	t := &localTaskStore{
 		persistentMetadata: persistentMetadata{
-			StoreStrategy: string(s.storeStrategy),
-			TaskID:        req.TaskID,
-			TaskMeta:      map[string]string{},
-			ContentLength: req.ContentLength,
-			TotalPieces:   req.TotalPieces,
-			PieceMd5Sign:  req.PieceMd5Sign,
-			PeerID:        req.PeerID,
-			Pieces:        map[int32]PieceMetadata{},
+			StoreStrategy:   string(s.storeStrategy),
+			TaskID:          req.TaskID,
+			TaskMeta:        map[string]string{},
+			ContentLength:   req.ContentLength,
+			TotalPieces:     req.TotalPieces,
+			PieceMd5Sign:    req.PieceMd5Sign,
+			PieceDigestSign: req.PieceDigestSign,
+			PeerID:          req.PeerID,
+			Pieces:          map[int32]PieceMetadata{},
+			Pinned:          s.matchPinRules(req.URL, req.Tag),
+			URL:             req.URL,
+			URLMeta:         req.URLMeta,
 		},
 		gcCallback:       s.gcCallback,
+		disk:             disk,
 		dataDir:          dataDir,
 		metadataFilePath: path.Join(dataDir, taskMetadata),
 		expireTime:       s.storeOption.TaskExpireTime.Duration,
@@ -324,12 +350,12 @@ func (s *storageManager) CreateTask(req RegisterTaskRequest) (TaskStorageDriver,
 		SugaredLoggerOnWith: logger.With("task", req.TaskID, "peer", req.PeerID, "component", "localTaskStore"),
 	}
 	if err := os.MkdirAll(t.dataDir, defaultDirectoryMode); err != nil && !os.IsExist(err) {
-		return nil, err
+		return nil, &diskError{disk: disk, err: err}
 	}
 	t.touch()
 	metadata, err := os.OpenFile(t.metadataFilePath, os.O_CREATE|os.O_RDWR, defaultFileMode)
 	if err != nil {
-		return nil, err
+		return nil, &diskError{disk: disk, err: err}
 	}
 	t.metadataFile = metadata
 
@@ -343,7 +369,8 @@ func (s *storageManager) CreateTask(req RegisterTaskRequest) (TaskStorageDriver,
 		t.DataFilePath = data
 		f, err := os.OpenFile(t.DataFilePath, os.O_CREATE|os.O_RDWR, defaultFileMode)
 		if err != nil {
-			return nil, err
+			metadata.Close()
+			return nil, &diskError{disk: disk, err: err}
 		}
 		f.Close()
 	case string(config.AdvanceLocalTaskStoreStrategy):
@@ -362,7 +389,7 @@ func (s *storageManager) CreateTask(req RegisterTaskRequest) (TaskStorageDriver,
 
 		stat := dirStat.Sys().(*syscall.Stat_t)
 		// same dev, can hard link
-		if stat.Dev == s.dataPathStat.Dev {
+		if stat.Dev == disk.stat.Dev {
 			logger.Debugf("same device, try to hard link")
 			if err := os.Link(t.DataFilePath, data); err != nil {
 				logger.Warnf("hard link failed for same device: %s, fallback to symbol link", err)
@@ -395,6 +422,7 @@ func (s *storageManager) CreateTask(req RegisterTaskRequest) (TaskStorageDriver,
 		s.indexTask2PeerTask[req.TaskID] = []*localTaskStore{t}
 	}
 	s.indexRWMutex.Unlock()
+	s.evictionPolicy.Access(t.evictionCandidate())
 	return t, nil
 }
 
@@ -406,7 +434,7 @@ func (s *storageManager) FindCompletedTask(taskID string) *ReusePeerTask {
 		return nil
 	}
 	for _, t := range ts {
-		if t.invalid.Load() {
+		if t.invalid.Load() || !t.diskHealthy() {
 			continue
 		}
 		// touch it before marking reclaim
@@ -419,6 +447,8 @@ func (s *storageManager) FindCompletedTask(taskID string) *ReusePeerTask {
 		if !t.Done {
 			continue
 		}
+		t.hit()
+		s.evictionPolicy.Access(t.evictionCandidate())
 		return &ReusePeerTask{
 			PeerTaskMetadata: PeerTaskMetadata{
 				PeerID: t.PeerID,
@@ -431,6 +461,122 @@ func (s *storageManager) FindCompletedTask(taskID string) *ReusePeerTask {
 	return nil
 }
 
+func (s *storageManager) ResumeTask(taskID, peerID string) *ResumePeerTask {
+	s.indexRWMutex.Lock()
+	defer s.indexRWMutex.Unlock()
+	ts, ok := s.indexTask2PeerTask[taskID]
+	if !ok {
+		return nil
+	}
+	for _, t := range ts {
+		if t.invalid.Load() || t.reclaimMarked.Load() || t.Done || !t.diskHealthy() {
+			continue
+		}
+		if t.PeerID == peerID {
+			continue
+		}
+
+		t.RLock()
+		pieces := make([]PieceMetadata, 0, len(t.Pieces))
+		for _, piece := range t.Pieces {
+			pieces = append(pieces, piece)
+		}
+		t.RUnlock()
+		if len(pieces) == 0 {
+			continue
+		}
+		if _, err := os.Stat(t.DataFilePath); err != nil {
+			logger.Warnf("stat unfinished task data %s error: %s", t.DataFilePath, err)
+			continue
+		}
+
+		prev := t.evictionCandidate()
+		if err := t.rebind(peerID); err != nil {
+			logger.Warnf("rebind unfinished task %s/%s to peer %s error: %s", taskID, prev.PeerID, peerID, err)
+			continue
+		}
+		t.touch()
+		s.evictionPolicy.Remove(prev)
+		s.evictionPolicy.Access(t.evictionCandidate())
+		s.tasks.Delete(prev.PeerTaskMetadata)
+		s.tasks.Store(
+			PeerTaskMetadata{
+				PeerID: peerID,
+				TaskID: taskID,
+			}, t)
+		logger.Infof("resume unfinished task %s/%s with peer %s, %d pieces reused", taskID, prev.PeerID, peerID, len(pieces))
+
+		sort.Slice(pieces, func(i, j int) bool {
+			return pieces[i].Num < pieces[j].Num
+		})
+		t.RLock()
+		defer t.RUnlock()
+		return &ResumePeerTask{
+			PeerTaskMetadata: PeerTaskMetadata{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			ContentLength:   t.ContentLength,
+			TotalPieces:     t.TotalPieces,
+			PieceMd5Sign:    t.PieceMd5Sign,
+			PieceDigestSign: t.PieceDigestSign,
+			Pieces:          pieces,
+		}
+	}
+	return nil
+}
+
+func (s *storageManager) ListTasks() []*CachedTask {
+	var tasks []*CachedTask
+	s.tasks.Range(func(key, val interface{}) bool {
+		t := val.(*localTaskStore)
+		if t.invalid.Load() || t.reclaimMarked.Load() || !t.diskHealthy() {
+			return true
+		}
+		t.RLock()
+		tasks = append(tasks, &CachedTask{
+			PeerTaskMetadata: key.(PeerTaskMetadata),
+			ContentLength:    t.ContentLength,
+			TotalPieces:      t.TotalPieces,
+			Done:             t.Done,
+			Pinned:           t.Pinned,
+			LastAccess:       time.Unix(0, t.lastAccess.Load()),
+			URL:              t.URL,
+			URLMeta:          t.URLMeta,
+		})
+		t.RUnlock()
+		return true
+	})
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].LastAccess.After(tasks[j].LastAccess)
+	})
+	return tasks
+}
+
+func (s *storageManager) PinTask(taskID string, pinned bool) error {
+	s.indexRWMutex.RLock()
+	ts := s.indexTask2PeerTask[taskID]
+	s.indexRWMutex.RUnlock()
+	if len(ts) == 0 {
+		return ErrTaskNotFound
+	}
+	for _, t := range ts {
+		if err := t.pin(pinned); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *storageManager) matchPinRules(url, tag string) bool {
+	for _, rule := range s.storeOption.PinRules {
+		if rule.Match(url, tag) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *storageManager) cleanIndex(taskID, peerID string) {
 	s.indexRWMutex.Lock()
 	defer s.indexRWMutex.Unlock()
@@ -475,20 +621,80 @@ func (s *storageManager) IsInvalid(req *PeerTaskMetadata) (bool, error) {
 }
 
 func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
-	dirs, err := os.ReadDir(s.storeOption.DataPath)
-	if os.IsNotExist(err) {
-		return nil
-	}
-	if err != nil {
-		return err
-	}
 	var (
 		loadErrs    []error
 		loadErrDirs []string
 	)
+	for _, disk := range s.disks {
+		// the unhealthy data path is reloaded when it recovers
+		if !disk.healthy.Load() {
+			continue
+		}
+		errs, errDirs := s.reloadDisk(disk, gcCallback)
+		disk.loaded.Store(true)
+		loadErrs = append(loadErrs, errs...)
+		loadErrDirs = append(loadErrDirs, errDirs...)
+	}
+	removeLoadErrDirs(loadErrDirs)
+	if len(loadErrs) > 0 {
+		var sb strings.Builder
+		for _, err := range loadErrs {
+			sb.WriteString(err.Error())
+		}
+		return fmt.Errorf("load tasks from disk error: %q", sb.String())
+	}
+	return nil
+}
+
+// removeLoadErrDirs removes the peer task directories failed to load
+func removeLoadErrDirs(loadErrDirs []string) {
+	for _, dir := range loadErrDirs {
+		// remove metadata
+		if err := os.Remove(path.Join(dir, taskMetadata)); err != nil {
+			logger.Warnf("remove load error file %s error: %s", path.Join(dir, taskMetadata), err)
+		} else {
+			logger.Warnf("remove load error file %s ok", path.Join(dir, taskMetadata))
+		}
+
+		// remove data
+		data := path.Join(dir, taskData)
+		stat, err := os.Lstat(data)
+		if err == nil {
+			// remove sym link file
+			if stat.Mode()&os.ModeSymlink == os.ModeSymlink {
+				dest, err0 := os.Readlink(data)
+				if err0 == nil {
+					if err = os.Remove(dest); err != nil {
+						logger.Warnf("remove load error file %s error: %s", data, err)
+					}
+				}
+			}
+			if err = os.Remove(data); err != nil {
+				logger.Warnf("remove load error file %s error: %s", data, err)
+			} else {
+				logger.Warnf("remove load error file %s ok", data)
+			}
+		}
+
+		if err = os.Remove(dir); err != nil {
+			logger.Warnf("remove load error directory %s error: %s", dir, err)
+		}
+		logger.Warnf("remove load error directory %s ok", dir)
+	}
+}
+
+// reloadDisk loads the tasks in the data path, returns the errors and the directories failed to load
+func (s *storageManager) reloadDisk(dataDisk *dataDisk, gcCallback GCCallback) (loadErrs []error, loadErrDirs []string) {
+	dirs, err := os.ReadDir(dataDisk.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return []error{err}, nil
+	}
 	for _, dir := range dirs {
 		taskID := dir.Name()
-		taskDir := path.Join(s.storeOption.DataPath, taskID)
+		taskDir := path.Join(dataDisk.Path, taskID)
 		peerDirs, err := os.ReadDir(taskDir)
 		if err != nil {
 			continue
@@ -508,8 +714,9 @@ func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
 		}
 		for _, peerDir := range peerDirs {
 			peerID := peerDir.Name()
-			dataDir := path.Join(s.storeOption.DataPath, taskID, peerID)
+			dataDir := path.Join(dataDisk.Path, taskID, peerID)
 			t := &localTaskStore{
+				disk:                dataDisk,
 				dataDir:             dataDir,
 				metadataFilePath:    path.Join(dataDir, taskMetadata),
 				expireTime:          s.storeOption.TaskExpireTime.Duration,
@@ -518,7 +725,8 @@ func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
 			}
 			t.touch()
 
-			if t.metadataFile, err = os.Open(t.metadataFilePath); err != nil {
+			// open with write mode, metadata of unfinished task will be updated when resumed
+			if t.metadataFile, err = os.OpenFile(t.metadataFilePath, os.O_RDWR, defaultFileMode); err != nil {
 				loadErrs = append(loadErrs, err)
 				loadErrDirs = append(loadErrDirs, dataDir)
 				logger.With("action", "reload", "stage", "read metadata", "taskID", taskID, "peerID", peerID).
@@ -555,113 +763,38 @@ func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
 			} else {
 				s.indexTask2PeerTask[taskID] = []*localTaskStore{t}
 			}
+			s.evictionPolicy.Access(t.evictionCandidate())
 		}
 	}
-	// remove load error peer tasks
-	for _, dir := range loadErrDirs {
-		// remove metadata
-		if err = os.Remove(path.Join(dir, taskMetadata)); err != nil {
-			logger.Warnf("remove load error file %s error: %s", path.Join(dir, taskMetadata), err)
-		} else {
-			logger.Warnf("remove load error file %s ok", path.Join(dir, taskMetadata))
-		}
-
-		// remove data
-		data := path.Join(dir, taskData)
-		stat, err := os.Lstat(data)
-		if err == nil {
-			// remove sym link file
-			if stat.Mode()&os.ModeSymlink == os.ModeSymlink {
-				dest, err0 := os.Readlink(data)
-				if err0 == nil {
-					if err = os.Remove(dest); err != nil {
-						logger.Warnf("remove load error file %s error: %s", data, err)
-					}
-				}
-			}
-			if err = os.Remove(data); err != nil {
-				logger.Warnf("remove load error file %s error: %s", data, err)
-			} else {
-				logger.Warnf("remove load error file %s ok", data)
-			}
-		}
-
-		if err = os.Remove(dir); err != nil {
-			logger.Warnf("remove load error directory %s error: %s", dir, err)
-		}
-		logger.Warnf("remove load error directory %s ok", dir)
-	}
-	if len(loadErrs) > 0 {
-		var sb strings.Builder
-		for _, err := range loadErrs {
-			sb.WriteString(err.Error())
-		}
-		return fmt.Errorf("load tasks from disk error: %q", sb.String())
-	}
-	return nil
+	return loadErrs, loadErrDirs
 }
 
 func (s *storageManager) TryGC() (bool, error) {
+	// put the recovered data paths back into rotation, or take the failing ones out
+	s.checkDisks()
+
 	var markedTasks []PeerTaskMetadata
-	var totalNotMarkedSize int64
+	var totalNotMarkedSize, pinnedSize int64
+	notMarkedSize := map[*dataDisk]int64{}
 	s.tasks.Range(func(key, task interface{}) bool {
+		if task.(*localTaskStore).isPinned() {
+			pinnedSize += task.(*localTaskStore).ContentLength
+		}
 		if task.(*localTaskStore).CanReclaim() {
 			task.(*localTaskStore).MarkReclaim()
 			markedTasks = append(markedTasks, key.(PeerTaskMetadata))
 		} else {
 			// just calculate not reclaimed task
 			totalNotMarkedSize += task.(*localTaskStore).ContentLength
+			notMarkedSize[task.(*localTaskStore).disk] += task.(*localTaskStore).ContentLength
 			logger.Debugf("task %s/%s not reach gc time",
 				key.(PeerTaskMetadata).TaskID, key.(PeerTaskMetadata).PeerID)
 		}
 		return true
 	})
 
-	quotaBytesExceed := totalNotMarkedSize - int64(s.storeOption.DiskGCThreshold)
-	quotaExceed := s.storeOption.DiskGCThreshold > 0 && quotaBytesExceed > 0
-	usageExceed, usageBytesExceed := s.diskUsageExceed()
-
-	if quotaExceed || usageExceed {
-		var bytesExceed int64
-		if quotaBytesExceed > usageBytesExceed {
-			bytesExceed = quotaBytesExceed
-		} else {
-			bytesExceed = usageBytesExceed
-		}
-		logger.Infof("quota threshold reached, start gc oldest task, size: %d bytes", bytesExceed)
-		var tasks []*localTaskStore
-		s.tasks.Range(func(key, val interface{}) bool {
-			// skip reclaimed task
-			task := val.(*localTaskStore)
-			if task.reclaimMarked.Load() {
-				return true
-			}
-			// task is not done, and is active in s.gcInterval
-			// next gc loop will check it again
-			if !task.Done && time.Now().Sub(time.Unix(0, task.lastAccess.Load())) < s.gcInterval {
-				return true
-			}
-			tasks = append(tasks, task)
-			return true
-		})
-		// sort by access time
-		sort.SliceStable(tasks, func(i, j int) bool {
-			return tasks[i].lastAccess.Load() < tasks[j].lastAccess.Load()
-		})
-		for _, task := range tasks {
-			task.MarkReclaim()
-			markedTasks = append(markedTasks, PeerTaskMetadata{task.PeerID, task.TaskID})
-			logger.Infof("quota threshold reached, mark task %s/%s reclaimed, last access: %s, size: %s",
-				task.TaskID, task.PeerID, time.Unix(0, task.lastAccess.Load()).Format(time.RFC3339Nano),
-				units.BytesSize(float64(task.ContentLength)))
-			bytesExceed -= task.ContentLength
-			if bytesExceed <= 0 {
-				break
-			}
-		}
-		if bytesExceed > 0 {
-			logger.Warnf("no enough tasks to gc, remind %d bytes", bytesExceed)
-		}
+	for _, disk := range s.disks {
+		markedTasks = append(markedTasks, s.tryGCDisk(disk, notMarkedSize[disk])...)
 	}
 
 	for _, key := range s.markedReclaimTasks {
@@ -670,6 +803,11 @@ func (s *storageManager) TryGC() (bool, error) {
 			continue
 		}
 		task := t.(*localTaskStore)
+		// the task is pinned after marked
+		if task.isPinned() {
+			logger.Infof("task %s/%s is pinned after marked, skip reclaiming", key.TaskID, key.PeerID)
+			continue
+		}
 		_, span := tracer.Start(context.Background(), config.SpanPeerGC)
 		span.SetAttributes(config.AttributePeerID.String(task.PeerID))
 		span.SetAttributes(config.AttributeTaskID.String(task.TaskID))
@@ -677,12 +815,15 @@ func (s *storageManager) TryGC() (bool, error) {
 		s.tasks.Delete(key)
 		s.cleanIndex(task.TaskID, task.PeerID)
 		if err := task.Reclaim(); err != nil {
+			// the task is removed from storage manager, forget it in eviction policy either
+			s.evictionPolicy.Remove(task.evictionCandidate())
 			// FIXME: retry later or push to queue
 			logger.Errorf("gc task %s/%s error: %s", key.TaskID, key.PeerID, err)
 			span.RecordError(err)
 			span.End()
 			continue
 		}
+		s.evictionPolicy.Evict(task.evictionCandidate())
 		logger.Infof("task %s/%s reclaimed", key.TaskID, key.PeerID)
 		// remove reclaimed task in markedTasks
 		for i, k := range markedTasks {
@@ -693,11 +834,77 @@ func (s *storageManager) TryGC() (bool, error) {
 		}
 		span.End()
 	}
+	metrics.PinnedTaskBytes.Set(float64(pinnedSize))
+	metrics.UnpinnedTaskBytes.Set(float64(totalNotMarkedSize - pinnedSize))
 	logger.Infof("marked %d task(s), reclaimed %d task(s)", len(markedTasks), len(s.markedReclaimTasks))
 	s.markedReclaimTasks = markedTasks
 	return true, nil
 }
 
+// tryGCDisk marks the tasks in the data path reclaimed when its thresholds are reached
+func (s *storageManager) tryGCDisk(disk *dataDisk, notMarkedSize int64) []PeerTaskMetadata {
+	quotaBytesExceed := notMarkedSize - int64(disk.DiskGCThreshold)
+	quotaExceed := disk.DiskGCThreshold > 0 && quotaBytesExceed > 0
+	usageExceed, usageBytesExceed := disk.usageExceed()
+	if !quotaExceed && !usageExceed {
+		return nil
+	}
+
+	var bytesExceed int64
+	if quotaBytesExceed > usageBytesExceed {
+		bytesExceed = quotaBytesExceed
+	} else {
+		bytesExceed = usageBytesExceed
+	}
+	logger.Infof("quota threshold of %s reached, start gc oldest task, size: %d bytes", disk.Path, bytesExceed)
+	var candidates []*EvictionCandidate
+	s.tasks.Range(func(key, val interface{}) bool {
+		task := val.(*localTaskStore)
+		// skip task in other data paths
+		if task.disk != disk {
+			return true
+		}
+		// skip reclaimed task
+		if task.reclaimMarked.Load() {
+			return true
+		}
+		// skip pinned task
+		if task.isPinned() {
+			return true
+		}
+		// task is not done, and is active in s.gcInterval
+		// next gc loop will check it again
+		if !task.Done && time.Now().Sub(time.Unix(0, task.lastAccess.Load())) < s.gcInterval {
+			return true
+		}
+		candidates = append(candidates, task.evictionCandidate())
+		return true
+	})
+	// sort by eviction policy
+	s.evictionPolicy.Sort(candidates)
+	var markedTasks []PeerTaskMetadata
+	for _, candidate := range candidates {
+		val, ok := s.tasks.Load(candidate.PeerTaskMetadata)
+		if !ok {
+			continue
+		}
+		task := val.(*localTaskStore)
+		task.MarkReclaim()
+		markedTasks = append(markedTasks, PeerTaskMetadata{task.PeerID, task.TaskID})
+		logger.Infof("quota threshold reached, mark task %s/%s reclaimed, last access: %s, size: %s",
+			task.TaskID, task.PeerID, time.Unix(0, task.lastAccess.Load()).Format(time.RFC3339Nano),
+			units.BytesSize(float64(task.ContentLength)))
+		bytesExceed -= task.ContentLength
+		if bytesExceed <= 0 {
+			break
+		}
+	}
+	if bytesExceed > 0 {
+		logger.Warnf("no enough tasks to gc in %s, remind %d bytes", disk.Path, bytesExceed)
+	}
+	return markedTasks
+}
+
 func (s *storageManager) CleanUp() {
 	_, _ = s.forceGC()
 }
@@ -707,6 +914,7 @@ func (s *storageManager) forceGC() (bool, error) {
 		meta := key.(PeerTaskMetadata)
 		s.tasks.Delete(meta)
 		s.cleanIndex(meta.TaskID, meta.PeerID)
+		s.evictionPolicy.Remove(task.(*localTaskStore).evictionCandidate())
 		task.(*localTaskStore).MarkReclaim()
 		err := task.(*localTaskStore).Reclaim()
 		if err != nil {
@@ -716,23 +924,3 @@ func (s *storageManager) forceGC() (bool, error) {
 	})
 	return true, nil
 }
-
-func (s *storageManager) diskUsageExceed() (exceed bool, bytes int64) {
-	if s.storeOption.DiskGCThresholdPercent <= 0 {
-		return false, 0
-	}
-	usage, err := disk.Usage(s.storeOption.DataPath)
-	if err != nil {
-		logger.Warnf("get %s disk usage error: %s", s.storeOption.DataPath, err)
-		return false, 0
-	}
-	logger.Debugf("disk usage: %#v", usage)
-	if usage.UsedPercent < s.storeOption.DiskGCThresholdPercent {
-		return false, 0
-	}
-
-	bs := (usage.UsedPercent - s.storeOption.DiskGCThresholdPercent) * float64(usage.Total)
-	logger.Infof("disk used percent %f, exceed threshold percent %f, %d bytes to reclaim",
-		usage.UsedPercent, s.storeOption.DiskGCThresholdPercent, int64(bs))
-	return true, int64(bs)
-}
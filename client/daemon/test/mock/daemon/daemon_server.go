@@ -64,6 +64,20 @@ func (mr *MockDaemonServerMockRecorder) Download(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockDaemonServer)(nil).Download), arg0, arg1, arg2)
 }
 
+// DownloadPiece mocks base method.
+func (m *MockDaemonServer) DownloadPiece(arg0 *dfdaemon.DownloadPieceRequest, arg1 dfdaemon.Daemon_DownloadPieceServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadPiece", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadPiece indicates an expected call of DownloadPiece.
+func (mr *MockDaemonServerMockRecorder) DownloadPiece(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadPiece", reflect.TypeOf((*MockDaemonServer)(nil).DownloadPiece), arg0, arg1)
+}
+
 // GetPieceTasks mocks base method.
 func (m *MockDaemonServer) GetPieceTasks(arg0 context.Context, arg1 *base.PieceTaskRequest) (*base.PiecePacket, error) {
 	m.ctrl.T.Helper()
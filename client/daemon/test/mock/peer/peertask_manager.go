@@ -52,6 +52,20 @@ func (mr *MockTaskManagerMockRecorder) IsPeerTaskRunning(id interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPeerTaskRunning", reflect.TypeOf((*MockTaskManager)(nil).IsPeerTaskRunning), id)
 }
 
+// ListRunningPeerTasks mocks base method.
+func (m *MockTaskManager) ListRunningPeerTasks() []*peer.PeerTaskStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRunningPeerTasks")
+	ret0, _ := ret[0].([]*peer.PeerTaskStatus)
+	return ret0
+}
+
+// ListRunningPeerTasks indicates an expected call of ListRunningPeerTasks.
+func (mr *MockTaskManagerMockRecorder) ListRunningPeerTasks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRunningPeerTasks", reflect.TypeOf((*MockTaskManager)(nil).ListRunningPeerTasks))
+}
+
 // StartFileTask mocks base method.
 func (m *MockTaskManager) StartFileTask(ctx context.Context, req *peer.FileTaskRequest) (chan *peer.FileTaskProgress, *peer.TinyData, error) {
 	m.ctrl.T.Helper()
@@ -68,6 +82,21 @@ func (mr *MockTaskManagerMockRecorder) StartFileTask(ctx, req interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartFileTask", reflect.TypeOf((*MockTaskManager)(nil).StartFileTask), ctx, req)
 }
 
+// StartSeedTask mocks base method.
+func (m *MockTaskManager) StartSeedTask(ctx context.Context, req *peer.SeedTaskRequest) (*peer.SeedTaskResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartSeedTask", ctx, req)
+	ret0, _ := ret[0].(*peer.SeedTaskResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartSeedTask indicates an expected call of StartSeedTask.
+func (mr *MockTaskManagerMockRecorder) StartSeedTask(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartSeedTask", reflect.TypeOf((*MockTaskManager)(nil).StartSeedTask), ctx, req)
+}
+
 // StartStreamTask mocks base method.
 func (m *MockTaskManager) StartStreamTask(ctx context.Context, req *peer.StreamTaskRequest) (io.ReadCloser, map[string]string, error) {
 	m.ctrl.T.Helper()
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: storage_manager.go
+// Source: storage/storage_manager.go
 
 // Package mock_storage is a generated GoMock package.
 package mock_storage
@@ -324,6 +324,34 @@ func (mr *MockManagerMockRecorder) Keep() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keep", reflect.TypeOf((*MockManager)(nil).Keep))
 }
 
+// ListTasks mocks base method.
+func (m *MockManager) ListTasks() []*storage.CachedTask {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasks")
+	ret0, _ := ret[0].([]*storage.CachedTask)
+	return ret0
+}
+
+// ListTasks indicates an expected call of ListTasks.
+func (mr *MockManagerMockRecorder) ListTasks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*MockManager)(nil).ListTasks))
+}
+
+// PinTask mocks base method.
+func (m *MockManager) PinTask(taskID string, pinned bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinTask", taskID, pinned)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PinTask indicates an expected call of PinTask.
+func (mr *MockManagerMockRecorder) PinTask(taskID, pinned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinTask", reflect.TypeOf((*MockManager)(nil).PinTask), taskID, pinned)
+}
+
 // ReadAllPieces mocks base method.
 func (m *MockManager) ReadAllPieces(ctx context.Context, req *storage.ReadAllPiecesRequest) (io.ReadCloser, error) {
 	m.ctrl.T.Helper()
@@ -370,6 +398,20 @@ func (mr *MockManagerMockRecorder) RegisterTask(ctx, req interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterTask", reflect.TypeOf((*MockManager)(nil).RegisterTask), ctx, req)
 }
 
+// ResumeTask mocks base method.
+func (m *MockManager) ResumeTask(taskID, peerID string) *storage.ResumePeerTask {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeTask", taskID, peerID)
+	ret0, _ := ret[0].(*storage.ResumePeerTask)
+	return ret0
+}
+
+// ResumeTask indicates an expected call of ResumeTask.
+func (mr *MockManagerMockRecorder) ResumeTask(taskID, peerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeTask", reflect.TypeOf((*MockManager)(nil).ResumeTask), taskID, peerID)
+}
+
 // Store mocks base method.
 func (m *MockManager) Store(ctx context.Context, req *storage.StoreRequest) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,313 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/golang/groupcache/lru"
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/sync/singleflight"
+
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+const (
+	// HeaderDockerContentDigest is the digest of manifest returned by registry
+	HeaderDockerContentDigest = "Docker-Content-Digest"
+
+	// maxManifestSize is the max size of manifest accepted by registry
+	maxManifestSize = 4 * 1024 * 1024
+
+	defaultManifestCacheTTL        = 30 * time.Second
+	defaultManifestCacheMaxEntries = 1024
+
+	// defaultManifestFetchTimeout bounds the upstream request shared by the collapsed requests
+	defaultManifestFetchTimeout = 30 * time.Second
+)
+
+// manifestReg matches the manifest requests of registry, the submatches are repository and reference
+var manifestReg = regexp.MustCompile("^/v2/(.+)/manifests/([^/]+)$")
+
+// ManifestCache caches the registry manifests in memory to collapse the manifest requests of many clients.
+// The tag-addressed manifests are cached with a short ttl, the digest-addressed manifests are immutable
+// and cached until they are evicted. The entries are scoped by the credential of requests, a cached manifest
+// is only served to the requests with the same credential, the anonymous requests share their own entries.
+// The HEAD requests are forwarded as HEAD, because registries count the GET requests of manifests as pulls,
+// their responses are cached as headers only and served to the HEAD requests.
+type ManifestCache struct {
+	ttl          time.Duration
+	fetchTimeout time.Duration
+
+	lock    sync.Mutex
+	entries *lru.Cache
+
+	// group collapses the concurrent identical requests to one upstream request
+	group singleflight.Group
+}
+
+// manifestEntry is the snapshot of a manifest response
+type manifestEntry struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	// headOnly is true when the entry is the response of HEAD request without body
+	headOnly      bool
+	contentLength int64
+	// expireAt is zero when the entry never expires
+	expireAt time.Time
+}
+
+// NewManifestCache creates a ManifestCache, the defaults are used when ttl or maxEntries is not positive
+func NewManifestCache(ttl time.Duration, maxEntries int) *ManifestCache {
+	if ttl <= 0 {
+		ttl = defaultManifestCacheTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = defaultManifestCacheMaxEntries
+	}
+	return &ManifestCache{
+		ttl:          ttl,
+		fetchTimeout: defaultManifestFetchTimeout,
+		entries:      lru.New(maxEntries),
+	}
+}
+
+// IsManifestRequest returns whether the request is a GET or HEAD request of registry manifest
+func IsManifestRequest(req *http.Request) bool {
+	return (req.Method == http.MethodGet || req.Method == http.MethodHead) && manifestReg.MatchString(req.URL.Path)
+}
+
+// RoundTrip serves the manifest request from cache, the missed manifest is fetched with next by the method
+// of request, the GET responses fill the manifests and the HEAD responses fill the headers only
+func (mc *ManifestCache) RoundTrip(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	matches := manifestReg.FindStringSubmatch(req.URL.Path)
+	if matches == nil {
+		return next.RoundTrip(req)
+	}
+	repo, reference := matches[1], matches[2]
+	dgst, isDigest := parseDigest(reference)
+	// the requests with different credentials may get different responses from registry
+	scope := credentialScope(req)
+
+	// the media type of tag-addressed manifests depends on the accepted types of client
+	key := fmt.Sprintf("%s|%s/%s:%s|%s", scope, req.URL.Host, repo, reference, req.Header.Get(headers.Accept))
+	if isDigest {
+		key = digestKey(scope, req.URL.Host, repo, dgst)
+	}
+
+	if entry, ok := mc.load(key); ok {
+		metrics.ProxyManifestCacheCount.WithLabelValues("hit").Add(1)
+		logger.Debugf("manifest cache hit: %s", key)
+		return entry.response(req), nil
+	}
+	if req.Method == http.MethodHead {
+		if entry, ok := mc.load(headKey(key)); ok {
+			metrics.ProxyManifestCacheCount.WithLabelValues("hit").Add(1)
+			logger.Debugf("manifest header cache hit: %s", key)
+			return entry.response(req), nil
+		}
+	}
+
+	metrics.ProxyManifestCacheCount.WithLabelValues("miss").Add(1)
+	// the shared fetch is not bound to the request of any caller, a canceled caller only stops waiting
+	ch := mc.group.DoChan(req.Method+" "+key, func() (interface{}, error) {
+		entry, err := mc.fetch(req, next)
+		if err != nil {
+			return nil, err
+		}
+		if entry.statusCode != http.StatusOK {
+			return entry, nil
+		}
+
+		// the digest returned by registry is preferred, the digest reference is used when it's absent
+		contentDigest, hasDigest := parseDigest(entry.header.Get(HeaderDockerContentDigest))
+		if entry.headOnly {
+			if isDigest && hasDigest && contentDigest != dgst {
+				logger.Warnf("manifest %s does not match the digest %s returned by registry", key, contentDigest)
+				return entry, nil
+			}
+			if isDigest {
+				mc.store(headKey(key), entry)
+			} else {
+				mc.store(headKey(key), entry.withExpireAt(time.Now().Add(mc.ttl)))
+			}
+			return entry, nil
+		}
+
+		if !hasDigest && isDigest {
+			contentDigest, hasDigest = dgst, true
+		}
+		if hasDigest && !verifyDigest(contentDigest, entry.body) {
+			logger.Warnf("manifest %s does not match the digest %s", key, contentDigest)
+			return entry, nil
+		}
+		if isDigest && contentDigest != dgst {
+			logger.Warnf("manifest %s does not match the digest %s returned by registry", key, contentDigest)
+			return entry, nil
+		}
+
+		if !isDigest {
+			mc.store(key, entry.withExpireAt(time.Now().Add(mc.ttl)))
+		}
+		if hasDigest {
+			mc.store(digestKey(scope, req.URL.Host, repo, contentDigest), entry)
+		}
+		return entry, nil
+	})
+
+	select {
+	case result := <-ch:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		if result.Shared {
+			metrics.ProxyManifestCacheCount.WithLabelValues("shared").Add(1)
+		}
+		return result.Val.(*manifestEntry).response(req), nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+// fetch gets the manifest with the method of request and reads the whole response, it is detached from
+// the context of request and bounded by fetchTimeout
+func (mc *ManifestCache) fetch(req *http.Request, next http.RoundTripper) (*manifestEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mc.fetchTimeout)
+	defer cancel()
+	resp, err := next.RoundTrip(req.Clone(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if req.Method == http.MethodHead {
+		return &manifestEntry{
+			statusCode:    resp.StatusCode,
+			header:        resp.Header.Clone(),
+			headOnly:      true,
+			contentLength: resp.ContentLength,
+		}, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxManifestSize {
+		return nil, fmt.Errorf("manifest %s exceeds %d bytes", req.URL.Path, maxManifestSize)
+	}
+	return &manifestEntry{
+		statusCode:    resp.StatusCode,
+		header:        resp.Header.Clone(),
+		body:          body,
+		contentLength: int64(len(body)),
+	}, nil
+}
+
+func (mc *ManifestCache) load(key string) (*manifestEntry, bool) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	v, ok := mc.entries.Get(key)
+	if !ok {
+		return nil, false
+	}
+	entry := v.(*manifestEntry)
+	if !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+		mc.entries.Remove(key)
+		return nil, false
+	}
+	return entry, true
+}
+
+func (mc *ManifestCache) store(key string, entry *manifestEntry) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	mc.entries.Add(key, entry)
+}
+
+// withExpireAt returns a copy of entry with expireAt, the header and body are shared
+func (e *manifestEntry) withExpireAt(expireAt time.Time) *manifestEntry {
+	entry := *e
+	entry.expireAt = expireAt
+	return &entry
+}
+
+// response builds a new response of req from the entry, the body is omitted for HEAD requests
+func (e *manifestEntry) response(req *http.Request) *http.Response {
+	resp := &http.Response{
+		Status:        strconv.Itoa(e.statusCode) + " " + http.StatusText(e.statusCode),
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		ContentLength: e.contentLength,
+		Request:       req,
+	}
+	if req.Method == http.MethodHead {
+		resp.Body = http.NoBody
+	} else {
+		resp.Body = io.NopCloser(bytes.NewReader(e.body))
+	}
+	if e.contentLength >= 0 {
+		resp.Header.Set(headers.ContentLength, strconv.FormatInt(e.contentLength, 10))
+	}
+	return resp
+}
+
+func parseDigest(s string) (digest.Digest, bool) {
+	dgst, err := digest.Parse(s)
+	return dgst, err == nil
+}
+
+func verifyDigest(dgst digest.Digest, body []byte) bool {
+	verifier := dgst.Verifier()
+	if _, err := verifier.Write(body); err != nil {
+		return false
+	}
+	return verifier.Verified()
+}
+
+func digestKey(scope, host, repo string, dgst digest.Digest) string {
+	return fmt.Sprintf("%s|%s/%s@%s", scope, host, repo, dgst)
+}
+
+// headKey returns the key of the headers only entry of key
+func headKey(key string) string {
+	return key + "|head"
+}
+
+// credentialScope returns the hash of the credential in request, the credential itself is not kept in memory
+func credentialScope(req *http.Request) string {
+	credential := req.Header.Get(headers.Authorization)
+	if credential == "" {
+		return "anonymous"
+	}
+	return digestutils.Sha256(credential)
+}
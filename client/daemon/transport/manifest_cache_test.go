@@ -0,0 +1,278 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/opencontainers/go-digest"
+	testifyassert "github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+var (
+	testManifest       = []byte(`{"schemaVersion":2}`)
+	testManifestDigest = digest.FromBytes(testManifest)
+)
+
+// registry is a fake upstream of manifests, it counts the requests and the GET requests
+type registry struct {
+	requests atomic.Int32
+	gets     atomic.Int32
+	status   int
+	digest   string
+	delay    time.Duration
+}
+
+func (r *registry) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.requests.Inc()
+	select {
+	case <-time.After(r.delay):
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	header := http.Header{}
+	if r.digest != "" {
+		header.Set(HeaderDockerContentDigest, r.digest)
+	}
+	switch req.Method {
+	case http.MethodGet:
+		r.gets.Inc()
+		return &http.Response{
+			StatusCode:    r.status,
+			Header:        header,
+			ContentLength: int64(len(testManifest)),
+			Body:          io.NopCloser(bytes.NewReader(testManifest)),
+		}, nil
+	case http.MethodHead:
+		return &http.Response{
+			StatusCode:    r.status,
+			Header:        header,
+			ContentLength: int64(len(testManifest)),
+			Body:          http.NoBody,
+		}, nil
+	default:
+		return nil, io.ErrUnexpectedEOF
+	}
+}
+
+func doManifest(t *testing.T, mc *ManifestCache, upstream http.RoundTripper, method, reference string) (*http.Response, []byte) {
+	return doManifestWithAuth(t, mc, upstream, method, reference, "")
+}
+
+func doManifestWithAuth(t *testing.T, mc *ManifestCache, upstream http.RoundTripper, method, reference, authorization string) (*http.Response, []byte) {
+	req, _ := http.NewRequest(method, "https://registry/v2/library/alpine/manifests/"+reference, nil)
+	if authorization != "" {
+		req.Header.Set(headers.Authorization, authorization)
+	}
+	resp, err := mc.RoundTrip(req, upstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, body
+}
+
+func TestIsManifestRequest(t *testing.T) {
+	assert := testifyassert.New(t)
+	for _, tc := range []struct {
+		method string
+		url    string
+		expect bool
+	}{
+		{http.MethodGet, "https://registry/v2/library/alpine/manifests/latest", true},
+		{http.MethodHead, "https://registry/v2/library/alpine/manifests/" + testManifestDigest.String(), true},
+		{http.MethodPut, "https://registry/v2/library/alpine/manifests/latest", false},
+		{http.MethodGet, "https://registry/v2/library/alpine/blobs/" + testManifestDigest.String(), false},
+	} {
+		req, _ := http.NewRequest(tc.method, tc.url, nil)
+		assert.Equal(tc.expect, IsManifestRequest(req), tc.url)
+	}
+}
+
+func TestManifestCache_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry *registry
+		expect   func(t *testing.T, mc *ManifestCache, r *registry)
+	}{
+		{
+			name:     "tag-addressed manifest is cached and served to HEAD requests",
+			registry: &registry{status: http.StatusOK, digest: testManifestDigest.String()},
+			expect: func(t *testing.T, mc *ManifestCache, r *registry) {
+				assert := testifyassert.New(t)
+				_, body := doManifest(t, mc, r, http.MethodGet, "latest")
+				assert.Equal(testManifest, body)
+				resp, body := doManifest(t, mc, r, http.MethodHead, "latest")
+				assert.Equal(http.StatusOK, resp.StatusCode)
+				assert.Empty(body)
+				assert.Equal(int64(len(testManifest)), resp.ContentLength)
+				assert.Equal(testManifestDigest.String(), resp.Header.Get(HeaderDockerContentDigest))
+				assert.Equal(int32(1), r.requests.Load())
+			},
+		},
+		{
+			name:     "HEAD request is forwarded as HEAD and caches headers only",
+			registry: &registry{status: http.StatusOK, digest: testManifestDigest.String()},
+			expect: func(t *testing.T, mc *ManifestCache, r *registry) {
+				assert := testifyassert.New(t)
+				for i := 0; i < 2; i++ {
+					resp, body := doManifest(t, mc, r, http.MethodHead, "latest")
+					assert.Empty(body)
+					assert.Equal(int64(len(testManifest)), resp.ContentLength)
+					assert.Equal(testManifestDigest.String(), resp.Header.Get(HeaderDockerContentDigest))
+				}
+				assert.Equal(int32(1), r.requests.Load())
+				assert.Equal(int32(0), r.gets.Load())
+
+				// the headers only entry is not served to GET requests
+				_, body := doManifest(t, mc, r, http.MethodGet, "latest")
+				assert.Equal(testManifest, body)
+				_, body = doManifest(t, mc, r, http.MethodGet, testManifestDigest.String())
+				assert.Equal(testManifest, body)
+				assert.Equal(int32(2), r.requests.Load())
+				assert.Equal(int32(1), r.gets.Load())
+			},
+		},
+		{
+			name:     "canceled request does not fail the collapsed requests",
+			registry: &registry{status: http.StatusOK, delay: 100 * time.Millisecond},
+			expect: func(t *testing.T, mc *ManifestCache, r *registry) {
+				assert := testifyassert.New(t)
+				ctx, cancel := context.WithCancel(context.Background())
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://registry/v2/library/alpine/manifests/latest", nil)
+				errCh := make(chan error, 1)
+				go func() {
+					_, err := mc.RoundTrip(req, r)
+					errCh <- err
+				}()
+				time.Sleep(20 * time.Millisecond)
+
+				var wg sync.WaitGroup
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, body := doManifest(t, mc, r, http.MethodGet, "latest")
+					assert.Equal(testManifest, body)
+				}()
+				time.Sleep(20 * time.Millisecond)
+				cancel()
+				assert.ErrorIs(<-errCh, context.Canceled)
+				wg.Wait()
+				assert.Equal(int32(1), r.requests.Load())
+			},
+		},
+		{
+			name:     "tag-addressed manifest expires",
+			registry: &registry{status: http.StatusOK},
+			expect: func(t *testing.T, mc *ManifestCache, r *registry) {
+				assert := testifyassert.New(t)
+				doManifest(t, mc, r, http.MethodGet, "latest")
+				doManifest(t, mc, r, http.MethodGet, "latest")
+				assert.Equal(int32(1), r.requests.Load())
+				time.Sleep(2 * mc.ttl)
+				doManifest(t, mc, r, http.MethodGet, "latest")
+				assert.Equal(int32(2), r.requests.Load())
+			},
+		},
+		{
+			name:     "digest-addressed manifest never expires",
+			registry: &registry{status: http.StatusOK},
+			expect: func(t *testing.T, mc *ManifestCache, r *registry) {
+				assert := testifyassert.New(t)
+				doManifest(t, mc, r, http.MethodGet, testManifestDigest.String())
+				time.Sleep(2 * mc.ttl)
+				_, body := doManifest(t, mc, r, http.MethodGet, testManifestDigest.String())
+				assert.Equal(testManifest, body)
+				assert.Equal(int32(1), r.requests.Load())
+			},
+		},
+		{
+			name:     "manifest mismatching Docker-Content-Digest is not cached",
+			registry: &registry{status: http.StatusOK, digest: digest.FromString("other").String()},
+			expect: func(t *testing.T, mc *ManifestCache, r *registry) {
+				assert := testifyassert.New(t)
+				doManifest(t, mc, r, http.MethodGet, "latest")
+				doManifest(t, mc, r, http.MethodGet, "latest")
+				assert.Equal(int32(2), r.requests.Load())
+			},
+		},
+		{
+			name:     "failed response is not cached",
+			registry: &registry{status: http.StatusUnauthorized},
+			expect: func(t *testing.T, mc *ManifestCache, r *registry) {
+				assert := testifyassert.New(t)
+				resp, _ := doManifest(t, mc, r, http.MethodGet, "latest")
+				assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+				doManifest(t, mc, r, http.MethodGet, "latest")
+				assert.Equal(int32(2), r.requests.Load())
+			},
+		},
+		{
+			name:     "manifest is not served to requests with other credentials",
+			registry: &registry{status: http.StatusOK, digest: testManifestDigest.String()},
+			expect: func(t *testing.T, mc *ManifestCache, r *registry) {
+				assert := testifyassert.New(t)
+				doManifestWithAuth(t, mc, r, http.MethodGet, "latest", "Bearer private")
+				doManifestWithAuth(t, mc, r, http.MethodGet, testManifestDigest.String(), "Bearer private")
+				assert.Equal(int32(1), r.requests.Load())
+
+				// the anonymous request and the request with other credential go to registry
+				r.status = http.StatusUnauthorized
+				for _, reference := range []string{"latest", testManifestDigest.String()} {
+					resp, _ := doManifest(t, mc, r, http.MethodGet, reference)
+					assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+					resp, _ = doManifestWithAuth(t, mc, r, http.MethodHead, reference, "Bearer other")
+					assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+				}
+				assert.Equal(int32(5), r.requests.Load())
+			},
+		},
+		{
+			name:     "concurrent identical requests are collapsed",
+			registry: &registry{status: http.StatusOK, delay: 50 * time.Millisecond},
+			expect: func(t *testing.T, mc *ManifestCache, r *registry) {
+				assert := testifyassert.New(t)
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						_, body := doManifest(t, mc, r, http.MethodGet, "latest")
+						assert.Equal(testManifest, body)
+					}()
+				}
+				wg.Wait()
+				assert.Equal(int32(1), r.requests.Load())
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, NewManifestCache(100*time.Millisecond, 0), tc.registry)
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -27,6 +28,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -42,6 +44,7 @@ import (
 	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/net/httputils"
 )
 
@@ -49,10 +52,21 @@ var _ *logger.SugaredLoggerOnWith // pin this package for no log code generation
 
 var (
 	// layerReg the regex to determine if it is an image download
-	layerReg     = regexp.MustCompile("^.+/blobs/sha256.*$")
+	layerReg = regexp.MustCompile("^.+/blobs/sha256.*$")
+	// digestReg the regex to find the content digest in url
+	digestReg    = regexp.MustCompile("sha256:[A-Fa-f0-9]{64}")
 	traceContext = propagation.TraceContext{}
 )
 
+// policyContextKey is the context key of download policy
+type policyContextKey struct{}
+
+// SetDownloadPolicy attaches the download policy to request, the condition of transport uses it to
+// pass the policy of matched rule to the download, it takes precedence over the default policy
+func SetDownloadPolicy(req *http.Request, policy *config.DownloadPolicy) {
+	*req = *req.WithContext(context.WithValue(req.Context(), policyContextKey{}, policy))
+}
+
 // transport implements RoundTripper for dragonfly.
 // It uses http.fileTransport to serve requests that need to use dragonfly,
 // and uses http.Transport to serve the other requests.
@@ -77,6 +91,12 @@ type transport struct {
 
 	// dumpHTTPContent indicates to dump http request header and response header
 	dumpHTTPContent bool
+
+	// manifestCache caches the registry manifests which are not downloaded with dragonfly, it's nil when disabled
+	manifestCache *ManifestCache
+
+	// defaultPolicy is used when http request is without download policy, it's nil for daemon-wide settings
+	defaultPolicy *config.DownloadPolicy
 }
 
 // Option is functional config for transport.
@@ -137,6 +157,22 @@ func WithDumpHTTPContent(b bool) Option {
 	}
 }
 
+// WithManifestCache sets the cache for registry manifests, it is shared between transports
+func WithManifestCache(mc *ManifestCache) Option {
+	return func(rt *transport) *transport {
+		rt.manifestCache = mc
+		return rt
+	}
+}
+
+// WithDefaultPolicy sets default download policy for http requests without download policy
+func WithDefaultPolicy(policy *config.DownloadPolicy) Option {
+	return func(rt *transport) *transport {
+		rt.defaultPolicy = policy
+		return rt
+	}
+}
+
 // New constructs a new instance of a RoundTripper with additional options.
 func New(options ...Option) (http.RoundTripper, error) {
 	rt := &transport{
@@ -167,6 +203,12 @@ func (rt *transport) RoundTrip(req *http.Request) (resp *http.Response, err erro
 		logger.Debugf("round trip with dragonfly: %s", req.URL.String())
 		metrics.ProxyRequestViaDragonflyCount.Add(1)
 		resp, err = rt.download(ctx, req)
+	} else if rt.manifestCache != nil && IsManifestRequest(req) {
+		logger.Debugf("round trip with manifest cache, method: %s, url: %s", req.Method, req.URL.String())
+		req.Host = req.URL.Host
+		req.Header.Set("Host", req.Host)
+		metrics.ProxyRequestNotViaDragonflyCount.Add(1)
+		resp, err = rt.manifestCache.RoundTrip(req, rt.baseRoundTripper)
 	} else {
 		logger.Debugf("round trip directly, method: %s, url: %s", req.Method, req.URL.String())
 		req.Host = req.URL.Host
@@ -225,28 +267,54 @@ func (rt *transport) download(ctx context.Context, req *http.Request) (*http.Res
 		meta.Range = strings.TrimLeft(rangeHeader, "bytes=")
 	}
 
+	policy := rt.downloadPolicy(req)
+	defaultBiz := rt.defaultBiz
+	if policy.Tag != "" {
+		defaultBiz = policy.Tag
+	}
+
+	// Set meta digest's value, the digest is part of task id, so it is set for ranged requests either,
+	// the whole content is verified with it, but ranged content is not
+	if policy.RequireDigest {
+		digest := digestReg.FindString(req.URL.Path)
+		if digest == "" {
+			return badRequest(req, "digest is required by download policy, but not found in url")
+		}
+		meta.Digest = strings.ToLower(digest)
+	}
+
 	// Pick header's parameters
 	filter := httputils.PickHeader(req.Header, config.HeaderDragonflyFilter, rt.defaultFilter)
-	tag := httputils.PickHeader(req.Header, config.HeaderDragonflyBiz, rt.defaultBiz)
+	tag := httputils.PickHeader(req.Header, config.HeaderDragonflyBiz, defaultBiz)
+	taskIDHeaders := httputils.PickHeader(req.Header, config.HeaderDragonflyTaskIDHeaders, "")
 
 	// Delete hop-by-hop headers
 	delHopHeaders(req.Header)
 
 	meta.Header = httputils.HeaderToMap(req.Header)
-	meta.Tag = tag
+	meta.Tag = tagWithHeaders(tag, req.Header, taskIDHeaders)
 	meta.Filter = filter
 
+	ctx, cancel := withPolicyTimeout(ctx, policy)
+
 	body, attr, err := rt.peerTaskManager.StartStreamTask(
 		ctx,
 		&peer.StreamTaskRequest{
-			URL:     url,
-			URLMeta: meta,
-			Range:   rg,
-			PeerID:  peerID,
+			URL:               url,
+			URLMeta:           meta,
+			Range:             rg,
+			PeerID:            peerID,
+			Limit:             float64(policy.RateLimit.Limit),
+			DisableBackSource: policy.DisableBackSource,
+			Priority:          policy.Priority,
 		},
 	)
 	if err != nil {
+		cancel()
 		log.Errorf("download fail: %v", err)
+		if errors.Is(err, clientutil.ErrNoOverlap) {
+			return requestedRangeNotSatisfiable(req, err.Error())
+		}
 		// add more info for debugging
 		if attr != nil {
 			err = fmt.Errorf("task: %s\npeer: %s\nerror: %s",
@@ -255,6 +323,10 @@ func (rt *transport) download(ctx context.Context, req *http.Request) (*http.Res
 		return nil, err
 	}
 
+	if policy.Timeout.Duration > 0 {
+		body = &timeoutReadCloser{ReadCloser: body, ctx: ctx, cancel: cancel}
+	}
+
 	hdr := httputils.MapToHeader(attr)
 	log.Infof("download stream attribute: %v", hdr)
 
@@ -284,6 +356,64 @@ func (rt *transport) download(ctx context.Context, req *http.Request) (*http.Res
 	return resp, nil
 }
 
+// downloadPolicy returns the download policy of request, the daemon-wide settings are used when it's empty
+func (rt *transport) downloadPolicy(req *http.Request) *config.DownloadPolicy {
+	if policy, ok := req.Context().Value(policyContextKey{}).(*config.DownloadPolicy); ok && policy != nil {
+		return policy
+	}
+	if rt.defaultPolicy != nil {
+		return rt.defaultPolicy
+	}
+	return &config.DownloadPolicy{}
+}
+
+// withPolicyTimeout returns a context with the timeout of download policy, the cancel func is a no-op without timeout
+func withPolicyTimeout(ctx context.Context, policy *config.DownloadPolicy) (context.Context, context.CancelFunc) {
+	if policy.Timeout.Duration <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, policy.Timeout.Duration)
+}
+
+// timeoutReadCloser fails reading when the timeout of download policy is exceeded
+type timeoutReadCloser struct {
+	io.ReadCloser
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (r *timeoutReadCloser) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.ReadCloser.Read(p)
+}
+
+func (r *timeoutReadCloser) Close() error {
+	r.cancel()
+	return r.ReadCloser.Close()
+}
+
+// tagWithHeaders appends the digest of the given headers to tag, the tag is part of task id,
+// so the requests with different values of the headers are different tasks.
+// names is separated by comma, the values are digested to avoid leaking secrets in tag
+func tagWithHeaders(tag string, header http.Header, names string) string {
+	var data []string
+	for _, name := range strings.Split(names, ",") {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		data = append(data, name+"="+strings.Join(header.Values(name), ","))
+	}
+	if len(data) == 0 {
+		return tag
+	}
+
+	sort.Strings(data)
+	return config.TagWithHeaderDigest(tag, digestutils.Sha256(data...))
+}
+
 func (rt *transport) processDumpHTTPContent(req *http.Request, resp *http.Response) {
 	if !rt.dumpHTTPContent {
 		return
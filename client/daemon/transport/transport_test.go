@@ -22,11 +22,16 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-http-utils/headers"
 	"github.com/golang/mock/gomock"
 	testifyassert "github.com/stretchr/testify/assert"
 
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/peer"
 	"d7y.io/dragonfly/v2/client/daemon/test"
 	mock_peer "d7y.io/dragonfly/v2/client/daemon/test/mock/peer"
@@ -72,3 +77,111 @@ func TestTransport_RoundTrip(t *testing.T) {
 	}
 	assert.Equal(testData, output)
 }
+
+func TestTagWithHeaders(t *testing.T) {
+	assert := testifyassert.New(t)
+	header := http.Header{}
+	header.Set("X-Tenant", "tenant-secret")
+	header.Set("X-Region", "b")
+
+	assert.Equal("tag", tagWithHeaders("tag", header, ""))
+	assert.Equal("tag", tagWithHeaders("tag", header, " , "))
+
+	tag := tagWithHeaders("tag", header, "x-tenant, X-Region")
+	assert.True(strings.HasPrefix(tag, "tag#"))
+	assert.Equal("tag", config.TrimHeaderDigest(tag))
+	assert.NotContains(tag, "tenant-secret")
+	assert.Equal(tag, tagWithHeaders("tag", header, "X-Region,X-Tenant"), "order of headers is ignored")
+
+	header.Set("X-Tenant", "c")
+	assert.NotEqual(tag, tagWithHeaders("tag", header, "X-Tenant,X-Region"))
+}
+
+func TestTransport_DownloadPolicy(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	digest := "sha256:" + strings.Repeat("a", 64)
+	var url = "http://x/v2/library/blobs/" + digest
+
+	var streamRequest *peer.StreamTaskRequest
+	peerTaskManager := mock_peer.NewMockTaskManager(ctrl)
+	peerTaskManager.EXPECT().StartStreamTask(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req *peer.StreamTaskRequest) (io.ReadCloser, map[string]string, error) {
+			streamRequest = req
+			return io.NopCloser(bytes.NewBufferString("data")), nil, nil
+		},
+	).AnyTimes()
+
+	defaultPolicy := &config.DownloadPolicy{
+		Tag:      "default",
+		Priority: 1,
+	}
+	rulePolicy := &config.DownloadPolicy{
+		RateLimit:         clientutil.RateLimit{Limit: 1024},
+		DisableBackSource: true,
+		Tag:               "rule",
+		RequireDigest:     true,
+		Timeout:           clientutil.Duration{Duration: time.Millisecond},
+		Priority:          3,
+	}
+	var policy *config.DownloadPolicy
+	rt, _ := New(
+		WithPeerHost(&scheduler.PeerHost{}),
+		WithPeerTaskManager(peerTaskManager),
+		WithDefaultPolicy(defaultPolicy),
+		WithCondition(func(r *http.Request) bool {
+			if policy != nil {
+				SetDownloadPolicy(r, policy)
+			}
+			return true
+		}))
+
+	// default policy
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	resp, err := rt.RoundTrip(req)
+	assert.Nil(err)
+	resp.Body.Close()
+	assert.Equal("default", streamRequest.URLMeta.Tag)
+	assert.Empty(streamRequest.URLMeta.Digest)
+	assert.Equal(int32(1), streamRequest.Priority)
+	assert.False(streamRequest.DisableBackSource)
+
+	// policy of matched rule
+	policy = rulePolicy
+	req, _ = http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	resp, err = rt.RoundTrip(req)
+	assert.Nil(err)
+	assert.Equal("rule", streamRequest.URLMeta.Tag)
+	assert.Equal(digest, streamRequest.URLMeta.Digest)
+	assert.Equal(float64(1024), streamRequest.Limit)
+	assert.True(streamRequest.DisableBackSource)
+	assert.Equal(int32(3), streamRequest.Priority)
+	time.Sleep(10 * time.Millisecond)
+	_, err = io.ReadAll(resp.Body)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	resp.Body.Close()
+
+	// X-Dragonfly-Biz header takes precedence over the tag of policy
+	req, _ = http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	req.Header.Set(config.HeaderDragonflyBiz, "biz")
+	resp, err = rt.RoundTrip(req)
+	assert.Nil(err)
+	resp.Body.Close()
+	assert.Equal("biz", streamRequest.URLMeta.Tag)
+
+	// digest is part of task id, it is set for ranged requests either
+	req, _ = http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	req.Header.Set(headers.Range, "bytes=0-1")
+	resp, err = rt.RoundTrip(req)
+	assert.Nil(err)
+	resp.Body.Close()
+	assert.Equal("0-1", streamRequest.URLMeta.Range)
+	assert.Equal(digest, streamRequest.URLMeta.Digest)
+
+	// digest is required
+	req, _ = http.NewRequestWithContext(context.Background(), http.MethodGet, "http://x/y", nil)
+	resp, err = rt.RoundTrip(req)
+	assert.Nil(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+}
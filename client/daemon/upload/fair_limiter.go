@@ -0,0 +1,193 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+const defaultMaxWeight = 8
+
+// FairLimiter shares a rate limiter across concurrent children with weighted fair queuing.
+// Every task requested by a peer host is a flow, the weight of a flow is split by the active flows of the host,
+// so a host pulling many tasks or a huge file can not starve the others.
+type FairLimiter struct {
+	limiter   *rate.Limiter
+	maxWeight int
+
+	lock sync.Mutex
+	// vtime is the virtual time, it's the start tag of the latest dispatched request
+	vtime float64
+	flows map[flowKey]*flow
+	// hosts is the count of active flows per host
+	hosts       map[string]int
+	queue       fairQueue
+	dispatching bool
+}
+
+type flowKey struct {
+	host string
+	task string
+}
+
+type flow struct {
+	// finish is the finish tag of the latest request in the flow
+	finish  float64
+	pending int
+}
+
+type fairRequest struct {
+	ctx    context.Context
+	key    flowKey
+	start  float64
+	finish float64
+	n      int
+	done   chan error
+}
+
+// NewFairLimiter creates a FairLimiter, the burst size of limiter must be bigger than piece size
+func NewFairLimiter(limiter *rate.Limiter, maxWeight int) *FairLimiter {
+	if maxWeight <= 0 {
+		maxWeight = defaultMaxWeight
+	}
+	return &FairLimiter{
+		limiter:   limiter,
+		maxWeight: maxWeight,
+		flows:     map[flowKey]*flow{},
+		hosts:     map[string]int{},
+	}
+}
+
+// WaitN blocks until n bytes of the task are allowed to upload to the host,
+// requests are served in the order of the finish tags of their flows
+func (l *FairLimiter) WaitN(ctx context.Context, host, task string, weight int, n int) error {
+	if weight < 1 {
+		weight = 1
+	}
+	if weight > l.maxWeight {
+		weight = l.maxWeight
+	}
+
+	l.lock.Lock()
+	key := flowKey{host: host, task: task}
+	f, ok := l.flows[key]
+	if !ok {
+		f = &flow{finish: l.vtime}
+		l.flows[key] = f
+	}
+	if f.pending == 0 {
+		l.hosts[host]++
+	}
+	f.pending++
+
+	share := float64(weight) / float64(l.hosts[host])
+	req := &fairRequest{
+		ctx:   ctx,
+		key:   key,
+		start: math.Max(l.vtime, f.finish),
+		n:     n,
+		done:  make(chan error, 1),
+	}
+	req.finish = req.start + float64(n)/share
+	f.finish = req.finish
+	heap.Push(&l.queue, req)
+	if !l.dispatching {
+		l.dispatching = true
+		go l.dispatch()
+	}
+	l.lock.Unlock()
+
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		// the request will be dropped by dispatcher without consuming tokens
+		return ctx.Err()
+	}
+}
+
+// dispatch takes tokens for the queued requests one by one, it exits when the queue is empty
+func (l *FairLimiter) dispatch() {
+	for {
+		l.lock.Lock()
+		if l.queue.Len() == 0 {
+			// all flows are idle, the debts of them are meaningless for the requests in future
+			l.flows = map[flowKey]*flow{}
+			l.dispatching = false
+			l.lock.Unlock()
+			return
+		}
+		req := heap.Pop(&l.queue).(*fairRequest)
+		l.vtime = math.Max(l.vtime, req.start)
+		l.release(req.key)
+		l.lock.Unlock()
+
+		req.done <- l.limiter.WaitN(req.ctx, req.n)
+	}
+}
+
+// release marks one request of the flow dispatched and removes the idle flows, caller must hold the lock
+func (l *FairLimiter) release(key flowKey) {
+	if f, ok := l.flows[key]; ok {
+		f.pending--
+		if f.pending == 0 {
+			l.hosts[key.host]--
+			if l.hosts[key.host] <= 0 {
+				delete(l.hosts, key.host)
+			}
+		}
+	}
+	// idle flows which are not ahead of virtual time are same with new flows
+	for k, f := range l.flows {
+		if f.pending == 0 && f.finish <= l.vtime {
+			delete(l.flows, k)
+		}
+	}
+}
+
+// fairQueue is a min heap of requests ordered by finish tag
+type fairQueue []*fairRequest
+
+func (q fairQueue) Len() int {
+	return len(q)
+}
+
+func (q fairQueue) Less(i, j int) bool {
+	return q[i].finish < q[j].finish
+}
+
+func (q fairQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *fairQueue) Push(x interface{}) {
+	*q = append(*q, x.(*fairRequest))
+}
+
+func (q *fairQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	req := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return req
+}
@@ -0,0 +1,158 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+// queued returns the count of requests waiting in the queue of the flow
+func queued(l *FairLimiter, host, task string) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if f, ok := l.flows[flowKey{host: host, task: task}]; ok {
+		return f.pending
+	}
+	return 0
+}
+
+func waitQueued(t *testing.T, l *FairLimiter, host, task string, count int) {
+	for i := 0; i < 1000; i++ {
+		if queued(l, host, task) >= count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("requests of %s/%s are not queued", host, task)
+}
+
+func TestFairLimiter_WaitN(t *testing.T) {
+	pieceSize := 100
+
+	tests := []struct {
+		name   string
+		expect func(t *testing.T, l *FairLimiter)
+	}{
+		{
+			name: "a new child is served before the backlog of a busy child",
+			expect: func(t *testing.T, l *FairLimiter) {
+				assert := testifyassert.New(t)
+				var (
+					lock  sync.Mutex
+					order []string
+					wg    sync.WaitGroup
+				)
+				wait := func(host, task string) {
+					defer wg.Done()
+					assert.Nil(l.WaitN(context.Background(), host, task, 1, pieceSize))
+					lock.Lock()
+					order = append(order, host)
+					lock.Unlock()
+				}
+
+				wg.Add(20)
+				for i := 0; i < 20; i++ {
+					go wait("busy", "huge")
+				}
+				waitQueued(t, l, "busy", "huge", 15)
+				wg.Add(1)
+				go wait("new", "small")
+				wg.Wait()
+
+				var position int
+				for i, host := range order {
+					if host == "new" {
+						position = i
+					}
+				}
+				assert.Less(position, 6, fmt.Sprintf("order: %v", order))
+			},
+		},
+		{
+			name: "bandwidth is shared by weight",
+			expect: func(t *testing.T, l *FairLimiter) {
+				assert := testifyassert.New(t)
+				var (
+					lock   sync.Mutex
+					served = map[string]int{}
+					wg     sync.WaitGroup
+				)
+				ctx, cancel := context.WithCancel(context.Background())
+				wait := func(host string, weight int) {
+					defer wg.Done()
+					if err := l.WaitN(ctx, host, "task", weight, pieceSize); err != nil {
+						return
+					}
+					lock.Lock()
+					served[host]++
+					if served["heavy"]+served["light"] == 15 {
+						cancel()
+					}
+					lock.Unlock()
+				}
+
+				wg.Add(40)
+				for i := 0; i < 20; i++ {
+					go wait("heavy", 4)
+					go wait("light", 1)
+				}
+				wg.Wait()
+				cancel()
+				assert.Greater(served["heavy"], served["light"]*2, fmt.Sprintf("served: %v", served))
+			},
+		},
+		{
+			name: "canceled requests do not block others",
+			expect: func(t *testing.T, l *FairLimiter) {
+				assert := testifyassert.New(t)
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				assert.ErrorIs(l.WaitN(ctx, "host", "task", 1, pieceSize), context.Canceled)
+				assert.Nil(l.WaitN(context.Background(), "host", "task", 1, pieceSize))
+			},
+		},
+		{
+			name: "idle flows are removed",
+			expect: func(t *testing.T, l *FairLimiter) {
+				assert := testifyassert.New(t)
+				for i := 0; i < 10; i++ {
+					assert.Nil(l.WaitN(context.Background(), fmt.Sprintf("host-%d", i), "task", 1, pieceSize))
+				}
+				assert.Eventually(func() bool {
+					l.lock.Lock()
+					defer l.lock.Unlock()
+					return len(l.flows) == 0 && len(l.hosts) == 0
+				}, time.Second, 10*time.Millisecond)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// about 10ms per piece
+			limiter := rate.NewLimiter(rate.Limit(pieceSize*100), pieceSize)
+			tc.expect(t, NewFairLimiter(limiter, 0))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/atomic"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+)
+
+// idleCheckInterval is the interval to check in-flight uploads when waiting idle
+const idleCheckInterval = 100 * time.Millisecond
+
+// Inflight counts the in-flight uploads, it's shared by http upload, DownloadPiece rpc and ObtainSeeds rpc,
+// so that dfdaemon can wait all of them finished when draining
+type Inflight struct {
+	count atomic.Int64
+	// activeAt is the unix nano time of the latest upload started or finished
+	activeAt atomic.Int64
+}
+
+func NewInflight() *Inflight {
+	return &Inflight{}
+}
+
+// Track counts an upload until the returned function is called
+func (i *Inflight) Track() func() {
+	i.count.Inc()
+	i.activeAt.Store(time.Now().UnixNano())
+	return func() {
+		i.activeAt.Store(time.Now().UnixNano())
+		i.count.Dec()
+	}
+}
+
+// Count returns the count of in-flight uploads
+func (i *Inflight) Count() int64 {
+	return i.count.Load()
+}
+
+// WaitIdle blocks until there are no in-flight uploads for the quiet period or ctx is done,
+// the children still downloading request pieces one by one, so the count may be zero between two requests
+func (i *Inflight) WaitIdle(ctx context.Context, quietPeriod time.Duration) error {
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+	for {
+		n := i.count.Load()
+		if n == 0 && time.Since(time.Unix(0, i.activeAt.Load())) >= quietPeriod {
+			return nil
+		}
+		logger.Debugf("wait %d in-flight uploads", n)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("%d uploads are still in-flight: %w", n, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestInflight_WaitIdle(t *testing.T) {
+	assert := testifyassert.New(t)
+	inflight := NewInflight()
+	// the counter is shared by upload manager and rpc server
+	um := &uploadManager{inflight: inflight}
+	assert.Nil(um.WaitIdle(context.Background(), time.Minute), "no uploads ever")
+
+	done := inflight.Track()
+	assert.Equal(int64(1), inflight.Count())
+	ctx, cancel := context.WithTimeout(context.Background(), 2*idleCheckInterval)
+	defer cancel()
+	assert.ErrorIs(um.WaitIdle(ctx, 0), context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(idleCheckInterval)
+		done()
+	}()
+	assert.Nil(um.WaitIdle(context.Background(), 0), "in-flight upload finished")
+	assert.Equal(int64(0), inflight.Count())
+}
+
+func TestInflight_WaitIdleQuietPeriod(t *testing.T) {
+	assert := testifyassert.New(t)
+	inflight := NewInflight()
+	quietPeriod := 5 * idleCheckInterval
+
+	// a child downloading requests pieces one by one, the count is zero between two requests
+	stop := make(chan struct{})
+	done := inflight.Track()
+	go func() {
+		for {
+			time.Sleep(idleCheckInterval / 10)
+			done()
+			time.Sleep(idleCheckInterval / 2)
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			done = inflight.Track()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 4*quietPeriod)
+	defer cancel()
+	go func() {
+		time.Sleep(2 * quietPeriod)
+		close(stop)
+	}()
+
+	start := time.Now()
+	assert.Nil(inflight.WaitIdle(ctx, quietPeriod))
+	assert.GreaterOrEqual(time.Since(start), 2*quietPeriod, "waits until child stops and quiet period passed")
+	assert.Equal(int64(0), inflight.Count())
+}
@@ -23,12 +23,16 @@ import (
 	"math"
 	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-http-utils/headers"
 	"github.com/gorilla/mux"
 	"golang.org/x/time/rate"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 )
@@ -38,12 +42,19 @@ var _ *logger.SugaredLoggerOnWith // pin this package for no log code generation
 type Manager interface {
 	Serve(lis net.Listener) error
 	Stop() error
+	// WaitIdle blocks until there are no in-flight uploads for the quiet period or ctx is done
+	WaitIdle(ctx context.Context, quietPeriod time.Duration) error
 }
 
 type uploadManager struct {
 	*http.Server
 	*rate.Limiter
+	// fairLimiter is used instead of Limiter when it's set
+	fairLimiter    *FairLimiter
 	StorageManager storage.Manager
+
+	// inflight counts in-flight uploads, it may be shared with rpc server
+	inflight *Inflight
 }
 
 var _ Manager = (*uploadManager)(nil)
@@ -56,6 +67,7 @@ func NewUploadManager(s storage.Manager, opts ...func(*uploadManager)) (Manager,
 	u := &uploadManager{
 		Server:         &http.Server{},
 		StorageManager: s,
+		inflight:       NewInflight(),
 	}
 	u.initRouter()
 	for _, opt := range opts {
@@ -71,6 +83,20 @@ func WithLimiter(limiter *rate.Limiter) func(*uploadManager) {
 	}
 }
 
+// WithInflight shares the in-flight upload counter with other upload services
+func WithInflight(inflight *Inflight) func(*uploadManager) {
+	return func(manager *uploadManager) {
+		manager.inflight = inflight
+	}
+}
+
+// WithFairLimiter shares the upload rate limit across concurrent children with weighted fair queuing
+func WithFairLimiter(limiter *FairLimiter) func(*uploadManager) {
+	return func(manager *uploadManager) {
+		manager.fairLimiter = limiter
+	}
+}
+
 func (um *uploadManager) initRouter() {
 	r := mux.NewRouter()
 	r.HandleFunc(PeerDownloadHTTPPathPrefix+"{taskPrefix:.*}/"+"{task:.*}", um.handleUpload).Queries("peerId", "{.*}").Methods("GET")
@@ -85,8 +111,14 @@ func (um *uploadManager) Stop() error {
 	return um.Server.Shutdown(context.Background())
 }
 
+func (um *uploadManager) WaitIdle(ctx context.Context, quietPeriod time.Duration) error {
+	return um.inflight.WaitIdle(ctx, quietPeriod)
+}
+
 // handleUpload uses to upload a task file when other peers download from it.
 func (um *uploadManager) handleUpload(w http.ResponseWriter, r *http.Request) {
+	defer um.inflight.Track()()
+
 	var (
 		task = mux.Vars(r)["task"]
 		peer = r.FormValue("peerId")
@@ -126,7 +158,14 @@ func (um *uploadManager) handleUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer closer.Close()
-	if um.Limiter != nil {
+	host := remoteHost(r.RemoteAddr)
+	if um.fairLimiter != nil {
+		if err = um.fairLimiter.WaitN(r.Context(), host, task, ParseWeight(r.Header.Get(config.HeaderDragonflyPriority)), int(rg[0].Length)); err != nil {
+			sLogger.Errorf("get fair limit failed: %s", err)
+			http.Error(w, fmt.Sprintf("get limit error: %s", err), http.StatusInternalServerError)
+			return
+		}
+	} else if um.Limiter != nil {
 		if err = um.Limiter.WaitN(r.Context(), int(rg[0].Length)); err != nil {
 			sLogger.Errorf("get limit failed: %s", err)
 			http.Error(w, fmt.Sprintf("get limit error: %s", err), http.StatusInternalServerError)
@@ -136,12 +175,32 @@ func (um *uploadManager) handleUpload(w http.ResponseWriter, r *http.Request) {
 
 	// if w is a socket, golang will use sendfile or splice syscall for zero copy feature
 	// when start to transfer data, we could not call http.Error with header
-	if n, err := io.Copy(w, reader); err != nil {
+	n, err := io.Copy(w, reader)
+	metrics.UploadBytesCount.Add(float64(n))
+	if err != nil {
 		sLogger.Errorf("transfer data failed: %s", err)
 		return
-	} else if n != rg[0].Length {
+	}
+	if n != rg[0].Length {
 		sLogger.Errorf("transferred data length not match request, request: %d, transferred: %d",
 			rg[0].Length, n)
 		return
 	}
 }
+
+// ParseWeight parses the weight of upload request from X-Dragonfly-Priority header, the default weight is 1
+func ParseWeight(priority string) int {
+	weight, err := strconv.Atoi(priority)
+	if err != nil || weight < 1 {
+		return 1
+	}
+	return weight
+}
+
+func remoteHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
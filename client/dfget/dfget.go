@@ -75,6 +75,9 @@ func Download(cfg *config.DfgetConfig, client daemonclient.DaemonClient) error {
 
 func download(ctx context.Context, client daemonclient.DaemonClient, cfg *config.DfgetConfig, wLog *logger.SugaredLoggerOnWith) error {
 	if cfg.Recursive {
+		if cfg.RecursiveDirectory && !cfg.RecursiveList {
+			return directoryDownload(ctx, client, cfg)
+		}
 		return recursiveDownload(ctx, client, cfg)
 	}
 	return singleDownload(ctx, client, cfg, wLog)
@@ -227,13 +230,20 @@ func newDownRequest(cfg *config.DfgetConfig, hdr map[string]string) *dfdaemon.Do
 			Filter: cfg.Filter,
 			Header: hdr,
 		},
-		Pattern:    cfg.Pattern,
-		Callsystem: cfg.CallSystem,
-		Uid:        int64(basic.UserID),
-		Gid:        int64(basic.UserGroup),
+		Pattern:      cfg.Pattern,
+		Callsystem:   cfg.CallSystem,
+		Uid:          int64(basic.UserID),
+		Gid:          int64(basic.UserGroup),
+		ResumeOutput: cfg.Resume && isPartialOutput(cfg.Output),
 	}
 }
 
+// isPartialOutput returns whether output is an existing regular file with data
+func isPartialOutput(output string) bool {
+	info, err := os.Stat(output)
+	return err == nil && info.Mode().IsRegular() && info.Size() > 0
+}
+
 func newProgressBar(max int64) *progressbar.ProgressBar {
 	return progressbar.NewOptions64(max,
 		progressbar.OptionShowBytes(true),
@@ -0,0 +1,271 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dfget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"d7y.io/dragonfly/v2/client/config"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/basic"
+	daemonclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/source"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+// DirectoryReport is the json report of directory task
+type DirectoryReport struct {
+	URL        string                 `json:"url"`
+	Output     string                 `json:"output"`
+	Success    bool                   `json:"success"`
+	Error      string                 `json:"error,omitempty"`
+	TotalSize  int64                  `json:"totalSize"`
+	CostMillis int64                  `json:"costMillis"`
+	Files      []*DirectoryFileReport `json:"files"`
+}
+
+// DirectoryFileReport is the report of one file in directory task
+type DirectoryFileReport struct {
+	URL string `json:"url"`
+	// Path is the relative path in output directory
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Digest string `json:"digest,omitempty"`
+	// Verified indicates the file is verified with the content digest in url, like the oid of lfs objects,
+	// the other files are verified with piece digests in p2p downloading
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// lfsOIDQuery is the url query of git lfs object id, it is the sha256 of object
+const lfsOIDQuery = "oid"
+
+// sha256DigestReg matches the sha256 digest in content addressable url, like the blobs of registry
+var sha256DigestReg = regexp.MustCompile("sha256:([A-Fa-f0-9]{64})")
+
+// contentDigest returns the sha256 of content addressable url, it is empty for other urls
+func contentDigest(u *url.URL) string {
+	if oid := u.Query().Get(lfsOIDQuery); oid != "" {
+		return strings.ToLower(oid)
+	}
+	if m := sha256DigestReg.FindStringSubmatch(u.Path); m != nil {
+		return strings.ToLower(m[1])
+	}
+	return ""
+}
+
+// directoryDownload downloads all resources of url as a whole, files are downloaded into a staging directory
+// in parallel, and the staging directory is renamed to output only when all files are downloaded and verified
+func directoryDownload(ctx context.Context, client daemonclient.DaemonClient, cfg *config.DfgetConfig) error {
+	var (
+		start  = time.Now()
+		report = &DirectoryReport{URL: cfg.URL, Output: cfg.Output}
+	)
+
+	err := downloadDirectory(ctx, client, cfg, report)
+	report.Success = err == nil
+	if err != nil {
+		report.Error = err.Error()
+	}
+	report.CostMillis = time.Now().Sub(start).Milliseconds()
+
+	if reportErr := writeDirectoryReport(cfg.RecursiveReport, report); reportErr != nil {
+		logger.Errorf("write directory report error: %s", reportErr)
+		if err == nil {
+			err = reportErr
+		}
+	}
+	return err
+}
+
+func downloadDirectory(ctx context.Context, client daemonclient.DaemonClient, cfg *config.DfgetConfig, report *DirectoryReport) error {
+	files, err := buildDirectoryManifest(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	report.Files = files
+
+	staging, err := os.MkdirTemp(filepath.Dir(cfg.Output), fmt.Sprintf(".%s.dfget-", filepath.Base(cfg.Output)))
+	if err != nil {
+		return err
+	}
+	// staging directory does not exist any more after placed
+	defer os.RemoveAll(staging)
+	if err = os.Chmod(staging, 0755); err != nil {
+		return err
+	}
+	if err = os.Chown(staging, basic.UserID, basic.UserGroup); err != nil {
+		return errors.Wrapf(err, "change directory owner to uid[%d] gid[%d]", basic.UserID, basic.UserGroup)
+	}
+
+	logger.Infof("download %d files of %s into staging directory %s", len(files), cfg.URL, staging)
+	eg, egCtx := errgroup.WithContext(ctx)
+	sema := make(chan struct{}, cfg.RecursiveConcurrency)
+loop:
+	for _, file := range files {
+		select {
+		case sema <- struct{}{}:
+		case <-egCtx.Done():
+			break loop
+		}
+		file := file
+		eg.Go(func() error {
+			defer func() { <-sema }()
+			if err := downloadDirectoryFile(egCtx, client, cfg, staging, file); err != nil {
+				file.Error = err.Error()
+				return errors.Wrapf(err, "download %s", file.URL)
+			}
+			return nil
+		})
+	}
+	if err = eg.Wait(); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		report.TotalSize += file.Size
+	}
+	return placeDirectory(staging, cfg.Output)
+}
+
+// buildDirectoryManifest lists all accepted files of url, sorted by relative path
+func buildDirectoryManifest(ctx context.Context, cfg *config.DfgetConfig) ([]*DirectoryFileReport, error) {
+	request, err := source.NewRequestWithContext(ctx, cfg.URL, parseHeader(cfg.Header))
+	if err != nil {
+		return nil, err
+	}
+	dirURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	urls, err := source.List(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		files []*DirectoryFileReport
+		paths = map[string]string{}
+	)
+	for _, u := range urls {
+		if !accept(u.String(), dirURL.Path, u.Path, cfg.RecursiveLevel, cfg.RecursiveAcceptRegex, cfg.RecursiveRejectRegex) {
+			logger.Debugf("url %s is not accepted, skip", u.String())
+			continue
+		}
+		// clean the path to keep files in output directory
+		rel := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(u.Path, dirURL.Path)), "/")
+		if rel == "" {
+			return nil, errors.Errorf("url %s has no relative path to %s", u.String(), cfg.URL)
+		}
+		if dup, ok := paths[rel]; ok {
+			return nil, errors.Errorf("url %s and %s are downloaded to the same path %s", dup, u.String(), rel)
+		}
+		paths[rel] = u.String()
+		files = append(files, &DirectoryFileReport{
+			URL:  u.String(),
+			Path: rel,
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// downloadDirectoryFile downloads one file into staging directory and verifies it with the content digest in url
+func downloadDirectoryFile(ctx context.Context, client daemonclient.DaemonClient, cfg *config.DfgetConfig, staging string, file *DirectoryFileReport) error {
+	// reuse dfget config, the digest is for the whole directory task, not for each file
+	c := *cfg
+	c.Recursive, c.RecursiveDirectory, c.ShowProgress, c.Digest = false, false, false, ""
+	c.URL, c.Output = file.URL, filepath.Join(staging, filepath.FromSlash(file.Path))
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("download %s to %s", c.URL, c.Output)
+	if err = singleDownload(ctx, client, &c, logger.With("url", c.URL)); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(c.Output)
+	if err != nil {
+		return err
+	}
+	actual := digestutils.HashFile(c.Output, digestutils.Sha256Hash)
+	if actual == "" {
+		return errors.Errorf("calculate digest of %s failed", c.Output)
+	}
+	if expected := contentDigest(u); expected != "" {
+		if actual != expected {
+			return errors.Errorf("digest not match, desired: %s, actual: %s", expected, actual)
+		}
+		file.Verified = true
+	}
+	file.Size = info.Size()
+	file.Digest = strings.Join([]string{digestutils.Sha256Hash.String(), actual}, ":")
+	return nil
+}
+
+// placeDirectory renames staging directory to output, the existing output is replaced
+func placeDirectory(staging, output string) error {
+	if _, err := os.Lstat(output); os.IsNotExist(err) {
+		return os.Rename(staging, output)
+	}
+
+	backup := staging + ".old"
+	if err := os.Rename(output, backup); err != nil {
+		return err
+	}
+	if err := os.Rename(staging, output); err != nil {
+		if restoreErr := os.Rename(backup, output); restoreErr != nil {
+			logger.Errorf("restore %s error: %s", output, restoreErr)
+		}
+		return err
+	}
+	return os.RemoveAll(backup)
+}
+
+func writeDirectoryReport(reportPath string, report *DirectoryReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if reportPath == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	return os.WriteFile(reportPath, data, 0644)
+}
@@ -0,0 +1,196 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dfget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/source"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+const dirTestScheme = "dirtest"
+
+// fakeDirectorySource lists and downloads files in memory, files in failures return error when downloading,
+// files in oids are listed with the oid query like lfs objects
+type fakeDirectorySource struct {
+	files    map[string]string
+	failures map[string]bool
+	oids     map[string]string
+}
+
+func (s *fakeDirectorySource) GetContentLength(request *source.Request) (int64, error) {
+	return int64(len(s.files[request.URL.Path])), nil
+}
+
+func (s *fakeDirectorySource) IsSupportRange(request *source.Request) (bool, error) {
+	return false, nil
+}
+
+func (s *fakeDirectorySource) IsExpired(request *source.Request, info *source.ExpireInfo) (bool, error) {
+	return false, nil
+}
+
+func (s *fakeDirectorySource) Download(request *source.Request) (*source.Response, error) {
+	if s.failures[request.URL.Path] {
+		return nil, errors.New("download failed")
+	}
+	return source.NewResponse(io.NopCloser(strings.NewReader(s.files[request.URL.Path]))), nil
+}
+
+func (s *fakeDirectorySource) GetLastModified(request *source.Request) (int64, error) {
+	return -1, nil
+}
+
+func (s *fakeDirectorySource) List(request *source.Request) ([]*url.URL, error) {
+	var urls []*url.URL
+	for p := range s.files {
+		u := &url.URL{Scheme: dirTestScheme, Host: request.URL.Host, Path: p}
+		if oid, ok := s.oids[p]; ok {
+			u.RawQuery = url.Values{lfsOIDQuery: []string{oid}}.Encode()
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
+
+func Test_directoryDownload(t *testing.T) {
+	files := map[string]string{
+		"/models/config.json":    `{"layers": 12}`,
+		"/models/weights/a.bin":  "weights a",
+		"/models/weights/b.bin":  "weights b",
+		"/models/tokenizer.json": `{"vocab": 30522}`,
+	}
+	sourceClient := &fakeDirectorySource{files: files, failures: map[string]bool{}, oids: map[string]string{}}
+	require.Nil(t, source.Register(dirTestScheme, sourceClient, func(request *source.Request) *source.Request {
+		return request
+	}))
+	defer source.UnRegister(dirTestScheme)
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "models")
+	reportPath := filepath.Join(dir, "report.json")
+	newConfig := func() *config.DfgetConfig {
+		return &config.DfgetConfig{
+			URL:                  dirTestScheme + "://bucket/models",
+			Output:               output,
+			Recursive:            true,
+			RecursiveDirectory:   true,
+			RecursiveConcurrency: 2,
+			RecursiveReport:      reportPath,
+		}
+	}
+	readReport := func() *DirectoryReport {
+		data, err := os.ReadFile(reportPath)
+		require.Nil(t, err)
+		report := &DirectoryReport{}
+		require.Nil(t, json.Unmarshal(data, report))
+		return report
+	}
+
+	// failed task leaves output untouched
+	require.Nil(t, os.MkdirAll(output, 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(output, "old.bin"), []byte("old"), 0644))
+	sourceClient.failures["/models/weights/b.bin"] = true
+	err := download(context.Background(), nil, newConfig(), nil)
+	assert.NotNil(t, err)
+	report := readReport()
+	assert.False(t, report.Success)
+	assert.NotEmpty(t, report.Error)
+	entries, err := os.ReadDir(output)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "old.bin", entries[0].Name())
+	staging, err := filepath.Glob(filepath.Join(dir, ".models.dfget-*"))
+	assert.Nil(t, err)
+	assert.Empty(t, staging)
+
+	// successful task replaces output with all files
+	delete(sourceClient.failures, "/models/weights/b.bin")
+	err = download(context.Background(), nil, newConfig(), nil)
+	assert.Nil(t, err)
+	report = readReport()
+	assert.True(t, report.Success)
+	assert.Len(t, report.Files, len(files))
+
+	var totalSize int64
+	for i, file := range report.Files {
+		if i > 0 {
+			assert.True(t, report.Files[i-1].Path < file.Path)
+		}
+		content := files["/models/"+file.Path]
+		assert.Equal(t, dirTestScheme+"://bucket/models/"+file.Path, file.URL)
+		assert.Equal(t, int64(len(content)), file.Size)
+		assert.Equal(t, "sha256:"+digestutils.Sha256(content), file.Digest)
+		assert.False(t, file.Verified)
+
+		data, err := os.ReadFile(filepath.Join(output, file.Path))
+		assert.Nil(t, err)
+		assert.Equal(t, content, string(data))
+		totalSize += file.Size
+	}
+	assert.Equal(t, totalSize, report.TotalSize)
+	_, err = os.Stat(filepath.Join(output, "old.bin"))
+	assert.True(t, os.IsNotExist(err))
+
+	// file with the oid of other content fails the task
+	sourceClient.oids["/models/weights/a.bin"] = digestutils.Sha256("weights b")
+	err = download(context.Background(), nil, newConfig(), nil)
+	assert.NotNil(t, err)
+	report = readReport()
+	assert.False(t, report.Success)
+	assert.Contains(t, report.Error, "digest not match")
+
+	// file with the oid of its content is verified
+	sourceClient.oids["/models/weights/a.bin"] = digestutils.Sha256("weights a")
+	err = download(context.Background(), nil, newConfig(), nil)
+	assert.Nil(t, err)
+	report = readReport()
+	assert.True(t, report.Success)
+	for _, file := range report.Files {
+		assert.Equal(t, file.Path == "weights/a.bin", file.Verified)
+	}
+}
+
+func Test_contentDigest(t *testing.T) {
+	oid := digestutils.Sha256("model")
+	tests := []struct {
+		url    string
+		digest string
+	}{
+		{url: "hf://huggingface.co/org/model/resolve/main/model.bin?oid=" + oid, digest: oid},
+		{url: "oci://registry.example.com/v2/library/model/blobs/sha256:" + strings.ToUpper(oid), digest: oid},
+		{url: "s3://bucket/models/model.bin", digest: ""},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		require.Nil(t, err)
+		assert.Equal(t, tt.digest, contentDigest(u), tt.url)
+	}
+}
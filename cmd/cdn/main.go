@@ -20,7 +20,10 @@ import (
 	_ "d7y.io/dragonfly/v2/cdn/supervisor/cdn/storage/disk"   //nolint:gci    // Register disk storage manager
 	_ "d7y.io/dragonfly/v2/cdn/supervisor/cdn/storage/hybrid" // Register hybrid storage manager
 	_ "d7y.io/dragonfly/v2/pkg/source/httpprotocol"           // Register http client
+	_ "d7y.io/dragonfly/v2/pkg/source/lfsprotocol"            // Register git lfs and huggingface client
+	_ "d7y.io/dragonfly/v2/pkg/source/ociprotocol"            // Register oci client
 	_ "d7y.io/dragonfly/v2/pkg/source/ossprotocol"            // Register oss client
+	_ "d7y.io/dragonfly/v2/pkg/source/s3protocol"             // Register s3 client
 
 	"d7y.io/dragonfly/v2/cmd/cdn/cmd" //nolint:gci
 )
@@ -160,6 +160,20 @@ func SetupQuitSignalHandler(handler func()) {
 	}()
 }
 
+// SetupDrainSignalHandler calls handler when receiving SIGUSR2, the handler is called only once
+func SetupDrainSignalHandler(handler func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2)
+
+	go func() {
+		sig := <-signals
+		logger.Warnf("receive signal: %v", sig)
+		signal.Stop(signals)
+		handler()
+		logger.Warnf("handle signal: %v finish", sig)
+	}()
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig(useConfigFile bool, name string, config interface{}) {
 	// Use config file and read once.
@@ -178,5 +178,6 @@ func runDaemon(d dfpath.Dfpath) error {
 		return err
 	}
 	dependency.SetupQuitSignalHandler(func() { svr.Stop() })
+	dependency.SetupDrainSignalHandler(func() { svr.Drain() })
 	return svr.Serve()
 }
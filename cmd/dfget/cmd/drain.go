@@ -0,0 +1,54 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"d7y.io/dragonfly/v2/client/daemon/status"
+)
+
+// drainCmd represents the drain command
+var drainCmd = &cobra.Command{
+	Use:   "drain",
+	Short: "drain the client daemon before maintenance",
+	Long: `reject new downloads of the client daemon, make its peers leave schedulers and exit after the in-flight uploads are finished,
+the max waiting duration is drain.timeout in the daemon configuration, draining can also be triggered by sending SIGUSR2 to the daemon.`,
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := initDfgetDfpath(dfgetConfig)
+		if err != nil {
+			return err
+		}
+
+		if err = status.Drain(context.Background(), d.DaemonStatusSockPath()); err != nil {
+			return errors.Wrap(err, "drain daemon")
+		}
+		fmt.Println("daemon is draining")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(drainCmd)
+}
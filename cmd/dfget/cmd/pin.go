@@ -0,0 +1,104 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"d7y.io/dragonfly/v2/client/daemon/status"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+)
+
+type pinOption struct {
+	taskID string
+	tag    string
+	filter string
+	digest string
+}
+
+var (
+	pinOpt   pinOption
+	unpinOpt pinOption
+)
+
+// pinCmd represents the pin command
+var pinCmd = &cobra.Command{
+	Use:               "pin [url]",
+	Short:             "pin the task in the client daemon storage, pinned tasks are skipped by gc",
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPin(args, &pinOpt, true)
+	},
+}
+
+// unpinCmd represents the unpin command
+var unpinCmd = &cobra.Command{
+	Use:               "unpin [url]",
+	Short:             "unpin the task in the client daemon storage, unpinned tasks can be reclaimed by gc",
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPin(args, &unpinOpt, false)
+	},
+}
+
+func init() {
+	for cmd, opt := range map[*cobra.Command]*pinOption{pinCmd: &pinOpt, unpinCmd: &unpinOpt} {
+		rootCmd.AddCommand(cmd)
+
+		flags := cmd.Flags()
+		flags.StringVar(&opt.taskID, "task-id", "", "The task id to operate, it conflicts with url")
+		flags.StringVar(&opt.tag, "tag", "", "The tag of the url when the task was downloaded")
+		flags.StringVar(&opt.filter, "filter", "", "The filter of the url when the task was downloaded")
+		flags.StringVar(&opt.digest, "digest", "", "The digest of the url when the task was downloaded")
+	}
+}
+
+func runPin(args []string, opt *pinOption, pinned bool) error {
+	taskID := opt.taskID
+	switch {
+	case len(args) == 1 && taskID != "":
+		return errors.New("url conflicts with --task-id")
+	case len(args) == 1:
+		taskID = idgen.TaskID(args[0], &base.UrlMeta{
+			Digest: opt.digest,
+			Tag:    opt.tag,
+			Filter: opt.filter,
+		})
+	case taskID == "":
+		return errors.New("url or --task-id is required")
+	}
+
+	d, err := initDfgetDfpath(dfgetConfig)
+	if err != nil {
+		return err
+	}
+
+	if err = status.PinTask(context.Background(), d.DaemonStatusSockPath(), taskID, pinned); err != nil {
+		return errors.Wrapf(err, "pin task %s", taskID)
+	}
+	fmt.Printf("task %s pinned: %t\n", taskID, pinned)
+	return nil
+}
@@ -157,6 +157,9 @@ func init() {
 	flagSet.Bool("disable-back-source", dfgetConfig.DisableBackSource,
 		"Disable downloading directly from source when the daemon fails to download file")
 
+	flagSet.Bool("resume", dfgetConfig.Resume,
+		"Resume into the existing output file, only the missing or invalid pieces are downloaded")
+
 	flagSet.StringP("pattern", "p", dfgetConfig.Pattern, "The downloading pattern: p2p/cdn/source")
 
 	flagSet.BoolP("show-progress", "b", dfgetConfig.ShowProgress, "Show progress bar, it conflicts with --console")
@@ -182,6 +185,15 @@ func init() {
 	flagSet.String("reject-regex", dfgetConfig.RecursiveRejectRegex,
 		`Recursively download only. Specify a regular expression to reject the complete URL. In this case, you have to enclose the pattern into quotes to prevent your shell from expanding it`)
 
+	flagSet.Bool("directory", dfgetConfig.RecursiveDirectory,
+		"Recursively download only. Download all resources as one directory task, the output directory is replaced only when all files succeed")
+
+	flagSet.Int("concurrency", dfgetConfig.RecursiveConcurrency,
+		"Directory task only. The number of files downloaded in parallel")
+
+	flagSet.String("report", dfgetConfig.RecursiveReport,
+		"Directory task only. The path to write json report of files, sizes, digests and sources, print to stdout when it's empty")
+
 	// Bind cmd flags
 	if err := viper.BindPFlags(flagSet); err != nil {
 		panic(errors.Wrap(err, "bind dfget flags to viper"))
@@ -0,0 +1,130 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"d7y.io/dragonfly/v2/client/daemon/status"
+	"d7y.io/dragonfly/v2/pkg/unit"
+)
+
+var (
+	statusInterval time.Duration
+	statusOnce     bool
+	statusJSON     bool
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:               "status",
+	Aliases:           []string{"top"},
+	Short:             "show the running and cached tasks of the client daemon",
+	Long:              `query the local status api of the client daemon and render the running peer tasks and cached tasks live.`,
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := initDfgetDfpath(dfgetConfig)
+		if err != nil {
+			return err
+		}
+
+		sockPath := d.DaemonStatusSockPath()
+		if statusOnce || statusJSON {
+			s, err := status.GetStatus(context.Background(), sockPath)
+			if err != nil {
+				return errors.Wrap(err, "query daemon status")
+			}
+			if statusJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(s)
+			}
+			renderStatus(os.Stdout, s)
+			return nil
+		}
+
+		ticker := time.NewTicker(statusInterval)
+		defer ticker.Stop()
+		for {
+			s, err := status.GetStatus(context.Background(), sockPath)
+			if err != nil {
+				return errors.Wrap(err, "query daemon status")
+			}
+			// clear screen and move cursor to top left
+			fmt.Print("\033[H\033[2J")
+			renderStatus(os.Stdout, s)
+			<-ticker.C
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	flags := statusCmd.Flags()
+	flags.DurationVarP(&statusInterval, "interval", "n", time.Second, "Refresh interval of the live view")
+	flags.BoolVar(&statusOnce, "once", false, "Print the status once and exit")
+	flags.BoolVar(&statusJSON, "json", false, "Print the raw status in json format once and exit")
+}
+
+func renderStatus(out io.Writer, s *status.Status) {
+	fmt.Fprintf(out, "dfdaemon status at %s, running: %d, cached: %d\n\n",
+		s.Time.Format("2006-01-02 15:04:05"), len(s.Running), len(s.Cached))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TASK\tPEER\tPROGRESS\tPIECES\tSIZE\tRATE\tSOURCE\tPARENTS")
+	for _, t := range s.Running {
+		progress := "-"
+		if t.ContentLength > 0 {
+			progress = fmt.Sprintf("%.1f%%", float64(t.CompletedLength)*100/float64(t.ContentLength))
+		}
+		src := "p2p"
+		if t.BackSource {
+			src = fmt.Sprintf("back-source(%s)", unit.Bytes(t.BackSourceTraffic))
+		}
+		var parents []string
+		for _, p := range t.Parents {
+			parents = append(parents, fmt.Sprintf("%s(%s)", p.PeerID, unit.Bytes(p.Traffic)))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%s\t%s/s\t%s\t%s\n",
+			t.TaskID, t.PeerID, progress, t.ReadyPieces, t.TotalPieces,
+			unit.Bytes(t.ContentLength), unit.Bytes(t.Rate), src, strings.Join(parents, ","))
+	}
+	_ = w.Flush()
+
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TASK\tPEER\tSIZE\tPIECES\tDONE\tPINNED\tLAST ACCESS")
+	for _, t := range s.Cached {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%t\t%t\t%s\n",
+			t.TaskID, t.PeerID, unit.Bytes(t.ContentLength), t.TotalPieces, t.Done, t.Pinned,
+			t.LastAccess.Format("2006-01-02 15:04:05"))
+	}
+	_ = w.Flush()
+}
@@ -24,6 +24,15 @@ import (
 
 	// Register oss client
 	_ "d7y.io/dragonfly/v2/pkg/source/ossprotocol"
+
+	// Register git lfs and huggingface client
+	_ "d7y.io/dragonfly/v2/pkg/source/lfsprotocol"
+
+	// Register oci client
+	_ "d7y.io/dragonfly/v2/pkg/source/ociprotocol"
+
+	// Register s3 client
+	_ "d7y.io/dragonfly/v2/pkg/source/s3protocol"
 )
 
 func main() {
@@ -31,20 +31,22 @@ type Dfpath interface {
 	DataDir() string
 	PluginDir() string
 	DaemonSockPath() string
+	DaemonStatusSockPath() string
 	DaemonLockPath() string
 	DfgetLockPath() string
 }
 
 // Dfpath provides init project path function
 type dfpath struct {
-	workHome       string
-	cacheDir       string
-	logDir         string
-	dataDir        string
-	pluginDir      string
-	daemonSockPath string
-	daemonLockPath string
-	dfgetLockPath  string
+	workHome             string
+	cacheDir             string
+	logDir               string
+	dataDir              string
+	pluginDir            string
+	daemonSockPath       string
+	daemonStatusSockPath string
+	daemonLockPath       string
+	dfgetLockPath        string
 }
 
 // Cache of the dfpath
@@ -101,6 +103,7 @@ func New(options ...Option) (Dfpath, error) {
 
 		d.pluginDir = filepath.Join(d.workHome, "plugins")
 		d.daemonSockPath = filepath.Join(d.workHome, "daemon.sock")
+		d.daemonStatusSockPath = filepath.Join(d.workHome, "daemon-status.sock")
 		d.daemonLockPath = filepath.Join(d.workHome, "daemon.lock")
 		d.dfgetLockPath = filepath.Join(d.workHome, "dfget.lock")
 
@@ -146,6 +149,10 @@ func (d *dfpath) DaemonSockPath() string {
 	return d.daemonSockPath
 }
 
+func (d *dfpath) DaemonStatusSockPath() string {
+	return d.daemonStatusSockPath
+}
+
 func (d *dfpath) DaemonLockPath() string {
 	return d.daemonLockPath
 }
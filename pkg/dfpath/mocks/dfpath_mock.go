@@ -75,6 +75,20 @@ func (mr *MockDfpathMockRecorder) DaemonSockPath() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DaemonSockPath", reflect.TypeOf((*MockDfpath)(nil).DaemonSockPath))
 }
 
+// DaemonStatusSockPath mocks base method.
+func (m *MockDfpath) DaemonStatusSockPath() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DaemonStatusSockPath")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// DaemonStatusSockPath indicates an expected call of DaemonStatusSockPath.
+func (mr *MockDfpathMockRecorder) DaemonStatusSockPath() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DaemonStatusSockPath", reflect.TypeOf((*MockDfpath)(nil).DaemonStatusSockPath))
+}
+
 // DataDir mocks base method.
 func (m *MockDfpath) DataDir() string {
 	m.ctrl.T.Helper()
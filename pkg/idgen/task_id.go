@@ -17,6 +17,7 @@
 package idgen
 
 import (
+	neturl "net/url"
 	"strings"
 
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
@@ -24,6 +25,37 @@ import (
 	"d7y.io/dragonfly/v2/pkg/util/net/urlutils"
 )
 
+const (
+	// lfsOIDQuery is the url query of git lfs object id
+	lfsOIDQuery = "oid"
+)
+
+// lfsSchemes are the schemes of git lfs objects, their task ids are keyed by the repository and lfs oid,
+// so the same object in different revisions of a repository shares one task
+var lfsSchemes = map[string]bool{
+	"lfs": true,
+	"hf":  true,
+}
+
+// lfsResolveSegment separates the repository and the revision in huggingface url
+const lfsResolveSegment = "/resolve/"
+
+// taskURL returns the url part of task id
+func taskURL(url string, filters []string) string {
+	if u, err := neturl.Parse(url); err == nil && lfsSchemes[u.Scheme] {
+		if oid := u.Query().Get(lfsOIDQuery); oid != "" {
+			repository := u.Path
+			if idx := strings.Index(repository, lfsResolveSegment); idx >= 0 {
+				repository = repository[:idx]
+			}
+			repository = strings.TrimSuffix(strings.Trim(repository, "/"), ".git")
+			// the object is scoped by repository, so a url with the oid of another repository can not share its task
+			return "lfs:" + u.Host + "/" + repository + ":" + oid
+		}
+	}
+	return urlutils.FilterURLParam(url, filters)
+}
+
 func taskID(url string, meta *base.UrlMeta, ignoreRange bool) string {
 	var filters []string
 	if meta != nil && meta.Filter != "" {
@@ -31,7 +63,7 @@ func taskID(url string, meta *base.UrlMeta, ignoreRange bool) string {
 	}
 
 	var data []string
-	data = append(data, urlutils.FilterURLParam(url, filters))
+	data = append(data, taskURL(url, filters))
 	if meta != nil {
 		if meta.Digest != "" {
 			data = append(data, meta.Digest)
@@ -80,6 +80,19 @@ func TestTaskID(t *testing.T) {
 				assert.Equal("2773851c628744fb7933003195db436ce397c1722920696c4274ff804d86920b", d)
 			},
 		},
+		{
+			name: "generate taskID with lfs oid",
+			url:  "hf://huggingface.co/gpt2/resolve/main/model.bin?oid=248dfc3911869ec493c76e65bf2fcf7f615828b0254c12b473182f0f81d3a707",
+			meta: nil,
+			expect: func(t *testing.T, d interface{}) {
+				assert := assert.New(t)
+				assert.Equal(TaskID("hf://huggingface.co/gpt2/resolve/v1/model-v1.bin?oid=248dfc3911869ec493c76e65bf2fcf7f615828b0254c12b473182f0f81d3a707", nil), d)
+				assert.Equal(TaskID("lfs://huggingface.co/gpt2.git?oid=248dfc3911869ec493c76e65bf2fcf7f615828b0254c12b473182f0f81d3a707&size=1", nil), d)
+				assert.NotEqual(TaskID("hf://huggingface.co/gpt3/resolve/main/model.bin?oid=248dfc3911869ec493c76e65bf2fcf7f615828b0254c12b473182f0f81d3a707", nil), d)
+				assert.NotEqual(TaskID("lfs://github.com/org/repo?oid=248dfc3911869ec493c76e65bf2fcf7f615828b0254c12b473182f0f81d3a707&size=1", nil), d)
+				assert.NotEqual(TaskID("hf://huggingface.co/gpt2/resolve/main/model.bin", nil), d)
+			},
+		},
 		{
 			name: "generate taskID with tag",
 			url:  "https://example.com",
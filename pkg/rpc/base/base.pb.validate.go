@@ -431,6 +431,15 @@ func (m *PieceInfo) Validate() error {
 
 	// no validation rules for PieceStyle
 
+	if _, ok := DigestAlgorithm_name[int32(m.GetDigestAlgorithm())]; !ok {
+		return PieceInfoValidationError{
+			field:  "DigestAlgorithm",
+			reason: "value must be one of the defined enum values",
+		}
+	}
+
+	// no validation rules for PieceDigest
+
 	return nil
 }
 
@@ -540,6 +549,10 @@ func (m *PiecePacket) Validate() error {
 
 	// no validation rules for PieceMd5Sign
 
+	// no validation rules for DstRpcAddr
+
+	// no validation rules for PieceDigestSign
+
 	return nil
 }
 
@@ -50,10 +50,15 @@ type proxy struct {
 
 func New(seederServer SeederServer, opts ...grpc.ServerOption) *grpc.Server {
 	grpcServer := grpc.NewServer(append(rpc.DefaultServerOptions, opts...)...)
-	cdnsystem.RegisterSeederServer(grpcServer, &proxy{server: seederServer})
+	Register(grpcServer, seederServer)
 	return grpcServer
 }
 
+// Register registers the seeder service to an existing grpc server, like the peer grpc server of dfdaemon
+func Register(grpcServer *grpc.Server, seederServer SeederServer) {
+	cdnsystem.RegisterSeederServer(grpcServer, &proxy{server: seederServer})
+}
+
 func (p *proxy) ObtainSeeds(sr *cdnsystem.SeedRequest, stream cdnsystem.Seeder_ObtainSeedsServer) (err error) {
 	metrics.DownloadCount.Inc()
 	metrics.ConcurrentDownloadGauge.Inc()
@@ -286,6 +286,17 @@ func (conn *Connection) GetServerNode(hashKey string) (string, bool) {
 	return "unknown", false
 }
 
+// ServerNodes returns the endpoints of all server nodes
+func (conn *Connection) ServerNodes() []string {
+	conn.rwMutex.RLock()
+	defer conn.rwMutex.RUnlock()
+	var nodes []string
+	for _, addr := range conn.serverNodes {
+		nodes = append(nodes, addr.GetEndpoint())
+	}
+	return nodes
+}
+
 func (conn *Connection) GetClientConnByTarget(node string) (*grpc.ClientConn, error) {
 	logger.GrpcLogger.With("conn", conn.name).Debugf("start to get client conn by target %s", node)
 	conn.rwMutex.RLock()
@@ -77,6 +77,8 @@ type DaemonClient interface {
 
 	CheckHealth(ctx context.Context, target dfnet.NetAddr, opts ...grpc.CallOption) error
 
+	DownloadPiece(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.DownloadPieceRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_DownloadPieceClient, error)
+
 	Close() error
 }
 
@@ -137,3 +139,17 @@ func (dc *daemonClient) CheckHealth(ctx context.Context, target dfnet.NetAddr, o
 	}
 	return
 }
+
+func (dc *daemonClient) DownloadPiece(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.DownloadPieceRequest, opts ...grpc.CallOption) (
+	dfdaemon.Daemon_DownloadPieceClient, error) {
+	client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+	}
+	stream, err := client.DownloadPiece(ctx, req, opts...)
+	if err != nil {
+		logger.WithTaskID(req.TaskId).Infof("DownloadPiece: invoke daemon node %s DownloadPiece failed: %v", target, err)
+		return nil, err
+	}
+	return stream, nil
+}
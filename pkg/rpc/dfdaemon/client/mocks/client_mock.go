@@ -92,6 +92,26 @@ func (mr *MockDaemonClientMockRecorder) Download(ctx, req interface{}, opts ...i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockDaemonClient)(nil).Download), varargs...)
 }
 
+// DownloadPiece mocks base method.
+func (m *MockDaemonClient) DownloadPiece(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.DownloadPieceRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_DownloadPieceClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, target, req}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DownloadPiece", varargs...)
+	ret0, _ := ret[0].(dfdaemon.Daemon_DownloadPieceClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadPiece indicates an expected call of DownloadPiece.
+func (mr *MockDaemonClientMockRecorder) DownloadPiece(ctx, target, req interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, target, req}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadPiece", reflect.TypeOf((*MockDaemonClient)(nil).DownloadPiece), varargs...)
+}
+
 // GetPieceTasks mocks base method.
 func (m *MockDaemonClient) GetPieceTasks(ctx context.Context, addr dfnet.NetAddr, ptr *base.PieceTaskRequest, opts ...grpc.CallOption) (*base.PiecePacket, error) {
 	m.ctrl.T.Helper()
@@ -28,6 +28,7 @@ import (
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/rpc/base/common"
 	cdnclient "d7y.io/dragonfly/v2/pkg/rpc/cdnsystem/client"
+	"d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
 )
 
@@ -50,6 +51,19 @@ func GetPieceTasks(ctx context.Context, destPeer *scheduler.PeerPacket_DestPeer,
 	return client.(DaemonClient).GetPieceTasks(ctx, netAddr, ptr, opts...)
 }
 
+// DownloadPiece downloads piece content from the grpc server of dst peer, destAddr is like ip:port
+func DownloadPiece(ctx context.Context, destAddr string, req *dfdaemon.DownloadPieceRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_DownloadPieceClient, error) {
+	netAddr := dfnet.NetAddr{
+		Type: dfnet.TCP,
+		Addr: destAddr,
+	}
+	client, err := GetElasticClientByAddrs([]dfnet.NetAddr{netAddr})
+	if err != nil {
+		return nil, err
+	}
+	return client.DownloadPiece(ctx, netAddr, req, opts...)
+}
+
 func getClient(netAddr dfnet.NetAddr, toCdn bool) (rpc.Closer, error) {
 	if toCdn {
 		return cdnclient.GetElasticClientByAddrs([]dfnet.NetAddr{netAddr})
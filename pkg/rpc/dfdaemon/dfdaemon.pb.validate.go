@@ -115,6 +115,8 @@ func (m *DownRequest) Validate() error {
 
 	// no validation rules for Gid
 
+	// no validation rules for ResumeOutput
+
 	return nil
 }
 
@@ -272,3 +274,177 @@ var _ interface {
 	Cause() error
 	ErrorName() string
 } = DownResultValidationError{}
+
+// Validate checks the field values on DownloadPieceRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *DownloadPieceRequest) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	if utf8.RuneCountInString(m.GetTaskId()) < 1 {
+		return DownloadPieceRequestValidationError{
+			field:  "TaskId",
+			reason: "value length must be at least 1 runes",
+		}
+	}
+
+	if utf8.RuneCountInString(m.GetSrcPid()) < 1 {
+		return DownloadPieceRequestValidationError{
+			field:  "SrcPid",
+			reason: "value length must be at least 1 runes",
+		}
+	}
+
+	if utf8.RuneCountInString(m.GetDstPid()) < 1 {
+		return DownloadPieceRequestValidationError{
+			field:  "DstPid",
+			reason: "value length must be at least 1 runes",
+		}
+	}
+
+	if m.GetPieceNum() < 0 {
+		return DownloadPieceRequestValidationError{
+			field:  "PieceNum",
+			reason: "value must be greater than or equal to 0",
+		}
+	}
+
+	return nil
+}
+
+// DownloadPieceRequestValidationError is the validation error returned by
+// DownloadPieceRequest.Validate if the designated constraints aren't met.
+type DownloadPieceRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DownloadPieceRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DownloadPieceRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DownloadPieceRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DownloadPieceRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DownloadPieceRequestValidationError) ErrorName() string {
+	return "DownloadPieceRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DownloadPieceRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDownloadPieceRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DownloadPieceRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DownloadPieceRequestValidationError{}
+
+// Validate checks the field values on DownloadPieceResult with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *DownloadPieceResult) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	if v, ok := interface{}(m.GetPieceInfo()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return DownloadPieceResultValidationError{
+				field:  "PieceInfo",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Content
+
+	return nil
+}
+
+// DownloadPieceResultValidationError is the validation error returned by
+// DownloadPieceResult.Validate if the designated constraints aren't met.
+type DownloadPieceResultValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DownloadPieceResultValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DownloadPieceResultValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DownloadPieceResultValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DownloadPieceResultValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DownloadPieceResultValidationError) ErrorName() string {
+	return "DownloadPieceResultValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DownloadPieceResultValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDownloadPieceResult.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DownloadPieceResultValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DownloadPieceResultValidationError{}
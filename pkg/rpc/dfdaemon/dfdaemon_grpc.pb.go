@@ -26,6 +26,8 @@ type DaemonClient interface {
 	GetPieceTasks(ctx context.Context, in *base.PieceTaskRequest, opts ...grpc.CallOption) (*base.PiecePacket, error)
 	// Check daemon health
 	CheckHealth(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Download piece content from other peers
+	DownloadPiece(ctx context.Context, in *DownloadPieceRequest, opts ...grpc.CallOption) (Daemon_DownloadPieceClient, error)
 }
 
 type daemonClient struct {
@@ -86,6 +88,38 @@ func (c *daemonClient) CheckHealth(ctx context.Context, in *emptypb.Empty, opts
 	return out, nil
 }
 
+func (c *daemonClient) DownloadPiece(ctx context.Context, in *DownloadPieceRequest, opts ...grpc.CallOption) (Daemon_DownloadPieceClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Daemon_ServiceDesc.Streams[1], "/dfdaemon.Daemon/DownloadPiece", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonDownloadPieceClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_DownloadPieceClient interface {
+	Recv() (*DownloadPieceResult, error)
+	grpc.ClientStream
+}
+
+type daemonDownloadPieceClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonDownloadPieceClient) Recv() (*DownloadPieceResult, error) {
+	m := new(DownloadPieceResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // DaemonServer is the server API for Daemon service.
 // All implementations must embed UnimplementedDaemonServer
 // for forward compatibility
@@ -96,6 +130,8 @@ type DaemonServer interface {
 	GetPieceTasks(context.Context, *base.PieceTaskRequest) (*base.PiecePacket, error)
 	// Check daemon health
 	CheckHealth(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// Download piece content from other peers
+	DownloadPiece(*DownloadPieceRequest, Daemon_DownloadPieceServer) error
 	mustEmbedUnimplementedDaemonServer()
 }
 
@@ -112,6 +148,9 @@ func (UnimplementedDaemonServer) GetPieceTasks(context.Context, *base.PieceTaskR
 func (UnimplementedDaemonServer) CheckHealth(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CheckHealth not implemented")
 }
+func (UnimplementedDaemonServer) DownloadPiece(*DownloadPieceRequest, Daemon_DownloadPieceServer) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadPiece not implemented")
+}
 func (UnimplementedDaemonServer) mustEmbedUnimplementedDaemonServer() {}
 
 // UnsafeDaemonServer may be embedded to opt out of forward compatibility for this service.
@@ -182,6 +221,27 @@ func _Daemon_CheckHealth_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_DownloadPiece_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadPieceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).DownloadPiece(m, &daemonDownloadPieceServer{stream})
+}
+
+type Daemon_DownloadPieceServer interface {
+	Send(*DownloadPieceResult) error
+	grpc.ServerStream
+}
+
+type daemonDownloadPieceServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonDownloadPieceServer) Send(m *DownloadPieceResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Daemon_ServiceDesc is the grpc.ServiceDesc for Daemon service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -204,6 +264,11 @@ var Daemon_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Daemon_Download_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "DownloadPiece",
+			Handler:       _Daemon_DownloadPiece_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "pkg/rpc/dfdaemon/dfdaemon.proto",
 }
@@ -79,6 +79,26 @@ func (mr *MockDaemonClientMockRecorder) Download(ctx, in interface{}, opts ...in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockDaemonClient)(nil).Download), varargs...)
 }
 
+// DownloadPiece mocks base method.
+func (m *MockDaemonClient) DownloadPiece(ctx context.Context, in *dfdaemon.DownloadPieceRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_DownloadPieceClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DownloadPiece", varargs...)
+	ret0, _ := ret[0].(dfdaemon.Daemon_DownloadPieceClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadPiece indicates an expected call of DownloadPiece.
+func (mr *MockDaemonClientMockRecorder) DownloadPiece(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadPiece", reflect.TypeOf((*MockDaemonClient)(nil).DownloadPiece), varargs...)
+}
+
 // GetPieceTasks mocks base method.
 func (m *MockDaemonClient) GetPieceTasks(ctx context.Context, in *base.PieceTaskRequest, opts ...grpc.CallOption) (*base.PiecePacket, error) {
 	m.ctrl.T.Helper()
@@ -222,6 +242,129 @@ func (mr *MockDaemon_DownloadClientMockRecorder) Trailer() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockDaemon_DownloadClient)(nil).Trailer))
 }
 
+// MockDaemon_DownloadPieceClient is a mock of Daemon_DownloadPieceClient interface.
+type MockDaemon_DownloadPieceClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockDaemon_DownloadPieceClientMockRecorder
+}
+
+// MockDaemon_DownloadPieceClientMockRecorder is the mock recorder for MockDaemon_DownloadPieceClient.
+type MockDaemon_DownloadPieceClientMockRecorder struct {
+	mock *MockDaemon_DownloadPieceClient
+}
+
+// NewMockDaemon_DownloadPieceClient creates a new mock instance.
+func NewMockDaemon_DownloadPieceClient(ctrl *gomock.Controller) *MockDaemon_DownloadPieceClient {
+	mock := &MockDaemon_DownloadPieceClient{ctrl: ctrl}
+	mock.recorder = &MockDaemon_DownloadPieceClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDaemon_DownloadPieceClient) EXPECT() *MockDaemon_DownloadPieceClientMockRecorder {
+	return m.recorder
+}
+
+// CloseSend mocks base method.
+func (m *MockDaemon_DownloadPieceClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend.
+func (mr *MockDaemon_DownloadPieceClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockDaemon_DownloadPieceClient)(nil).CloseSend))
+}
+
+// Context mocks base method.
+func (m *MockDaemon_DownloadPieceClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context.
+func (mr *MockDaemon_DownloadPieceClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockDaemon_DownloadPieceClient)(nil).Context))
+}
+
+// Header mocks base method.
+func (m *MockDaemon_DownloadPieceClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header.
+func (mr *MockDaemon_DownloadPieceClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockDaemon_DownloadPieceClient)(nil).Header))
+}
+
+// Recv mocks base method.
+func (m *MockDaemon_DownloadPieceClient) Recv() (*dfdaemon.DownloadPieceResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*dfdaemon.DownloadPieceResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv.
+func (mr *MockDaemon_DownloadPieceClientMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockDaemon_DownloadPieceClient)(nil).Recv))
+}
+
+// RecvMsg mocks base method.
+func (m_2 *MockDaemon_DownloadPieceClient) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg.
+func (mr *MockDaemon_DownloadPieceClientMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockDaemon_DownloadPieceClient)(nil).RecvMsg), m)
+}
+
+// SendMsg mocks base method.
+func (m_2 *MockDaemon_DownloadPieceClient) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg.
+func (mr *MockDaemon_DownloadPieceClientMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockDaemon_DownloadPieceClient)(nil).SendMsg), m)
+}
+
+// Trailer mocks base method.
+func (m *MockDaemon_DownloadPieceClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer.
+func (mr *MockDaemon_DownloadPieceClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockDaemon_DownloadPieceClient)(nil).Trailer))
+}
+
 // MockDaemonServer is a mock of DaemonServer interface.
 type MockDaemonServer struct {
 	ctrl     *gomock.Controller
@@ -274,6 +417,20 @@ func (mr *MockDaemonServerMockRecorder) Download(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockDaemonServer)(nil).Download), arg0, arg1)
 }
 
+// DownloadPiece mocks base method.
+func (m *MockDaemonServer) DownloadPiece(arg0 *dfdaemon.DownloadPieceRequest, arg1 dfdaemon.Daemon_DownloadPieceServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadPiece", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadPiece indicates an expected call of DownloadPiece.
+func (mr *MockDaemonServerMockRecorder) DownloadPiece(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadPiece", reflect.TypeOf((*MockDaemonServer)(nil).DownloadPiece), arg0, arg1)
+}
+
 // GetPieceTasks mocks base method.
 func (m *MockDaemonServer) GetPieceTasks(arg0 context.Context, arg1 *base.PieceTaskRequest) (*base.PiecePacket, error) {
 	m.ctrl.T.Helper()
@@ -454,3 +611,122 @@ func (mr *MockDaemon_DownloadServerMockRecorder) SetTrailer(arg0 interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockDaemon_DownloadServer)(nil).SetTrailer), arg0)
 }
+
+// MockDaemon_DownloadPieceServer is a mock of Daemon_DownloadPieceServer interface.
+type MockDaemon_DownloadPieceServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockDaemon_DownloadPieceServerMockRecorder
+}
+
+// MockDaemon_DownloadPieceServerMockRecorder is the mock recorder for MockDaemon_DownloadPieceServer.
+type MockDaemon_DownloadPieceServerMockRecorder struct {
+	mock *MockDaemon_DownloadPieceServer
+}
+
+// NewMockDaemon_DownloadPieceServer creates a new mock instance.
+func NewMockDaemon_DownloadPieceServer(ctrl *gomock.Controller) *MockDaemon_DownloadPieceServer {
+	mock := &MockDaemon_DownloadPieceServer{ctrl: ctrl}
+	mock.recorder = &MockDaemon_DownloadPieceServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDaemon_DownloadPieceServer) EXPECT() *MockDaemon_DownloadPieceServerMockRecorder {
+	return m.recorder
+}
+
+// Context mocks base method.
+func (m *MockDaemon_DownloadPieceServer) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context.
+func (mr *MockDaemon_DownloadPieceServerMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockDaemon_DownloadPieceServer)(nil).Context))
+}
+
+// RecvMsg mocks base method.
+func (m_2 *MockDaemon_DownloadPieceServer) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg.
+func (mr *MockDaemon_DownloadPieceServerMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockDaemon_DownloadPieceServer)(nil).RecvMsg), m)
+}
+
+// Send mocks base method.
+func (m *MockDaemon_DownloadPieceServer) Send(arg0 *dfdaemon.DownloadPieceResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockDaemon_DownloadPieceServerMockRecorder) Send(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockDaemon_DownloadPieceServer)(nil).Send), arg0)
+}
+
+// SendHeader mocks base method.
+func (m *MockDaemon_DownloadPieceServer) SendHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHeader indicates an expected call of SendHeader.
+func (mr *MockDaemon_DownloadPieceServerMockRecorder) SendHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHeader", reflect.TypeOf((*MockDaemon_DownloadPieceServer)(nil).SendHeader), arg0)
+}
+
+// SendMsg mocks base method.
+func (m_2 *MockDaemon_DownloadPieceServer) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg.
+func (mr *MockDaemon_DownloadPieceServerMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockDaemon_DownloadPieceServer)(nil).SendMsg), m)
+}
+
+// SetHeader mocks base method.
+func (m *MockDaemon_DownloadPieceServer) SetHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHeader indicates an expected call of SetHeader.
+func (mr *MockDaemon_DownloadPieceServerMockRecorder) SetHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHeader", reflect.TypeOf((*MockDaemon_DownloadPieceServer)(nil).SetHeader), arg0)
+}
+
+// SetTrailer mocks base method.
+func (m *MockDaemon_DownloadPieceServer) SetTrailer(arg0 metadata.MD) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTrailer", arg0)
+}
+
+// SetTrailer indicates an expected call of SetTrailer.
+func (mr *MockDaemon_DownloadPieceServerMockRecorder) SetTrailer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockDaemon_DownloadPieceServer)(nil).SetTrailer), arg0)
+}
@@ -40,6 +40,8 @@ type DaemonServer interface {
 	GetPieceTasks(context.Context, *base.PieceTaskRequest) (*base.PiecePacket, error)
 	// Check daemon health
 	CheckHealth(context.Context) error
+	// Download piece content from other peers
+	DownloadPiece(*dfdaemon.DownloadPieceRequest, dfdaemon.Daemon_DownloadPieceServer) error
 }
 
 type proxy struct {
@@ -93,6 +95,10 @@ func (p *proxy) CheckHealth(ctx context.Context, req *emptypb.Empty) (*emptypb.E
 	return new(emptypb.Empty), p.server.CheckHealth(ctx)
 }
 
+func (p *proxy) DownloadPiece(req *dfdaemon.DownloadPieceRequest, stream dfdaemon.Daemon_DownloadPieceServer) error {
+	return p.server.DownloadPiece(req, stream)
+}
+
 func send(drc chan *dfdaemon.DownResult, closeDrc func(), stream dfdaemon.Daemon_DownloadServer, errChan chan error) {
 	err := safe.Call(func() {
 		defer closeDrc()
@@ -58,6 +58,12 @@ type SchedulerClient interface {
 
 	LeaveTask(context.Context, *scheduler.PeerTarget, ...grpc.CallOption) error
 
+	// AnnounceHost announces host and its cached tasks to all schedulers
+	AnnounceHost(context.Context, *scheduler.AnnounceHostRequest, ...grpc.CallOption) error
+
+	// LeaveHost makes host and all its peers leaving from all schedulers
+	LeaveHost(context.Context, *scheduler.LeaveHostRequest, ...grpc.CallOption) error
+
 	UpdateState(addrs []dfnet.NetAddr)
 
 	Close() error
@@ -240,4 +246,52 @@ func (sc *schedulerClient) LeaveTask(ctx context.Context, pt *scheduler.PeerTarg
 	return
 }
 
+func (sc *schedulerClient) AnnounceHost(ctx context.Context, req *scheduler.AnnounceHostRequest, opts ...grpc.CallOption) error {
+	// every scheduler manages the tasks hashed to it, so the host is announced to all of them
+	return sc.broadcast(func(client scheduler.SchedulerClient) error {
+		_, err := client.AnnounceHost(ctx, req, opts...)
+		return err
+	})
+}
+
+func (sc *schedulerClient) LeaveHost(ctx context.Context, req *scheduler.LeaveHostRequest, opts ...grpc.CallOption) (err error) {
+	defer func() {
+		logger.With("hostId", req.Uuid, "errMsg", err).Infof("leave host result: %t", err == nil)
+	}()
+	return sc.broadcast(func(client scheduler.SchedulerClient) error {
+		_, err := client.LeaveHost(ctx, req, opts...)
+		return err
+	})
+}
+
+// broadcast calls all schedulers, it returns the last error when any of them failed
+func (sc *schedulerClient) broadcast(call func(client scheduler.SchedulerClient) error) error {
+	var lastErr error
+	for _, node := range sc.Connection.ServerNodes() {
+		clientConn, err := sc.Connection.GetClientConnByTarget(node)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var unimplemented error
+		_, err = rpc.ExecuteWithRetry(func() (interface{}, error) {
+			err := call(scheduler.NewSchedulerClient(clientConn))
+			// the scheduler of old version does not support the call, retry is useless
+			if status.Code(err) == codes.Unimplemented {
+				unimplemented = err
+				return nil, nil
+			}
+			return nil, err
+		}, 0.2, 2.0, 3, nil)
+		if unimplemented != nil {
+			err = unimplemented
+		}
+		if err != nil {
+			logger.Warnf("call scheduler %s failed: %v", node, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
 var _ SchedulerClient = (*schedulerClient)(nil)
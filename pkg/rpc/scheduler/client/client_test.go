@@ -0,0 +1,85 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"d7y.io/dragonfly/v2/internal/dfnet"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+// announceServer returns an error with code for every announcement
+type announceServer struct {
+	scheduler.UnimplementedSchedulerServer
+	code  codes.Code
+	calls *atomic.Int32
+}
+
+func (s *announceServer) AnnounceHost(context.Context, *scheduler.AnnounceHostRequest) (*emptypb.Empty, error) {
+	s.calls.Inc()
+	return nil, status.Error(s.code, "announce host failed")
+}
+
+func TestSchedulerClient_AnnounceHost(t *testing.T) {
+	tests := []struct {
+		name  string
+		code  codes.Code
+		calls int32
+	}{
+		{
+			name:  "scheduler does not support announcement",
+			code:  codes.Unimplemented,
+			calls: 1,
+		},
+		{
+			name:  "scheduler is unavailable",
+			code:  codes.Unavailable,
+			calls: 3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.Nil(err)
+			server := grpc.NewServer()
+			srv := &announceServer{code: tc.code, calls: atomic.NewInt32(0)}
+			scheduler.RegisterSchedulerServer(server, srv)
+			go server.Serve(listener)
+			defer server.Stop()
+
+			client, err := GetClientByAddr([]dfnet.NetAddr{{Type: dfnet.TCP, Addr: listener.Addr().String()}})
+			assert.Nil(err)
+			defer client.Close()
+
+			err = client.AnnounceHost(context.Background(), &scheduler.AnnounceHostRequest{})
+			assert.Equal(tc.code, status.Code(err))
+			assert.Equal(tc.calls, srv.calls.Load())
+		})
+	}
+}
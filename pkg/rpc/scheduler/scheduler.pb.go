@@ -834,6 +834,222 @@ func (x *PeerTarget) GetPeerId() string {
 	return ""
 }
 
+type AnnounceHostRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// peer host info
+	PeerHost *PeerHost `protobuf:"bytes,1,opt,name=peer_host,json=peerHost,proto3" json:"peer_host,omitempty"`
+	// current host resource usage
+	HostLoad *base.HostLoad `protobuf:"bytes,2,opt,name=host_load,json=hostLoad,proto3" json:"host_load,omitempty"`
+	// upload load limit of the peer host, 0 represents using the limit of scheduler cluster
+	UploadLoadLimit int32 `protobuf:"varint,3,opt,name=upload_load_limit,json=uploadLoadLimit,proto3" json:"upload_load_limit,omitempty"`
+	// tasks cached in the peer host which can be served to other peers
+	CachedTasks []*CachedTask `protobuf:"bytes,4,rep,name=cached_tasks,json=cachedTasks,proto3" json:"cached_tasks,omitempty"`
+}
+
+func (x *AnnounceHostRequest) Reset() {
+	*x = AnnounceHostRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnnounceHostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnnounceHostRequest) ProtoMessage() {}
+
+func (x *AnnounceHostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnnounceHostRequest.ProtoReflect.Descriptor instead.
+func (*AnnounceHostRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AnnounceHostRequest) GetPeerHost() *PeerHost {
+	if x != nil {
+		return x.PeerHost
+	}
+	return nil
+}
+
+func (x *AnnounceHostRequest) GetHostLoad() *base.HostLoad {
+	if x != nil {
+		return x.HostLoad
+	}
+	return nil
+}
+
+func (x *AnnounceHostRequest) GetUploadLoadLimit() int32 {
+	if x != nil {
+		return x.UploadLoadLimit
+	}
+	return 0
+}
+
+func (x *AnnounceHostRequest) GetCachedTasks() []*CachedTask {
+	if x != nil {
+		return x.CachedTasks
+	}
+	return nil
+}
+
+type CachedTask struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// task id
+	TaskId string `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	// peer id of the task in the peer host
+	PeerId string `protobuf:"bytes,2,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`
+	// total content length(byte)
+	ContentLength int64 `protobuf:"varint,3,opt,name=content_length,json=contentLength,proto3" json:"content_length,omitempty"`
+	// total piece count
+	TotalPieceCount int32 `protobuf:"varint,4,opt,name=total_piece_count,json=totalPieceCount,proto3" json:"total_piece_count,omitempty"`
+	// url of the task, scheduler creates the task with it when the task is not registered
+	Url string `protobuf:"bytes,5,opt,name=url,proto3" json:"url,omitempty"`
+	// url meta of the task
+	UrlMeta *base.UrlMeta `protobuf:"bytes,6,opt,name=url_meta,json=urlMeta,proto3" json:"url_meta,omitempty"`
+}
+
+func (x *CachedTask) Reset() {
+	*x = CachedTask{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CachedTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CachedTask) ProtoMessage() {}
+
+func (x *CachedTask) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CachedTask.ProtoReflect.Descriptor instead.
+func (*CachedTask) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CachedTask) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *CachedTask) GetPeerId() string {
+	if x != nil {
+		return x.PeerId
+	}
+	return ""
+}
+
+func (x *CachedTask) GetContentLength() int64 {
+	if x != nil {
+		return x.ContentLength
+	}
+	return 0
+}
+
+func (x *CachedTask) GetTotalPieceCount() int32 {
+	if x != nil {
+		return x.TotalPieceCount
+	}
+	return 0
+}
+
+func (x *CachedTask) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *CachedTask) GetUrlMeta() *base.UrlMeta {
+	if x != nil {
+		return x.UrlMeta
+	}
+	return nil
+}
+
+type LeaveHostRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// peer host uuid
+	Uuid string `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+}
+
+func (x *LeaveHostRequest) Reset() {
+	*x = LeaveHostRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LeaveHostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveHostRequest) ProtoMessage() {}
+
+func (x *LeaveHostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveHostRequest.ProtoReflect.Descriptor instead.
+func (*LeaveHostRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *LeaveHostRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
 type PeerPacket_DestPeer struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -850,7 +1066,7 @@ type PeerPacket_DestPeer struct {
 func (x *PeerPacket_DestPeer) Reset() {
 	*x = PeerPacket_DestPeer{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[8]
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -863,7 +1079,7 @@ func (x *PeerPacket_DestPeer) String() string {
 func (*PeerPacket_DestPeer) ProtoMessage() {}
 
 func (x *PeerPacket_DestPeer) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[8]
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1047,23 +1263,65 @@ var file_pkg_rpc_scheduler_scheduler_proto_rawDesc = []byte{
 	0x20, 0x01, 0x28, 0x09, 0x42, 0x07, 0xfa, 0x42, 0x04, 0x72, 0x02, 0x10, 0x01, 0x52, 0x06, 0x74,
 	0x61, 0x73, 0x6b, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x07, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x69, 0x64,
 	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x07, 0xfa, 0x42, 0x04, 0x72, 0x02, 0x10, 0x01, 0x52,
-	0x06, 0x70, 0x65, 0x65, 0x72, 0x49, 0x64, 0x32, 0x9d, 0x02, 0x0a, 0x09, 0x53, 0x63, 0x68, 0x65,
-	0x64, 0x75, 0x6c, 0x65, 0x72, 0x12, 0x49, 0x0a, 0x10, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65,
-	0x72, 0x50, 0x65, 0x65, 0x72, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x1a, 0x2e, 0x73, 0x63, 0x68, 0x65,
-	0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65,
-	0x72, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
-	0x12, 0x46, 0x0a, 0x11, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x50, 0x69, 0x65, 0x63, 0x65, 0x52,
-	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x16, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65,
-	0x72, 0x2e, 0x50, 0x69, 0x65, 0x63, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x1a, 0x15, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x50, 0x61,
-	0x63, 0x6b, 0x65, 0x74, 0x28, 0x01, 0x30, 0x01, 0x12, 0x41, 0x0a, 0x10, 0x52, 0x65, 0x70, 0x6f,
-	0x72, 0x74, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x15, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x73,
-	0x75, 0x6c, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x3a, 0x0a, 0x09, 0x4c,
-	0x65, 0x61, 0x76, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x15, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x64,
-	0x75, 0x6c, 0x65, 0x72, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x1a,
+	0x06, 0x70, 0x65, 0x65, 0x72, 0x49, 0x64, 0x22, 0xed, 0x01, 0x0a, 0x13, 0x41, 0x6e, 0x6e, 0x6f,
+	0x75, 0x6e, 0x63, 0x65, 0x48, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x3a, 0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x50,
+	0x65, 0x65, 0x72, 0x48, 0x6f, 0x73, 0x74, 0x42, 0x08, 0xfa, 0x42, 0x05, 0x8a, 0x01, 0x02, 0x10,
+	0x01, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x2b, 0x0a, 0x09, 0x68,
+	0x6f, 0x73, 0x74, 0x5f, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e,
+	0x2e, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x08,
+	0x68, 0x6f, 0x73, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x33, 0x0a, 0x11, 0x75, 0x70, 0x6c, 0x6f,
+	0x61, 0x64, 0x5f, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x42, 0x07, 0xfa, 0x42, 0x04, 0x1a, 0x02, 0x28, 0x00, 0x52, 0x0f, 0x75, 0x70,
+	0x6c, 0x6f, 0x61, 0x64, 0x4c, 0x6f, 0x61, 0x64, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x38, 0x0a,
+	0x0c, 0x63, 0x61, 0x63, 0x68, 0x65, 0x64, 0x5f, 0x74, 0x61, 0x73, 0x6b, 0x73, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e,
+	0x43, 0x61, 0x63, 0x68, 0x65, 0x64, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x0b, 0x63, 0x61, 0x63, 0x68,
+	0x65, 0x64, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x22, 0xf1, 0x01, 0x0a, 0x0a, 0x43, 0x61, 0x63, 0x68,
+	0x65, 0x64, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x20, 0x0a, 0x07, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x07, 0xfa, 0x42, 0x04, 0x72, 0x02, 0x10, 0x01,
+	0x52, 0x06, 0x74, 0x61, 0x73, 0x6b, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x07, 0x70, 0x65, 0x65, 0x72,
+	0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x07, 0xfa, 0x42, 0x04, 0x72, 0x02,
+	0x10, 0x01, 0x52, 0x06, 0x70, 0x65, 0x65, 0x72, 0x49, 0x64, 0x12, 0x2e, 0x0a, 0x0e, 0x63, 0x6f,
+	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x42, 0x07, 0xfa, 0x42, 0x04, 0x22, 0x02, 0x28, 0x00, 0x52, 0x0d, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x6e, 0x74, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x33, 0x0a, 0x11, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x5f, 0x70, 0x69, 0x65, 0x63, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x05, 0x42, 0x07, 0xfa, 0x42, 0x04, 0x1a, 0x02, 0x28, 0x00, 0x52, 0x0f,
+	0x74, 0x6f, 0x74, 0x61, 0x6c, 0x50, 0x69, 0x65, 0x63, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72,
+	0x6c, 0x12, 0x28, 0x0a, 0x08, 0x75, 0x72, 0x6c, 0x5f, 0x6d, 0x65, 0x74, 0x61, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x55, 0x72, 0x6c, 0x4d, 0x65,
+	0x74, 0x61, 0x52, 0x07, 0x75, 0x72, 0x6c, 0x4d, 0x65, 0x74, 0x61, 0x22, 0x30, 0x0a, 0x10, 0x4c,
+	0x65, 0x61, 0x76, 0x65, 0x48, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x1c, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x08, 0xfa,
+	0x42, 0x05, 0x72, 0x03, 0xb0, 0x01, 0x01, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x32, 0xa7, 0x03,
+	0x0a, 0x09, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x12, 0x49, 0x0a, 0x10, 0x52,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x54, 0x61, 0x73, 0x6b, 0x12,
+	0x1a, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x50, 0x65, 0x65, 0x72,
+	0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x46, 0x0a, 0x11, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x50, 0x69, 0x65, 0x63, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x16, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x50, 0x69, 0x65, 0x63, 0x65, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x1a, 0x15, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e,
+	0x50, 0x65, 0x65, 0x72, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x28, 0x01, 0x30, 0x01, 0x12, 0x41,
+	0x0a, 0x10, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x12, 0x15, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x50,
+	0x65, 0x65, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x12, 0x3a, 0x0a, 0x09, 0x4c, 0x65, 0x61, 0x76, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x15,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x54,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x46, 0x0a,
+	0x0c, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x1e, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e,
+	0x63, 0x65, 0x48, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x40, 0x0a, 0x09, 0x4c, 0x65, 0x61, 0x76, 0x65, 0x48, 0x6f,
+	0x73, 0x74, 0x12, 0x1b, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x4c,
+	0x65, 0x61, 0x76, 0x65, 0x48, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
 	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
 	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x27, 0x5a, 0x25, 0x64, 0x37, 0x79, 0x2e, 0x69,
 	0x6f, 0x2f, 0x64, 0x72, 0x61, 0x67, 0x6f, 0x6e, 0x66, 0x6c, 0x79, 0x2f, 0x76, 0x32, 0x2f, 0x70,
@@ -1083,7 +1341,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP() []byte {
 	return file_pkg_rpc_scheduler_scheduler_proto_rawDescData
 }
 
-var file_pkg_rpc_scheduler_scheduler_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_pkg_rpc_scheduler_scheduler_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_pkg_rpc_scheduler_scheduler_proto_goTypes = []interface{}{
 	(*PeerTaskRequest)(nil),     // 0: scheduler.PeerTaskRequest
 	(*RegisterResult)(nil),      // 1: scheduler.RegisterResult
@@ -1093,41 +1351,52 @@ var file_pkg_rpc_scheduler_scheduler_proto_goTypes = []interface{}{
 	(*PeerPacket)(nil),          // 5: scheduler.PeerPacket
 	(*PeerResult)(nil),          // 6: scheduler.PeerResult
 	(*PeerTarget)(nil),          // 7: scheduler.PeerTarget
-	(*PeerPacket_DestPeer)(nil), // 8: scheduler.PeerPacket.DestPeer
-	(*base.UrlMeta)(nil),        // 9: base.UrlMeta
-	(*base.HostLoad)(nil),       // 10: base.HostLoad
-	(base.SizeScope)(0),         // 11: base.SizeScope
-	(*base.PieceInfo)(nil),      // 12: base.PieceInfo
-	(base.Code)(0),              // 13: base.Code
-	(*emptypb.Empty)(nil),       // 14: google.protobuf.Empty
+	(*AnnounceHostRequest)(nil), // 8: scheduler.AnnounceHostRequest
+	(*CachedTask)(nil),          // 9: scheduler.CachedTask
+	(*LeaveHostRequest)(nil),    // 10: scheduler.LeaveHostRequest
+	(*PeerPacket_DestPeer)(nil), // 11: scheduler.PeerPacket.DestPeer
+	(*base.UrlMeta)(nil),        // 12: base.UrlMeta
+	(*base.HostLoad)(nil),       // 13: base.HostLoad
+	(base.SizeScope)(0),         // 14: base.SizeScope
+	(*base.PieceInfo)(nil),      // 15: base.PieceInfo
+	(base.Code)(0),              // 16: base.Code
+	(*emptypb.Empty)(nil),       // 17: google.protobuf.Empty
 }
 var file_pkg_rpc_scheduler_scheduler_proto_depIdxs = []int32{
-	9,  // 0: scheduler.PeerTaskRequest.url_meta:type_name -> base.UrlMeta
+	12, // 0: scheduler.PeerTaskRequest.url_meta:type_name -> base.UrlMeta
 	3,  // 1: scheduler.PeerTaskRequest.peer_host:type_name -> scheduler.PeerHost
-	10, // 2: scheduler.PeerTaskRequest.host_load:type_name -> base.HostLoad
-	11, // 3: scheduler.RegisterResult.size_scope:type_name -> base.SizeScope
+	13, // 2: scheduler.PeerTaskRequest.host_load:type_name -> base.HostLoad
+	14, // 3: scheduler.RegisterResult.size_scope:type_name -> base.SizeScope
 	2,  // 4: scheduler.RegisterResult.single_piece:type_name -> scheduler.SinglePiece
-	12, // 5: scheduler.SinglePiece.piece_info:type_name -> base.PieceInfo
-	12, // 6: scheduler.PieceResult.piece_info:type_name -> base.PieceInfo
-	13, // 7: scheduler.PieceResult.code:type_name -> base.Code
-	10, // 8: scheduler.PieceResult.host_load:type_name -> base.HostLoad
-	8,  // 9: scheduler.PeerPacket.main_peer:type_name -> scheduler.PeerPacket.DestPeer
-	8,  // 10: scheduler.PeerPacket.steal_peers:type_name -> scheduler.PeerPacket.DestPeer
-	13, // 11: scheduler.PeerPacket.code:type_name -> base.Code
-	13, // 12: scheduler.PeerResult.code:type_name -> base.Code
-	0,  // 13: scheduler.Scheduler.RegisterPeerTask:input_type -> scheduler.PeerTaskRequest
-	4,  // 14: scheduler.Scheduler.ReportPieceResult:input_type -> scheduler.PieceResult
-	6,  // 15: scheduler.Scheduler.ReportPeerResult:input_type -> scheduler.PeerResult
-	7,  // 16: scheduler.Scheduler.LeaveTask:input_type -> scheduler.PeerTarget
-	1,  // 17: scheduler.Scheduler.RegisterPeerTask:output_type -> scheduler.RegisterResult
-	5,  // 18: scheduler.Scheduler.ReportPieceResult:output_type -> scheduler.PeerPacket
-	14, // 19: scheduler.Scheduler.ReportPeerResult:output_type -> google.protobuf.Empty
-	14, // 20: scheduler.Scheduler.LeaveTask:output_type -> google.protobuf.Empty
-	17, // [17:21] is the sub-list for method output_type
-	13, // [13:17] is the sub-list for method input_type
-	13, // [13:13] is the sub-list for extension type_name
-	13, // [13:13] is the sub-list for extension extendee
-	0,  // [0:13] is the sub-list for field type_name
+	15, // 5: scheduler.SinglePiece.piece_info:type_name -> base.PieceInfo
+	15, // 6: scheduler.PieceResult.piece_info:type_name -> base.PieceInfo
+	16, // 7: scheduler.PieceResult.code:type_name -> base.Code
+	13, // 8: scheduler.PieceResult.host_load:type_name -> base.HostLoad
+	11, // 9: scheduler.PeerPacket.main_peer:type_name -> scheduler.PeerPacket.DestPeer
+	11, // 10: scheduler.PeerPacket.steal_peers:type_name -> scheduler.PeerPacket.DestPeer
+	16, // 11: scheduler.PeerPacket.code:type_name -> base.Code
+	16, // 12: scheduler.PeerResult.code:type_name -> base.Code
+	3,  // 13: scheduler.AnnounceHostRequest.peer_host:type_name -> scheduler.PeerHost
+	13, // 14: scheduler.AnnounceHostRequest.host_load:type_name -> base.HostLoad
+	9,  // 15: scheduler.AnnounceHostRequest.cached_tasks:type_name -> scheduler.CachedTask
+	12, // 16: scheduler.CachedTask.url_meta:type_name -> base.UrlMeta
+	0,  // 17: scheduler.Scheduler.RegisterPeerTask:input_type -> scheduler.PeerTaskRequest
+	4,  // 18: scheduler.Scheduler.ReportPieceResult:input_type -> scheduler.PieceResult
+	6,  // 19: scheduler.Scheduler.ReportPeerResult:input_type -> scheduler.PeerResult
+	7,  // 20: scheduler.Scheduler.LeaveTask:input_type -> scheduler.PeerTarget
+	8,  // 21: scheduler.Scheduler.AnnounceHost:input_type -> scheduler.AnnounceHostRequest
+	10, // 22: scheduler.Scheduler.LeaveHost:input_type -> scheduler.LeaveHostRequest
+	1,  // 23: scheduler.Scheduler.RegisterPeerTask:output_type -> scheduler.RegisterResult
+	5,  // 24: scheduler.Scheduler.ReportPieceResult:output_type -> scheduler.PeerPacket
+	17, // 25: scheduler.Scheduler.ReportPeerResult:output_type -> google.protobuf.Empty
+	17, // 26: scheduler.Scheduler.LeaveTask:output_type -> google.protobuf.Empty
+	17, // 27: scheduler.Scheduler.AnnounceHost:output_type -> google.protobuf.Empty
+	17, // 28: scheduler.Scheduler.LeaveHost:output_type -> google.protobuf.Empty
+	23, // [23:29] is the sub-list for method output_type
+	17, // [17:23] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
 }
 
 func init() { file_pkg_rpc_scheduler_scheduler_proto_init() }
@@ -1233,6 +1502,42 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			}
 		}
 		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnnounceHostRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CachedTask); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LeaveHostRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PeerPacket_DestPeer); i {
 			case 0:
 				return &v.state
@@ -1255,7 +1560,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pkg_rpc_scheduler_scheduler_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -927,6 +927,308 @@ var _ interface {
 	ErrorName() string
 } = PeerTargetValidationError{}
 
+// Validate checks the field values on AnnounceHostRequest with the rules
+// defined in the proto definition for this message. If any rules are violated,
+// an error is returned.
+func (m *AnnounceHostRequest) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	if m.GetPeerHost() == nil {
+		return AnnounceHostRequestValidationError{
+			field:  "PeerHost",
+			reason: "value is required",
+		}
+	}
+
+	if v, ok := interface{}(m.GetPeerHost()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return AnnounceHostRequestValidationError{
+				field:  "PeerHost",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if v, ok := interface{}(m.GetHostLoad()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return AnnounceHostRequestValidationError{
+				field:  "HostLoad",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if m.GetUploadLoadLimit() < 0 {
+		return AnnounceHostRequestValidationError{
+			field:  "UploadLoadLimit",
+			reason: "value must be greater than or equal to 0",
+		}
+	}
+
+	for idx, item := range m.GetCachedTasks() {
+		_, _ = idx, item
+
+		if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return AnnounceHostRequestValidationError{
+					field:  fmt.Sprintf("CachedTasks[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// AnnounceHostRequestValidationError is the validation error returned by
+// AnnounceHostRequest.Validate if the designated constraints aren't met.
+type AnnounceHostRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e AnnounceHostRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e AnnounceHostRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e AnnounceHostRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e AnnounceHostRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e AnnounceHostRequestValidationError) ErrorName() string {
+	return "AnnounceHostRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e AnnounceHostRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sAnnounceHostRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = AnnounceHostRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = AnnounceHostRequestValidationError{}
+
+// Validate checks the field values on CachedTask with the rules defined in the
+// proto definition for this message. If any rules are violated, an error is
+// returned.
+func (m *CachedTask) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	if utf8.RuneCountInString(m.GetTaskId()) < 1 {
+		return CachedTaskValidationError{
+			field:  "TaskId",
+			reason: "value length must be at least 1 runes",
+		}
+	}
+
+	if utf8.RuneCountInString(m.GetPeerId()) < 1 {
+		return CachedTaskValidationError{
+			field:  "PeerId",
+			reason: "value length must be at least 1 runes",
+		}
+	}
+
+	if m.GetContentLength() < 0 {
+		return CachedTaskValidationError{
+			field:  "ContentLength",
+			reason: "value must be greater than or equal to 0",
+		}
+	}
+
+	if m.GetTotalPieceCount() < 0 {
+		return CachedTaskValidationError{
+			field:  "TotalPieceCount",
+			reason: "value must be greater than or equal to 0",
+		}
+	}
+
+	// no validation rules for Url
+
+	if v, ok := interface{}(m.GetUrlMeta()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return CachedTaskValidationError{
+				field:  "UrlMeta",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	return nil
+}
+
+// CachedTaskValidationError is the validation error returned by
+// CachedTask.Validate if the designated constraints aren't met.
+type CachedTaskValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CachedTaskValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CachedTaskValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CachedTaskValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CachedTaskValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CachedTaskValidationError) ErrorName() string { return "CachedTaskValidationError" }
+
+// Error satisfies the builtin error interface
+func (e CachedTaskValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCachedTask.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CachedTaskValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CachedTaskValidationError{}
+
+// Validate checks the field values on LeaveHostRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, an
+// error is returned.
+func (m *LeaveHostRequest) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	if err := m._validateUuid(m.GetUuid()); err != nil {
+		return LeaveHostRequestValidationError{
+			field:  "Uuid",
+			reason: "value must be a valid UUID",
+			cause:  err,
+		}
+	}
+
+	return nil
+}
+
+func (m *LeaveHostRequest) _validateUuid(uuid string) error {
+	if matched := _scheduler_uuidPattern.MatchString(uuid); !matched {
+		return errors.New("invalid uuid format")
+	}
+
+	return nil
+}
+
+// LeaveHostRequestValidationError is the validation error returned by
+// LeaveHostRequest.Validate if the designated constraints aren't met.
+type LeaveHostRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e LeaveHostRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e LeaveHostRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e LeaveHostRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e LeaveHostRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e LeaveHostRequestValidationError) ErrorName() string { return "LeaveHostRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e LeaveHostRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sLeaveHostRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = LeaveHostRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = LeaveHostRequestValidationError{}
+
 // Validate checks the field values on PeerPacket_DestPeer with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, an error is returned.
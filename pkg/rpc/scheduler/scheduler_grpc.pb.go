@@ -29,6 +29,11 @@ type SchedulerClient interface {
 	ReportPeerResult(ctx context.Context, in *PeerResult, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// LeaveTask makes the peer leaving from scheduling overlay for the task.
 	LeaveTask(ctx context.Context, in *PeerTarget, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// AnnounceHost announces the peer host and its cached tasks periodically,
+	// it works as the keepalive of the peer host.
+	AnnounceHost(ctx context.Context, in *AnnounceHostRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// LeaveHost makes the peer host and all its peers leaving from scheduling overlay.
+	LeaveHost(ctx context.Context, in *LeaveHostRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type schedulerClient struct {
@@ -97,6 +102,24 @@ func (c *schedulerClient) LeaveTask(ctx context.Context, in *PeerTarget, opts ..
 	return out, nil
 }
 
+func (c *schedulerClient) AnnounceHost(ctx context.Context, in *AnnounceHostRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/scheduler.Scheduler/AnnounceHost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schedulerClient) LeaveHost(ctx context.Context, in *LeaveHostRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/scheduler.Scheduler/LeaveHost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SchedulerServer is the server API for Scheduler service.
 // All implementations must embed UnimplementedSchedulerServer
 // for forward compatibility
@@ -111,6 +134,11 @@ type SchedulerServer interface {
 	ReportPeerResult(context.Context, *PeerResult) (*emptypb.Empty, error)
 	// LeaveTask makes the peer leaving from scheduling overlay for the task.
 	LeaveTask(context.Context, *PeerTarget) (*emptypb.Empty, error)
+	// AnnounceHost announces the peer host and its cached tasks periodically,
+	// it works as the keepalive of the peer host.
+	AnnounceHost(context.Context, *AnnounceHostRequest) (*emptypb.Empty, error)
+	// LeaveHost makes the peer host and all its peers leaving from scheduling overlay.
+	LeaveHost(context.Context, *LeaveHostRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedSchedulerServer()
 }
 
@@ -130,6 +158,12 @@ func (UnimplementedSchedulerServer) ReportPeerResult(context.Context, *PeerResul
 func (UnimplementedSchedulerServer) LeaveTask(context.Context, *PeerTarget) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LeaveTask not implemented")
 }
+func (UnimplementedSchedulerServer) AnnounceHost(context.Context, *AnnounceHostRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnnounceHost not implemented")
+}
+func (UnimplementedSchedulerServer) LeaveHost(context.Context, *LeaveHostRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaveHost not implemented")
+}
 func (UnimplementedSchedulerServer) mustEmbedUnimplementedSchedulerServer() {}
 
 // UnsafeSchedulerServer may be embedded to opt out of forward compatibility for this service.
@@ -223,6 +257,42 @@ func _Scheduler_LeaveTask_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Scheduler_AnnounceHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnnounceHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchedulerServer).AnnounceHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/scheduler.Scheduler/AnnounceHost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchedulerServer).AnnounceHost(ctx, req.(*AnnounceHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Scheduler_LeaveHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaveHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchedulerServer).LeaveHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/scheduler.Scheduler/LeaveHost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchedulerServer).LeaveHost(ctx, req.(*LeaveHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Scheduler_ServiceDesc is the grpc.ServiceDesc for Scheduler service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -242,6 +312,14 @@ var Scheduler_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "LeaveTask",
 			Handler:    _Scheduler_LeaveTask_Handler,
 		},
+		{
+			MethodName: "AnnounceHost",
+			Handler:    _Scheduler_AnnounceHost_Handler,
+		},
+		{
+			MethodName: "LeaveHost",
+			Handler:    _Scheduler_LeaveHost_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -20,6 +20,7 @@ import (
 	"io"
 	"net/url"
 	"os/user"
+	pathpkg "path"
 	"strings"
 	"sync"
 	"time"
@@ -151,6 +152,46 @@ func (h *hdfsSourceClient) Download(request *source.Request) (*source.Response,
 	return response, nil
 }
 
+// List lists all files under the hdfs directory recursively, the file itself is returned when url is a file
+func (h *hdfsSourceClient) List(request *source.Request) ([]*url.URL, error) {
+	hdfsClient, path, err := h.getHDFSClientAndPath(request.URL)
+	if err != nil {
+		return nil, err
+	}
+	info, err := hdfsClient.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []*url.URL{request.URL}, nil
+	}
+
+	var (
+		urls []*url.URL
+		dirs = []string{path}
+	)
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+		infos, err := hdfsClient.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			p := pathpkg.Join(dir, info.Name())
+			if info.IsDir() {
+				dirs = append(dirs, p)
+				continue
+			}
+			u := *request.URL
+			u.Path = p
+			u.RawPath = ""
+			urls = append(urls, &u)
+		}
+	}
+	return urls, nil
+}
+
 func (h *hdfsSourceClient) GetLastModified(request *source.Request) (int64, error) {
 
 	hdfsClient, path, err := h.getHDFSClientAndPath(request.URL)
@@ -223,6 +264,7 @@ func newHDFSSourceClient(opts ...HDFSSourceClientOption) *hdfsSourceClient {
 }
 
 var _ source.ResourceClient = (*hdfsSourceClient)(nil)
+var _ source.ResourceLister = (*hdfsSourceClient)(nil)
 
 func (rc *hdfsFileReaderClose) Read(p []byte) (n int, err error) {
 	return rc.limitedReader.Read(p)
@@ -364,6 +364,55 @@ func TestNewHDFSSourceClient(t *testing.T) {
 
 }
 
+func TestList_Directory(t *testing.T) {
+	patch := gomonkey.ApplyMethod(reflect.TypeOf(fakeHDFSClient), "Stat", func(*hdfs.Client, string) (os.FileInfo, error) {
+		return fakeHDFSFileInfo{dir: true, basename: "input"}, nil
+	})
+	patch.ApplyMethod(reflect.TypeOf(fakeHDFSClient), "ReadDir", func(_ *hdfs.Client, dirname string) ([]os.FileInfo, error) {
+		switch dirname {
+		case "/user/root/input":
+			return []os.FileInfo{
+				fakeHDFSFileInfo{basename: "f1.txt"},
+				fakeHDFSFileInfo{dir: true, basename: "sub"},
+			}, nil
+		case "/user/root/input/sub":
+			return []os.FileInfo{
+				fakeHDFSFileInfo{basename: "f2.txt"},
+			}, nil
+		}
+		return nil, errors.Errorf("open %s: file does not exist", dirname)
+	})
+	defer patch.Reset()
+
+	request, err := source.NewRequest("hdfs://" + hdfsExistFileHost + "/user/root/input")
+	assert.Nil(t, err)
+	urls, err := sourceClient.(source.ResourceLister).List(request)
+	assert.Nil(t, err)
+	var actual []string
+	for _, u := range urls {
+		actual = append(actual, u.String())
+	}
+	assert.Equal(t, []string{
+		"hdfs://" + hdfsExistFileHost + "/user/root/input/f1.txt",
+		"hdfs://" + hdfsExistFileHost + "/user/root/input/sub/f2.txt",
+	}, actual)
+}
+
+func TestList_File(t *testing.T) {
+	stubRet := []gomonkey.OutputCell{
+		{Values: gomonkey.Params{fakeHDFSFileInfo{basename: "f1.txt"}, nil}},
+	}
+	patch := gomonkey.ApplyMethodSeq(reflect.TypeOf(fakeHDFSClient), "Stat", stubRet)
+	defer patch.Reset()
+
+	request, err := source.NewRequest(hdfsExistFileURL)
+	assert.Nil(t, err)
+	urls, err := sourceClient.(source.ResourceLister).List(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(urls))
+	assert.Equal(t, hdfsExistFileURL, urls[0].String())
+}
+
 type fakeHDFSFileInfo struct {
 	dir      bool
 	basename string
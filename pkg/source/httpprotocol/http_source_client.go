@@ -181,6 +181,8 @@ func (client *httpSourceClient) Download(request *source.Request) (*source.Respo
 	}
 	response := source.NewResponse(
 		resp.Body,
+		source.WithStatus(resp.StatusCode, resp.Status),
+		source.WithContentLength(resp.ContentLength),
 		source.WithExpireInfo(
 			source.ExpireInfo{
 				LastModified: resp.Header.Get(headers.LastModified),
@@ -0,0 +1,187 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpprotocol
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-http-utils/headers"
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+
+	"d7y.io/dragonfly/v2/pkg/source"
+)
+
+var _ source.ResourceLister = (*httpSourceClient)(nil)
+
+// autoIndexEntry is the entry of json directory listing, like nginx "autoindex_format json"
+type autoIndexEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+const (
+	autoIndexTypeDirectory = "directory"
+	// maxListBodySize limits the size of one directory listing page
+	maxListBodySize = 16 * 1024 * 1024
+)
+
+// List lists all files under the directory url recursively, the directory listing can be
+// autoindex-style html or json, only the entries under the directory are returned
+func (client *httpSourceClient) List(request *source.Request) ([]*url.URL, error) {
+	dirURL := *request.URL
+	if !strings.HasSuffix(dirURL.Path, "/") {
+		dirURL.Path += "/"
+	}
+	var (
+		urls    []*url.URL
+		visited = map[string]bool{}
+		dirs    = []*url.URL{&dirURL}
+	)
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+		if visited[dir.String()] {
+			continue
+		}
+		visited[dir.String()] = true
+
+		files, subDirs, err := client.listDir(request, dir)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, files...)
+		dirs = append(dirs, subDirs...)
+	}
+	return urls, nil
+}
+
+// listDir lists one directory and returns the files and sub directories in it
+func (client *httpSourceClient) listDir(request *source.Request, dir *url.URL) (files []*url.URL, dirs []*url.URL, err error) {
+	dirRequest := request.Clone(request.Context())
+	dirRequest.URL = dir
+	if dirRequest.Header == nil {
+		dirRequest.Header = source.Header{}
+	}
+	dirRequest.Header.Set(headers.Accept, "application/json, text/html;q=0.9")
+	resp, err := client.doRequest(http.MethodGet, dirRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if err = source.CheckResponseCode(resp.StatusCode, []int{http.StatusOK}); err != nil {
+		return nil, nil, err
+	}
+
+	body := io.LimitReader(resp.Body, maxListBodySize)
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get(headers.ContentType))
+	var hrefs []string
+	switch mediaType {
+	case "application/json":
+		hrefs, err = parseJSONListing(body)
+	case "text/html", "application/xhtml+xml", "":
+		hrefs, err = parseHTMLListing(body)
+	default:
+		return nil, nil, errors.Errorf("unsupported directory listing content type: %s", mediaType)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "parse directory listing of %s", dir)
+	}
+
+	seen := map[string]bool{}
+	for _, href := range hrefs {
+		u, ok := resolveEntry(dir, href)
+		if !ok || seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		if strings.HasSuffix(u.Path, "/") {
+			dirs = append(dirs, u)
+		} else {
+			files = append(files, u)
+		}
+	}
+	return files, dirs, nil
+}
+
+// resolveEntry resolves href against dir, the entries out of dir like parent directory,
+// sorting links and links to other hosts are ignored
+func resolveEntry(dir *url.URL, href string) (*url.URL, bool) {
+	ref, err := url.Parse(href)
+	if err != nil || ref.Path == "" {
+		return nil, false
+	}
+	u := dir.ResolveReference(ref)
+	u.Fragment = ""
+	if u.Scheme != dir.Scheme || u.Host != dir.Host {
+		return nil, false
+	}
+	if !strings.HasPrefix(u.Path, dir.Path) || u.Path == dir.Path {
+		return nil, false
+	}
+	return u, true
+}
+
+func parseJSONListing(r io.Reader) ([]string, error) {
+	var entries []autoIndexEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	var hrefs []string
+	for _, entry := range entries {
+		if entry.Name == "" {
+			continue
+		}
+		href := (&url.URL{Path: entry.Name}).String()
+		if entry.Type == autoIndexTypeDirectory {
+			href += "/"
+		}
+		hrefs = append(hrefs, href)
+	}
+	return hrefs, nil
+}
+
+func parseHTMLListing(r io.Reader) ([]string, error) {
+	var hrefs []string
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, err
+			}
+			return hrefs, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				if string(key) == "href" {
+					hrefs = append(hrefs, string(value))
+				}
+			}
+		}
+	}
+}
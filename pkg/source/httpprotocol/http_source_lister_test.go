@@ -0,0 +1,87 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpprotocol
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/go-http-utils/headers"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/source"
+)
+
+func TestHTTPSourceClient_List(t *testing.T) {
+	assert := testifyassert.New(t)
+	mux := http.NewServeMux()
+	// autoindex-style html listing
+	mux.HandleFunc("/data/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headers.ContentType, "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>Index of /data/</title></head><body>
+<a href="?C=N;O=D">Name</a>
+<a href="../">../</a>
+<a href="a.txt">a.txt</a>
+<a href="/data/b%20c.txt">b c.txt</a>
+<a href="sub/">sub/</a>
+<a href="json/">json/</a>
+<a href="https://other.example.com/x.txt">x.txt</a>
+<a href="a.txt#top">a.txt</a>
+</body></html>`))
+	})
+	mux.HandleFunc("/data/sub/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headers.ContentType, "text/html")
+		_, _ = w.Write([]byte(`<pre><a href="../">../</a><a href="c.txt">c.txt</a><a href="/data/">loop</a></pre>`))
+	})
+	// nginx json listing
+	mux.HandleFunc("/data/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headers.ContentType, "application/json")
+		_, _ = w.Write([]byte(`[{"name":"d.txt","type":"file","size":1},{"name":"deep","type":"directory"}]`))
+	})
+	mux.HandleFunc("/data/json/deep/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headers.ContentType, "application/json")
+		_, _ = w.Write([]byte(`[{"name":"e f.txt","type":"file"}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newHTTPSourceClient(WithHTTPClient(server.Client()))
+	request, err := source.NewRequest(server.URL + "/data")
+	assert.Nil(err)
+
+	urls, err := client.List(request)
+	assert.Nil(err)
+	var actual []string
+	for _, u := range urls {
+		actual = append(actual, u.String())
+	}
+	sort.Strings(actual)
+	assert.Equal([]string{
+		server.URL + "/data/a.txt",
+		server.URL + "/data/b%20c.txt",
+		server.URL + "/data/json/d.txt",
+		server.URL + "/data/json/deep/e%20f.txt",
+		server.URL + "/data/sub/c.txt",
+	}, actual)
+
+	request, err = source.NewRequest(server.URL + "/notfound/")
+	assert.Nil(err)
+	_, err = client.List(request)
+	assert.NotNil(err)
+}
@@ -0,0 +1,351 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lfsprotocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-http-utils/headers"
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/pkg/source"
+	"d7y.io/dragonfly/v2/pkg/util/timeutils"
+)
+
+const HuggingFaceClient = "hf"
+
+// header keys for huggingface client
+const (
+	hfToken     = "hfToken"
+	hfPlainHTTP = "hfPlainHTTP"
+
+	// hfTokenEnv is the token environment variable used by huggingface tools
+	hfTokenEnv = "HF_TOKEN"
+)
+
+const (
+	resolveSegment = "/resolve/"
+
+	// headerLinkedETag is the sha256 oid of lfs file returned by the hub
+	headerLinkedETag = "X-Linked-Etag"
+
+	maxRedirects = 10
+)
+
+var linkNextPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+var _ source.ResourceClient = (*huggingFaceSourceClient)(nil)
+var _ source.ResourceLister = (*huggingFaceSourceClient)(nil)
+
+func init() {
+	if err := source.Register(HuggingFaceClient, NewHuggingFaceSourceClient(), adaptor); err != nil {
+		panic(err)
+	}
+}
+
+func NewHuggingFaceSourceClient(opts ...HuggingFaceSourceClientOption) source.ResourceClient {
+	return newHuggingFaceSourceClient(opts...)
+}
+
+func newHuggingFaceSourceClient(opts ...HuggingFaceSourceClientOption) *huggingFaceSourceClient {
+	sourceClient := &huggingFaceSourceClient{
+		httpClient: http.DefaultClient,
+	}
+	for i := range opts {
+		opts[i](sourceClient)
+	}
+	return sourceClient
+}
+
+type HuggingFaceSourceClientOption func(p *huggingFaceSourceClient)
+
+// WithHuggingFaceHTTPClient sets the http client used to access huggingface hub
+func WithHuggingFaceHTTPClient(client *http.Client) HuggingFaceSourceClientOption {
+	return func(sourceClient *huggingFaceSourceClient) {
+		sourceClient.httpClient = client
+	}
+}
+
+// huggingFaceSourceClient is an implementation of the interface of source.ResourceClient,
+// it downloads files with url hf://<host>/<repository>/resolve/<revision>/<file>.
+type huggingFaceSourceClient struct {
+	httpClient *http.Client
+}
+
+// hfReference is the parsed form of hf://<host>/[datasets/|spaces/]<repository>/resolve/<revision>/<file>
+type hfReference struct {
+	host string
+	// kind is models, datasets or spaces
+	kind       string
+	repository string
+	revision   string
+	file       string
+}
+
+// hfTreeEntry is the entry of huggingface tree api
+type hfTreeEntry struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	LFS  *struct {
+		OID  string `json:"oid"`
+		Size int64  `json:"size"`
+	} `json:"lfs,omitempty"`
+}
+
+func parseHFReference(u *url.URL) (*hfReference, error) {
+	idx := strings.Index(u.Path, resolveSegment)
+	if u.Host == "" || idx <= 0 {
+		return nil, errors.Errorf("invalid huggingface url %s, expect hf://<host>/<repository>/resolve/<revision>/<file>", u)
+	}
+	ref := &hfReference{
+		host:       u.Host,
+		kind:       "models",
+		repository: strings.Trim(u.Path[:idx], "/"),
+	}
+	for _, kind := range []string{"datasets", "spaces"} {
+		if strings.HasPrefix(ref.repository, kind+"/") {
+			ref.kind, ref.repository = kind, strings.TrimPrefix(ref.repository, kind+"/")
+		}
+	}
+
+	rest := strings.Trim(u.Path[idx+len(resolveSegment):], "/")
+	if rest == "" {
+		return nil, errors.Errorf("invalid huggingface url %s: revision is empty", u)
+	}
+	ref.revision = rest
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		ref.revision, ref.file = rest[:i], rest[i+1:]
+	}
+	return ref, nil
+}
+
+// GetContentLength gets the content length by HEAD method, the file is not downloaded
+func (c *huggingFaceSourceClient) GetContentLength(request *source.Request) (int64, error) {
+	resp, err := c.resolve(request, http.MethodHead)
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
+	defer resp.Body.Close()
+	return resp.ContentLength, nil
+}
+
+// IsSupportRange probes the file with a one byte range, the header of request is not modified
+func (c *huggingFaceSourceClient) IsSupportRange(request *source.Request) (bool, error) {
+	probe := request.Clone(request.Context())
+	if probe.Header.Get(headers.Range) == "" {
+		probe.Header.Set(headers.Range, "bytes=0-0")
+	}
+	resp, err := c.resolve(probe, http.MethodGet)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusPartialContent, nil
+}
+
+// IsExpired returns false for lfs files with oid, others are checked by etag and last modified like http
+func (c *huggingFaceSourceClient) IsExpired(request *source.Request, info *source.ExpireInfo) (bool, error) {
+	if request.URL.Query().Get(oidQuery) != "" {
+		return false, nil
+	}
+	resp, err := c.resolve(request, http.MethodHead)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return !(resp.Header.Get(headers.ETag) == info.ETag || resp.Header.Get(headers.LastModified) == info.LastModified), nil
+}
+
+func (c *huggingFaceSourceClient) Download(request *source.Request) (*source.Response, error) {
+	resp, err := c.resolve(request, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	return newObjectResponse(request, resp, request.URL.Query().Get(oidQuery))
+}
+
+func (c *huggingFaceSourceClient) GetLastModified(request *source.Request) (int64, error) {
+	resp, err := c.resolve(request, http.MethodHead)
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Body.Close()
+	return timeutils.UnixMillis(resp.Header.Get(headers.LastModified)), nil
+}
+
+// List lists all files of the repository revision with tree api, the oid of lfs file is set in url query,
+// so that the same lfs file in different revisions shares one task
+func (c *huggingFaceSourceClient) List(request *source.Request) ([]*url.URL, error) {
+	ref, err := parseHFReference(request.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	api := url.URL{
+		Scheme:   hfScheme(request),
+		Host:     ref.host,
+		Path:     fmt.Sprintf("/api/%s/%s/tree/%s", ref.kind, ref.repository, ref.revision),
+		RawQuery: "recursive=true",
+	}
+	if ref.file != "" {
+		api.Path += "/" + ref.file
+	}
+
+	var (
+		urls []*url.URL
+		next = api.String()
+	)
+	for next != "" {
+		var entries []hfTreeEntry
+		if next, err = c.getTree(request, next, &entries); err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Type != "file" {
+				continue
+			}
+			u := &url.URL{
+				Scheme: request.URL.Scheme,
+				Host:   request.URL.Host,
+				Path:   path.Join(request.URL.Path[:strings.Index(request.URL.Path, resolveSegment)], "resolve", ref.revision, entry.Path),
+			}
+			if entry.LFS != nil && entry.LFS.OID != "" {
+				u.RawQuery = url.Values{oidQuery: []string{entry.LFS.OID}}.Encode()
+			}
+			urls = append(urls, u)
+		}
+	}
+	return urls, nil
+}
+
+// getTree gets one page of tree api and returns the next page url
+func (c *huggingFaceSourceClient) getTree(request *source.Request, pageURL string, entries *[]hfTreeEntry) (string, error) {
+	req, err := http.NewRequestWithContext(request.Context(), http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	setToken(req, request)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("huggingface tree api %s: unexpected status code %d", pageURL, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(entries); err != nil {
+		return "", errors.Wrap(err, "decode huggingface tree response")
+	}
+
+	if match := linkNextPattern.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+		next, err := req.URL.Parse(match[1])
+		if err != nil {
+			return "", errors.Wrap(err, "parse next page link")
+		}
+		return next.String(), nil
+	}
+	return "", nil
+}
+
+// resolve requests the file by resolve api with method, redirects are followed manually,
+// the token is only sent to the hub, not the cdn with signed url.
+// When the url carries an oid, the hub must confirm that the file is the lfs object with the oid,
+// because the ranged contents are not verified with the oid
+func (c *huggingFaceSourceClient) resolve(request *source.Request, method string) (*http.Response, error) {
+	ref, err := parseHFReference(request.URL)
+	if err != nil {
+		return nil, err
+	}
+	if ref.file == "" {
+		return nil, errors.Errorf("invalid huggingface url %s: file is empty", request.URL)
+	}
+
+	target := &url.URL{
+		Scheme: hfScheme(request),
+		Host:   ref.host,
+		Path:   request.URL.Path,
+	}
+	client := *c.httpClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	for i := 0; i <= maxRedirects; i++ {
+		req, err := http.NewRequestWithContext(request.Context(), method, target.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if target.Host == ref.host {
+			setToken(req, request)
+		}
+		if rg := request.Header.Get(headers.Range); rg != "" {
+			req.Header.Set(headers.Range, rg)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if oid := request.URL.Query().Get(oidQuery); oid != "" && target.Host == ref.host {
+			if linked := strings.Trim(resp.Header.Get(headerLinkedETag), `"`); linked != oid {
+				resp.Body.Close()
+				return nil, errors.Errorf("huggingface url %s: oid not match, desired: %s, actual: %s", request.URL, oid, linked)
+			}
+		}
+
+		switch resp.StatusCode {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+			resp.Body.Close()
+			if target, err = req.URL.Parse(resp.Header.Get(headers.Location)); err != nil {
+				return nil, errors.Wrap(err, "parse redirect location")
+			}
+			continue
+		}
+		if err = source.CheckResponseCode(resp.StatusCode, []int{http.StatusOK, http.StatusPartialContent}); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		return resp, nil
+	}
+	return nil, errors.Errorf("huggingface url %s: stopped after %d redirects", request.URL, maxRedirects)
+}
+
+func hfScheme(request *source.Request) string {
+	if plain, _ := strconv.ParseBool(request.Header.Get(hfPlainHTTP)); plain {
+		return "http"
+	}
+	return "https"
+}
+
+// setToken sets the bearer token from request header, or from HF_TOKEN environment variable
+func setToken(req *http.Request, request *source.Request) {
+	token := request.Header.Get(hfToken)
+	if token == "" {
+		token = os.Getenv(hfTokenEnv)
+	}
+	if token != "" {
+		req.Header.Set(headers.Authorization, "Bearer "+token)
+	}
+}
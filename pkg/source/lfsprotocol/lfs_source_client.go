@@ -0,0 +1,323 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lfsprotocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-http-utils/headers"
+	"github.com/pkg/errors"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/source"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
+)
+
+const LFSClient = "lfs"
+
+// header keys for lfs client
+const (
+	lfsUsername  = "lfsUsername"
+	lfsPassword  = "lfsPassword"
+	lfsPlainHTTP = "lfsPlainHTTP"
+)
+
+const (
+	// oidQuery and sizeQuery are the url queries of lfs object,
+	// the oid query is also used by idgen to generate task id
+	oidQuery  = "oid"
+	sizeQuery = "size"
+
+	mediaTypeLFS = "application/vnd.git-lfs+json"
+)
+
+var _ source.ResourceClient = (*lfsSourceClient)(nil)
+
+func init() {
+	if err := source.Register(LFSClient, NewLFSSourceClient(), adaptor); err != nil {
+		panic(err)
+	}
+}
+
+func adaptor(request *source.Request) *source.Request {
+	clonedRequest := request.Clone(request.Context())
+	if request.Header.Get(source.Range) != "" {
+		clonedRequest.Header.Set(headers.Range, fmt.Sprintf("bytes=%s", request.Header.Get(source.Range)))
+		clonedRequest.Header.Del(source.Range)
+	}
+	return clonedRequest
+}
+
+func NewLFSSourceClient(opts ...LFSSourceClientOption) source.ResourceClient {
+	return newLFSSourceClient(opts...)
+}
+
+func newLFSSourceClient(opts ...LFSSourceClientOption) *lfsSourceClient {
+	sourceClient := &lfsSourceClient{
+		httpClient: http.DefaultClient,
+	}
+	for i := range opts {
+		opts[i](sourceClient)
+	}
+	return sourceClient
+}
+
+type LFSSourceClientOption func(p *lfsSourceClient)
+
+// WithLFSHTTPClient sets the http client used to access lfs servers
+func WithLFSHTTPClient(client *http.Client) LFSSourceClientOption {
+	return func(sourceClient *lfsSourceClient) {
+		sourceClient.httpClient = client
+	}
+}
+
+// lfsSourceClient is an implementation of the interface of source.ResourceClient,
+// it downloads git lfs objects with url lfs://<host>/<repository>?oid=<oid>&size=<size>.
+type lfsSourceClient struct {
+	httpClient *http.Client
+}
+
+// lfsObject is the object of git lfs batch api
+type lfsObject struct {
+	OID     string `json:"oid"`
+	Size    int64  `json:"size"`
+	Actions *struct {
+		Download *lfsAction `json:"download"`
+	} `json:"actions,omitempty"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+type lfsAction struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+type lfsBatchRequest struct {
+	Operation string      `json:"operation"`
+	Transfers []string    `json:"transfers"`
+	Objects   []lfsObject `json:"objects"`
+	HashAlgo  string      `json:"hash_algo"`
+}
+
+type lfsBatchResponse struct {
+	Transfer string      `json:"transfer"`
+	Objects  []lfsObject `json:"objects"`
+}
+
+// GetContentLength returns the size in url without accessing the lfs server, the size is part of lfs pointer
+func (c *lfsSourceClient) GetContentLength(request *source.Request) (int64, error) {
+	_, size, err := parseObject(request)
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
+	rg := request.Header.Get(headers.Range)
+	if rg == "" {
+		return size, nil
+	}
+	r, err := rangeutils.ParseRange(strings.TrimPrefix(rg, "bytes="), uint64(size))
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
+	return int64(r.Length()), nil
+}
+
+// IsSupportRange probes the object with a one byte range, the header of request is not modified
+func (c *lfsSourceClient) IsSupportRange(request *source.Request) (bool, error) {
+	probe := request.Clone(request.Context())
+	if probe.Header.Get(headers.Range) == "" {
+		probe.Header.Set(headers.Range, "bytes=0-0")
+	}
+	resp, _, err := c.getObject(probe)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusPartialContent, nil
+}
+
+// IsExpired returns false as lfs objects are content addressable
+func (c *lfsSourceClient) IsExpired(request *source.Request, info *source.ExpireInfo) (bool, error) {
+	return false, nil
+}
+
+func (c *lfsSourceClient) Download(request *source.Request) (*source.Response, error) {
+	resp, oid, err := c.getObject(request)
+	if err != nil {
+		return nil, err
+	}
+	return newObjectResponse(request, resp, oid)
+}
+
+// GetLastModified returns -1 as lfs objects are content addressable and have no modification time
+func (c *lfsSourceClient) GetLastModified(request *source.Request) (int64, error) {
+	return -1, nil
+}
+
+// parseObject returns the oid and size of lfs object in url
+func parseObject(request *source.Request) (string, int64, error) {
+	query := request.URL.Query()
+	oid := query.Get(oidQuery)
+	if oid == "" {
+		return "", 0, errors.Errorf("invalid lfs url %s: oid is empty", request.URL)
+	}
+	size, err := strconv.ParseInt(query.Get(sizeQuery), 10, 64)
+	if err != nil || size < 0 {
+		return "", 0, errors.Errorf("invalid lfs url %s: size is invalid", request.URL)
+	}
+	return oid, size, nil
+}
+
+// getObject gets the download action by batch api and gets the object, range header is kept
+func (c *lfsSourceClient) getObject(request *source.Request) (*http.Response, string, error) {
+	oid, size, err := parseObject(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	action, err := c.batch(request, oid, size)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(request.Context(), http.MethodGet, action.Href, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	for k, v := range action.Header {
+		req.Header.Set(k, v)
+	}
+	if rg := request.Header.Get(headers.Range); rg != "" {
+		req.Header.Set(headers.Range, rg)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = source.CheckResponseCode(resp.StatusCode, []int{http.StatusOK, http.StatusPartialContent}); err != nil {
+		resp.Body.Close()
+		return nil, "", err
+	}
+	return resp, oid, nil
+}
+
+// batch requests the download action of object by git lfs batch api
+func (c *lfsSourceClient) batch(request *source.Request, oid string, size int64) (*lfsAction, error) {
+	body, err := json.Marshal(&lfsBatchRequest{
+		Operation: "download",
+		Transfers: []string{"basic"},
+		Objects:   []lfsObject{{OID: oid, Size: size}},
+		HashAlgo:  "sha256",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(request.Context(), http.MethodPost, batchURL(request), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(headers.Accept, mediaTypeLFS)
+	req.Header.Set(headers.ContentType, mediaTypeLFS)
+	if username := request.Header.Get(lfsUsername); username != "" {
+		req.SetBasicAuth(username, request.Header.Get(lfsPassword))
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("lfs batch api of %s: unexpected status code %d", req.URL.Host, resp.StatusCode)
+	}
+
+	var result lfsBatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, errors.Wrap(err, "decode lfs batch response")
+	}
+	if result.Transfer != "" && result.Transfer != "basic" {
+		return nil, errors.Errorf("unsupported lfs transfer %s", result.Transfer)
+	}
+	for _, object := range result.Objects {
+		if object.OID != oid {
+			continue
+		}
+		if object.Error != nil {
+			return nil, errors.Errorf("lfs object %s: %d %s", oid, object.Error.Code, object.Error.Message)
+		}
+		if object.Actions == nil || object.Actions.Download == nil {
+			return nil, errors.Errorf("lfs object %s: download action is empty", oid)
+		}
+		return object.Actions.Download, nil
+	}
+	return nil, errors.Errorf("lfs object %s not found in batch response", oid)
+}
+
+// batchURL returns the batch api url of repository, like https://github.com/org/repo.git/info/lfs/objects/batch
+func batchURL(request *source.Request) string {
+	scheme := "https"
+	if plain, _ := strconv.ParseBool(request.Header.Get(lfsPlainHTTP)); plain {
+		scheme = "http"
+	}
+	repository := strings.TrimSuffix(request.URL.Path, "/")
+	if !strings.HasSuffix(repository, ".git") {
+		repository += ".git"
+	}
+	u := url.URL{
+		Scheme: scheme,
+		Host:   request.URL.Host,
+		Path:   repository + "/info/lfs/objects/batch",
+	}
+	return u.String()
+}
+
+// newObjectResponse returns the response of lfs object, the whole object is verified with oid
+func newObjectResponse(request *source.Request, resp *http.Response, oid string) (*source.Response, error) {
+	body := resp.Body
+	if oid != "" && request.Header.Get(headers.Range) == "" {
+		reader, err := digestutils.NewDigestReaderWithAlgorithm(logger.With("url", request.URL.String()),
+			resp.Body, digestutils.Sha256Hash, oid)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		body = &readCloser{Reader: reader, Closer: resp.Body}
+	}
+	return source.NewResponse(
+		body,
+		source.WithContentLength(resp.ContentLength),
+		source.WithExpireInfo(
+			source.ExpireInfo{
+				LastModified: resp.Header.Get(headers.LastModified),
+				ETag:         resp.Header.Get(headers.ETag),
+			},
+		)), nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
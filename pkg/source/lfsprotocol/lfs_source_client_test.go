@@ -0,0 +1,272 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lfsprotocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/opencontainers/go-digest"
+	testifyassert "github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/source"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+const (
+	testModel   = "model weights"
+	testConfig  = `{"hidden_size": 768}`
+	testToken   = "hf_token"
+	testLFSUser = "user"
+)
+
+var testOID = digest.FromString(testModel).Encoded()
+
+// newObjectServer serves objects by oid, like the storage of lfs servers or cdn of huggingface,
+// the GET requests are counted in gets
+func newObjectServer(t *testing.T, objects map[string]string, gets *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("authorization header must not be sent to object server")
+		}
+		if r.Method == http.MethodGet {
+			gets.Inc()
+		}
+		content, ok := objects[strings.TrimPrefix(r.URL.Path, "/objects/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+}
+
+func newLFSServer(t *testing.T, objectServer *httptest.Server) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/org/repo.git/info/lfs/objects/batch" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if username, _, _ := r.BasicAuth(); username != testLFSUser {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var batch lfsBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("decode batch request: %s", err)
+		}
+
+		var resp lfsBatchResponse
+		resp.Transfer = "basic"
+		for _, object := range batch.Objects {
+			o := lfsObject{OID: object.OID, Size: object.Size}
+			o.Actions = &struct {
+				Download *lfsAction `json:"download"`
+			}{Download: &lfsAction{Href: fmt.Sprintf("%s/objects/%s", objectServer.URL, object.OID)}}
+			resp.Objects = append(resp.Objects, o)
+		}
+		w.Header().Set("Content-Type", mediaTypeLFS)
+		json.NewEncoder(w).Encode(&resp)
+	}))
+}
+
+func TestLFSSourceClient_Download(t *testing.T) {
+	assert := testifyassert.New(t)
+	var gets atomic.Int32
+	objectServer := newObjectServer(t, map[string]string{
+		testOID:                                 testModel,
+		digest.FromString("tampered").Encoded(): "not tampered",
+	}, &gets)
+	defer objectServer.Close()
+	lfsServer := newLFSServer(t, objectServer)
+	defer lfsServer.Close()
+
+	newRequest := func(oid string, size int) *source.Request {
+		u, _ := url.Parse(lfsServer.URL)
+		request, err := source.NewRequest(fmt.Sprintf("lfs://%s/org/repo?oid=%s&size=%d", u.Host, oid, size))
+		assert.Nil(err)
+		request.Header.Set(lfsPlainHTTP, "true")
+		request.Header.Set(lfsUsername, testLFSUser)
+		request.Header.Set(lfsPassword, "password")
+		return request
+	}
+	client := newLFSSourceClient()
+
+	length, err := client.GetContentLength(newRequest(testOID, len(testModel)))
+	assert.Nil(err)
+	assert.Equal(int64(len(testModel)), length)
+	assert.Equal(int32(0), gets.Load(), "content length is the size in url")
+
+	request := newRequest(testOID, len(testModel))
+	support, err := client.IsSupportRange(request)
+	assert.Nil(err)
+	assert.True(support)
+	assert.Empty(request.Header.Get(headers.Range), "probe range must not leak into request")
+
+	response, err := client.Download(newRequest(testOID, len(testModel)))
+	assert.Nil(err)
+	data, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Nil(err)
+	assert.Equal(testModel, string(data))
+
+	request = newRequest(testOID, len(testModel))
+	request.Header.Set(source.Range, "6-12")
+	response, err = client.Download(adaptor(request))
+	assert.Nil(err)
+	data, err = io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Nil(err)
+	assert.Equal("weights", string(data))
+
+	response, err = client.Download(newRequest(digest.FromString("tampered").Encoded(), 12))
+	assert.Nil(err)
+	_, err = io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.ErrorIs(err, digestutils.ErrDigestNotMatch)
+
+	_, err = client.Download(newRequest("", 0))
+	assert.NotNil(err)
+}
+
+func newHubServer(t *testing.T, objectServer *httptest.Server) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/api/models/org/model/tree/main" && r.URL.Query().Get("cursor") == "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/models/org/model/tree/main?recursive=true&cursor=1>; rel="next"`, "http://"+r.Host))
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"type": "directory", "path": "onnx"},
+				{"type": "file", "path": "config.json", "size": len(testConfig)},
+			})
+		case r.URL.Path == "/api/models/org/model/tree/main":
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"type": "file", "path": "onnx/model.bin", "size": len(testModel),
+					"lfs": map[string]interface{}{"oid": testOID, "size": len(testModel)}},
+			})
+		case r.URL.Path == "/org/model/resolve/main/config.json":
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(testConfig))
+		case r.URL.Path == "/org/model/resolve/main/onnx/model.bin":
+			w.Header().Set(headerLinkedETag, fmt.Sprintf("%q", testOID))
+			http.Redirect(w, r, fmt.Sprintf("%s/objects/%s", objectServer.URL, testOID), http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestHuggingFaceSourceClient_ListAndDownload(t *testing.T) {
+	assert := testifyassert.New(t)
+	var gets atomic.Int32
+	objectServer := newObjectServer(t, map[string]string{testOID: testModel}, &gets)
+	defer objectServer.Close()
+	hubServer := newHubServer(t, objectServer)
+	defer hubServer.Close()
+	hub, _ := url.Parse(hubServer.URL)
+
+	newRequest := func(rawURL string) *source.Request {
+		request, err := source.NewRequest(rawURL)
+		assert.Nil(err)
+		request.Header.Set(hfPlainHTTP, "true")
+		request.Header.Set(hfToken, testToken)
+		return request
+	}
+	client := newHuggingFaceSourceClient()
+
+	urls, err := client.List(newRequest(fmt.Sprintf("hf://%s/org/model/resolve/main", hub.Host)))
+	assert.Nil(err)
+	var got []string
+	for _, u := range urls {
+		got = append(got, u.String())
+	}
+	assert.Equal([]string{
+		fmt.Sprintf("hf://%s/org/model/resolve/main/config.json", hub.Host),
+		fmt.Sprintf("hf://%s/org/model/resolve/main/onnx/model.bin?oid=%s", hub.Host, testOID),
+	}, got)
+
+	expected := map[string]string{"config.json": testConfig, "model.bin": testModel}
+	for _, u := range urls {
+		request := newRequest(u.String())
+		length, err := client.GetContentLength(request)
+		assert.Nil(err)
+		assert.Equal(int64(len(expected[u.Path[strings.LastIndex(u.Path, "/")+1:]])), length)
+		support, err := client.IsSupportRange(request)
+		assert.Nil(err)
+		assert.True(support)
+		assert.Empty(request.Header.Get(headers.Range), "probe range must not leak into request")
+	}
+	_, err = client.GetLastModified(newRequest(urls[1].String()))
+	assert.Nil(err)
+	// only the range probe of lfs file gets the object
+	assert.Equal(int32(1), gets.Load())
+
+	for _, u := range urls {
+		response, err := client.Download(newRequest(u.String()))
+		assert.Nil(err)
+		data, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Nil(err)
+		assert.Equal(expected[u.Path[strings.LastIndex(u.Path, "/")+1:]], string(data))
+	}
+
+	expired, err := client.IsExpired(newRequest(urls[1].String()), &source.ExpireInfo{})
+	assert.Nil(err)
+	assert.False(expired)
+
+	// the same lfs object in another revision shares the task, but not in another repository
+	assert.Equal(idgen.TaskID(urls[1].String(), nil),
+		idgen.TaskID(fmt.Sprintf("hf://%s/org/model/resolve/v1/onnx/model.bin?oid=%s", hub.Host, testOID), nil))
+	assert.NotEqual(idgen.TaskID(urls[1].String(), nil),
+		idgen.TaskID(fmt.Sprintf("lfs://github.com/org/repo?oid=%s&size=%d", testOID, len(testModel)), nil))
+
+	// the file is not the lfs object with the oid, even for ranged request
+	request := newRequest(fmt.Sprintf("hf://%s/org/model/resolve/main/config.json?oid=%s", hub.Host, testOID))
+	request.Header.Set(headers.Range, "bytes=0-1")
+	_, err = client.Download(request)
+	assert.NotNil(err)
+}
+
+func TestParseHFReference(t *testing.T) {
+	assert := testifyassert.New(t)
+	u, _ := url.Parse("hf://huggingface.co/datasets/org/data/resolve/v1.0/train/part-0.parquet")
+	ref, err := parseHFReference(u)
+	assert.Nil(err)
+	assert.Equal(&hfReference{
+		host:       "huggingface.co",
+		kind:       "datasets",
+		repository: "org/data",
+		revision:   "v1.0",
+		file:       "train/part-0.parquet",
+	}, ref)
+
+	u, _ = url.Parse("hf://huggingface.co/gpt2/blob/main/config.json")
+	_, err = parseHFReference(u)
+	assert.NotNil(err)
+}
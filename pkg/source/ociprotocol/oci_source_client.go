@@ -0,0 +1,296 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ociprotocol
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-http-utils/headers"
+	"github.com/pkg/errors"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/source"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
+)
+
+const OCIClient = "oci"
+
+// header keys for oci client
+const (
+	username  = "ociUsername"
+	password  = "ociPassword"
+	plainHTTP = "ociPlainHTTP"
+)
+
+var _ source.ResourceClient = (*ociSourceClient)(nil)
+var _ source.ResourceLister = (*ociSourceClient)(nil)
+
+func init() {
+	if err := source.Register(OCIClient, NewOCISourceClient(), adaptor); err != nil {
+		panic(err)
+	}
+}
+
+func adaptor(request *source.Request) *source.Request {
+	clonedRequest := request.Clone(request.Context())
+	if request.Header.Get(source.Range) != "" {
+		clonedRequest.Header.Set(headers.Range, fmt.Sprintf("bytes=%s", request.Header.Get(source.Range)))
+		clonedRequest.Header.Del(source.Range)
+	}
+	return clonedRequest
+}
+
+func NewOCISourceClient(opts ...OCISourceClientOption) source.ResourceClient {
+	return newOCISourceClient(opts...)
+}
+
+func newOCISourceClient(opts ...OCISourceClientOption) *ociSourceClient {
+	sourceClient := &ociSourceClient{
+		httpClient: http.DefaultClient,
+		tokens:     sync.Map{},
+	}
+	for i := range opts {
+		opts[i](sourceClient)
+	}
+	return sourceClient
+}
+
+type OCISourceClientOption func(p *ociSourceClient)
+
+// WithHTTPClient sets the http client used to access registries
+func WithHTTPClient(client *http.Client) OCISourceClientOption {
+	return func(sourceClient *ociSourceClient) {
+		sourceClient.httpClient = client
+	}
+}
+
+// ociSourceClient is an implementation of the interface of source.ResourceClient,
+// it downloads layers of artifacts in oci registries, like oras pull.
+type ociSourceClient struct {
+	httpClient *http.Client
+	// registry_scope_username -> bearer token
+	tokens sync.Map
+}
+
+// credential is the registry credential in request header
+type credential struct {
+	username  string
+	password  string
+	plainHTTP bool
+}
+
+func (c *credential) scheme() string {
+	if c.plainHTTP {
+		return "http"
+	}
+	return "https"
+}
+
+func getCredential(header source.Header) (*credential, error) {
+	auth := &credential{
+		username: header.Get(username),
+		password: header.Get(password),
+	}
+	if v := header.Get(plainHTTP); v != "" {
+		var err error
+		if auth.plainHTTP, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.Wrapf(err, "parse %s", plainHTTP)
+		}
+	}
+	return auth, nil
+}
+
+// GetContentLength returns the size of layer descriptor, the length of range is returned for ranged request
+func (c *ociSourceClient) GetContentLength(request *source.Request) (int64, error) {
+	ref, err := parseReference(request.URL)
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
+	layer, err := c.resolveLayer(request, ref)
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
+	rg := request.Header.Get(headers.Range)
+	if rg == "" {
+		return layer.Size, nil
+	}
+	r, err := rangeutils.ParseRange(strings.TrimPrefix(rg, "bytes="), uint64(layer.Size))
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
+	return int64(r.Length()), nil
+}
+
+// IsSupportRange probes the blob with a one byte range
+func (c *ociSourceClient) IsSupportRange(request *source.Request) (bool, error) {
+	ref, err := parseReference(request.URL)
+	if err != nil {
+		return false, err
+	}
+	auth, err := getCredential(request.Header)
+	if err != nil {
+		return false, err
+	}
+	layer, err := c.resolveLayer(request, ref)
+	if err != nil {
+		return false, err
+	}
+
+	header := http.Header{}
+	header.Set(headers.Range, "bytes=0-0")
+	resp, err := c.doRequest(request.Context(), http.MethodGet, ref, "blobs/"+layer.Digest.String(), header, auth)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if err = source.CheckResponseCode(resp.StatusCode, []int{http.StatusOK, http.StatusPartialContent}); err != nil {
+		return false, err
+	}
+	return resp.StatusCode == http.StatusPartialContent, nil
+}
+
+// IsExpired checks whether the tag points to another layer, layers referenced by digest never expire
+func (c *ociSourceClient) IsExpired(request *source.Request, info *source.ExpireInfo) (bool, error) {
+	ref, err := parseReference(request.URL)
+	if err != nil {
+		return false, err
+	}
+	if ref.isDigest() {
+		return false, nil
+	}
+	layer, err := c.resolveLayer(request, ref)
+	if err != nil {
+		return false, err
+	}
+	return layer.Digest.String() != info.ETag, nil
+}
+
+func (c *ociSourceClient) Download(request *source.Request) (*source.Response, error) {
+	resp, layer, err := c.getBlob(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body := resp.Body
+	// verify the whole blob with layer digest, ranged contents are verified by piece digests
+	if request.Header.Get(headers.Range) == "" {
+		reader, err := digestutils.NewDigestReaderWithAlgorithm(logger.With("url", request.URL.String()),
+			resp.Body, layer.Digest.Algorithm(), layer.Digest.Encoded())
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		body = &readCloser{Reader: reader, Closer: resp.Body}
+	}
+	return source.NewResponse(
+		body,
+		source.WithContentLength(resp.ContentLength),
+		source.WithExpireInfo(
+			source.ExpireInfo{
+				ETag: layer.Digest.String(),
+			},
+		)), nil
+}
+
+// GetLastModified returns -1 as blobs are content addressable and have no modification time
+func (c *ociSourceClient) GetLastModified(request *source.Request) (int64, error) {
+	return -1, nil
+}
+
+// List lists all layers in the manifest, the layer name is the title annotation or the digest
+func (c *ociSourceClient) List(request *source.Request) ([]*url.URL, error) {
+	ref, err := parseReference(request.URL)
+	if err != nil {
+		return nil, err
+	}
+	if ref.layer != "" {
+		return []*url.URL{request.URL}, nil
+	}
+	auth, err := getCredential(request.Header)
+	if err != nil {
+		return nil, err
+	}
+	m, err := c.resolveManifest(request.Context(), ref, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	base := ref.manifestURL(request.URL)
+	var urls []*url.URL
+	for _, layer := range m.Layers {
+		u := *base
+		u.Path = path.Join(base.Path, layer.name())
+		urls = append(urls, &u)
+	}
+	return urls, nil
+}
+
+// resolveLayer returns the layer descriptor of the request
+func (c *ociSourceClient) resolveLayer(request *source.Request, ref *reference) (descriptor, error) {
+	auth, err := getCredential(request.Header)
+	if err != nil {
+		return descriptor{}, err
+	}
+	m, err := c.resolveManifest(request.Context(), ref, auth)
+	if err != nil {
+		return descriptor{}, err
+	}
+	return m.findLayer(ref.layer)
+}
+
+// getBlob resolves the layer of request and gets its blob, range header is kept
+func (c *ociSourceClient) getBlob(request *source.Request) (*http.Response, descriptor, error) {
+	ref, err := parseReference(request.URL)
+	if err != nil {
+		return nil, descriptor{}, err
+	}
+	auth, err := getCredential(request.Header)
+	if err != nil {
+		return nil, descriptor{}, err
+	}
+	layer, err := c.resolveLayer(request, ref)
+	if err != nil {
+		return nil, descriptor{}, err
+	}
+
+	header := http.Header{}
+	if rg := request.Header.Get(headers.Range); rg != "" {
+		header.Set(headers.Range, rg)
+	}
+	resp, err := c.doRequest(request.Context(), http.MethodGet, ref, "blobs/"+layer.Digest.String(), header, auth)
+	if err != nil {
+		return nil, descriptor{}, err
+	}
+	if err = source.CheckResponseCode(resp.StatusCode, []int{http.StatusOK, http.StatusPartialContent}); err != nil {
+		resp.Body.Close()
+		return nil, descriptor{}, err
+	}
+	return resp, layer, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,301 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ociprotocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/source"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+const (
+	testToken      = "test-token"
+	testRepository = "charts/nginx"
+)
+
+var testLastModified = time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+type fakeRegistry struct {
+	*httptest.Server
+	blobs     map[digest.Digest]string
+	manifests map[string][]byte
+	// tokenRequests counts requests to the token realm
+	tokenRequests int
+	// blobRanges records the range header of blob requests
+	blobRanges []string
+}
+
+func newFakeRegistry(t *testing.T, layers map[string]string) *fakeRegistry {
+	r := &fakeRegistry{
+		blobs:     map[digest.Digest]string{},
+		manifests: map[string][]byte{},
+	}
+	m := manifest{MediaType: mediaTypeOCIManifest}
+	for name, content := range layers {
+		d := digest.FromString(content)
+		r.blobs[d] = content
+		m.Layers = append(m.Layers, descriptor{
+			MediaType:   "application/octet-stream",
+			Digest:      d,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{annotationTitle: name},
+		})
+	}
+	body, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.manifests["v1"] = body
+	r.manifests[digest.FromBytes(body).String()] = body
+
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	return r
+}
+
+func (r *fakeRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		r.tokenRequests++
+		if req.URL.Query().Get("scope") != fmt.Sprintf("repository:%s:pull", testRepository) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": testToken})
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer "+testToken {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, r.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	prefix := fmt.Sprintf("/v2/%s/", testRepository)
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	kind, ref := splitAPIPath(strings.TrimPrefix(req.URL.Path, prefix))
+	switch kind {
+	case "manifests":
+		body, ok := r.manifests[ref]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", mediaTypeOCIManifest)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(body).String())
+		w.Write(body)
+	case "blobs":
+		r.blobRanges = append(r.blobRanges, req.Header.Get("Range"))
+		content, ok := r.blobs[digest.Digest(ref)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, req, "", testLastModified, strings.NewReader(content))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// splitAPIPath splits "manifests/<reference>" or "blobs/<digest>"
+func splitAPIPath(p string) (string, string) {
+	idx := strings.IndexByte(p, '/')
+	if idx < 0 {
+		return p, ""
+	}
+	return p[:idx], p[idx+1:]
+}
+
+func newTestRequest(t *testing.T, registry *fakeRegistry, rawURL string) *source.Request {
+	u, err := url.Parse(registry.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := source.NewRequest(strings.Replace(rawURL, "registry", u.Host, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set(plainHTTP, "true")
+	return request
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		url       string
+		expected  *reference
+		expectErr bool
+	}{
+		{
+			url:      "oci://ghcr.io/charts/nginx",
+			expected: &reference{registry: "ghcr.io", repository: "charts/nginx", reference: "latest"},
+		},
+		{
+			url:      "oci://localhost:5000/charts/nginx:1.0.0",
+			expected: &reference{registry: "localhost:5000", repository: "charts/nginx", reference: "1.0.0"},
+		},
+		{
+			url:      "oci://ghcr.io/models/llama:v1/weights/model.bin",
+			expected: &reference{registry: "ghcr.io", repository: "models/llama", reference: "v1", layer: "weights/model.bin"},
+		},
+		{
+			url: "oci://ghcr.io/wasm/app@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855/app.wasm",
+			expected: &reference{registry: "ghcr.io", repository: "wasm/app",
+				reference: "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", layer: "app.wasm"},
+		},
+		{
+			url:       "oci://ghcr.io/wasm/app@sha256:invalid",
+			expectErr: true,
+		},
+		{
+			url:       "oci://ghcr.io/wasm/app:",
+			expectErr: true,
+		},
+		{
+			url:       "oci:///wasm/app:v1",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			u, err := url.Parse(tc.url)
+			assert.Nil(err)
+			ref, err := parseReference(u)
+			if tc.expectErr {
+				assert.NotNil(err)
+				return
+			}
+			assert.Nil(err)
+			assert.Equal(tc.expected, ref)
+		})
+	}
+}
+
+func TestOCISourceClient_ListAndDownload(t *testing.T) {
+	assert := testifyassert.New(t)
+	layers := map[string]string{
+		"Chart.yaml":  "name: nginx",
+		"values.yaml": "replicas: 3",
+	}
+	registry := newFakeRegistry(t, layers)
+	defer registry.Close()
+	client := newOCISourceClient()
+
+	urls, err := client.List(newTestRequest(t, registry, "oci://registry/charts/nginx:v1"))
+	assert.Nil(err)
+	assert.Len(urls, 2)
+
+	for _, u := range urls {
+		request := newTestRequest(t, registry, u.String())
+		name := strings.TrimPrefix(u.Path, "/charts/nginx:v1/")
+
+		length, err := client.GetContentLength(request)
+		assert.Nil(err)
+		assert.Equal(int64(len(layers[name])), length)
+		assert.Empty(registry.blobRanges, "content length is from layer descriptor")
+
+		response, err := client.Download(request)
+		assert.Nil(err)
+		data, err := io.ReadAll(response.Body)
+		assert.Nil(err)
+		response.Body.Close()
+		assert.Equal(layers[name], string(data))
+		registry.blobRanges = nil
+		assert.Equal(digest.FromString(layers[name]).String(), response.Header.Get(source.ETag))
+
+		expired, err := client.IsExpired(request, &source.ExpireInfo{ETag: digest.FromString(layers[name]).String()})
+		assert.Nil(err)
+		assert.False(expired)
+	}
+	// the token is cached after the first challenge
+	assert.Equal(1, registry.tokenRequests)
+
+	_, err = client.Download(newTestRequest(t, registry, "oci://registry/charts/nginx:v1"))
+	assert.NotNil(err, "layer name is required for multi-layer manifest")
+	_, err = client.Download(newTestRequest(t, registry, "oci://registry/charts/nginx:v1/none"))
+	assert.NotNil(err)
+}
+
+func TestOCISourceClient_RangeAndDigest(t *testing.T) {
+	assert := testifyassert.New(t)
+	registry := newFakeRegistry(t, map[string]string{"model.bin": "0123456789"})
+	defer registry.Close()
+	client := newOCISourceClient()
+
+	var manifestDigest string
+	for ref := range registry.manifests {
+		if strings.HasPrefix(ref, "sha256:") {
+			manifestDigest = ref
+		}
+	}
+	rawURL := "oci://registry/charts/nginx@" + manifestDigest
+
+	support, err := client.IsSupportRange(newTestRequest(t, registry, rawURL))
+	assert.Nil(err)
+	assert.True(support)
+	assert.Equal([]string{"bytes=0-0"}, registry.blobRanges)
+
+	request := newTestRequest(t, registry, rawURL)
+	request.Header.Set(source.Range, "2-5")
+	length, err := client.GetContentLength(adaptor(request))
+	assert.Nil(err)
+	assert.Equal(int64(4), length)
+	response, err := client.Download(adaptor(request))
+	assert.Nil(err)
+	data, err := io.ReadAll(response.Body)
+	assert.Nil(err)
+	response.Body.Close()
+	assert.Equal("2345", string(data))
+
+	// tamper the blob, the whole blob download must fail with digest mismatch
+	for d := range registry.blobs {
+		registry.blobs[d] = "9876543210"
+	}
+	response, err = client.Download(newTestRequest(t, registry, rawURL))
+	assert.Nil(err)
+	_, err = io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.ErrorIs(err, digestutils.ErrDigestNotMatch)
+}
+
+func TestParseChallenge(t *testing.T) {
+	assert := testifyassert.New(t)
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull"`)
+	assert.Equal("bearer", scheme)
+	assert.Equal(map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/alpine:pull",
+	}, params)
+
+	scheme, params = parseChallenge(`Basic realm="harbor"`)
+	assert.Equal("basic", scheme)
+	assert.Equal("harbor", params["realm"])
+}
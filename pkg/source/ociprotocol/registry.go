@@ -0,0 +1,366 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ociprotocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+
+	"github.com/go-http-utils/headers"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+const (
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	// annotationTitle is the layer file name used by oras
+	annotationTitle = "org.opencontainers.image.title"
+
+	defaultTag = "latest"
+
+	// maxManifestSize limits the manifest body, same as the limit of containerd
+	maxManifestSize = 4 * 1024 * 1024
+)
+
+var manifestAcceptHeader = strings.Join([]string{
+	mediaTypeOCIManifest,
+	mediaTypeOCIIndex,
+	mediaTypeDockerManifest,
+	mediaTypeDockerManifestList,
+}, ", ")
+
+// reference is the parsed form of oci://registry/repository[:tag|@digest][/layer]
+type reference struct {
+	registry   string
+	repository string
+	// reference is a tag or a digest
+	reference string
+	// layer is the title annotation or the digest of layer, empty means the only layer
+	layer string
+}
+
+func parseReference(u *url.URL) (*reference, error) {
+	if u.Host == "" {
+		return nil, errors.Errorf("invalid oci url %s: registry is empty", u)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) == 0 || segments[0] == "" {
+		return nil, errors.Errorf("invalid oci url %s: repository is empty", u)
+	}
+
+	// repository path components never contain ':' or '@', the first one with them carries the tag or digest
+	ref := &reference{registry: u.Host}
+	for i, segment := range segments {
+		if idx := strings.IndexAny(segment, "@:"); idx >= 0 {
+			if idx == 0 {
+				return nil, errors.Errorf("invalid oci url %s: repository is empty", u)
+			}
+			ref.repository = strings.Join(append(segments[:i:i], segment[:idx]), "/")
+			ref.reference = segment[idx+1:]
+			ref.layer = strings.Join(segments[i+1:], "/")
+			break
+		}
+	}
+	if ref.repository == "" {
+		ref.repository = strings.Join(segments, "/")
+		ref.reference = defaultTag
+	}
+	if ref.reference == "" {
+		return nil, errors.Errorf("invalid oci url %s: tag or digest is empty", u)
+	}
+	if strings.Contains(ref.reference, ":") {
+		if _, err := digest.Parse(ref.reference); err != nil {
+			return nil, errors.Wrapf(err, "invalid oci url %s", u)
+		}
+	}
+	return ref, nil
+}
+
+// isDigest returns whether the reference is content addressable
+func (r *reference) isDigest() bool {
+	return strings.Contains(r.reference, ":")
+}
+
+// manifestURL returns the url of the artifact with the tag or digest and without layer
+func (r *reference) manifestURL(u *url.URL) *url.URL {
+	sep := ":"
+	if r.isDigest() {
+		sep = "@"
+	}
+	return &url.URL{
+		Scheme:   u.Scheme,
+		Host:     u.Host,
+		Path:     fmt.Sprintf("/%s%s%s", r.repository, sep, r.reference),
+		RawQuery: u.RawQuery,
+	}
+}
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      digest.Digest     `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform,omitempty"`
+}
+
+// name returns the file name of layer
+func (d descriptor) name() string {
+	if title := d.Annotations[annotationTitle]; title != "" {
+		return title
+	}
+	return d.Digest.String()
+}
+
+// manifest covers the fields used in oci image manifest, oci image index and docker manifest v2
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Manifests []descriptor `json:"manifests,omitempty"`
+	Layers    []descriptor `json:"layers,omitempty"`
+}
+
+// findLayer returns the layer matching name, the only layer is returned when name is empty
+func (m *manifest) findLayer(name string) (descriptor, error) {
+	if name == "" {
+		if len(m.Layers) != 1 {
+			return descriptor{}, errors.Errorf("manifest contains %d layers, layer name is required", len(m.Layers))
+		}
+		return m.Layers[0], nil
+	}
+	for _, layer := range m.Layers {
+		if layer.name() == name || layer.Digest.String() == name {
+			return layer, nil
+		}
+	}
+	return descriptor{}, errors.Errorf("layer %s not found in manifest", name)
+}
+
+// resolveManifest fetches the manifest of reference, the manifest of current platform is selected from index
+func (c *ociSourceClient) resolveManifest(ctx context.Context, ref *reference, auth *credential) (*manifest, error) {
+	m, err := c.fetchManifest(ctx, ref, ref.reference, auth)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Manifests) == 0 {
+		return m, nil
+	}
+
+	selected := m.Manifests[0]
+	for _, desc := range m.Manifests {
+		if desc.Platform != nil && desc.Platform.OS == runtime.GOOS && desc.Platform.Architecture == runtime.GOARCH {
+			selected = desc
+			break
+		}
+	}
+	return c.fetchManifest(ctx, ref, selected.Digest.String(), auth)
+}
+
+func (c *ociSourceClient) fetchManifest(ctx context.Context, ref *reference, tagOrDigest string, auth *credential) (*manifest, error) {
+	header := http.Header{}
+	header.Set(headers.Accept, manifestAcceptHeader)
+	resp, err := c.doRequest(ctx, http.MethodGet, ref, "manifests/"+tagOrDigest, header, auth)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetch manifest %s/%s:%s: unexpected status code %d", ref.registry, ref.repository, tagOrDigest, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxManifestSize {
+		return nil, errors.Errorf("manifest exceeds %d bytes", maxManifestSize)
+	}
+
+	expected := resp.Header.Get("Docker-Content-Digest")
+	if strings.Contains(tagOrDigest, ":") {
+		expected = tagOrDigest
+	}
+	if expected != "" {
+		d, err := digest.Parse(expected)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse manifest digest")
+		}
+		if actual := d.Algorithm().FromBytes(body); actual != d {
+			return nil, errors.Errorf("manifest digest not match, desired: %s, actual: %s", d, actual)
+		}
+	}
+
+	m := &manifest{}
+	if err := json.Unmarshal(body, m); err != nil {
+		return nil, errors.Wrap(err, "unmarshal manifest")
+	}
+	return m, nil
+}
+
+// doRequest sends request to the registry api of repository, bearer token and basic auth challenges are handled
+func (c *ociSourceClient) doRequest(ctx context.Context, method string, ref *reference, path string, header http.Header, auth *credential) (*http.Response, error) {
+	u := url.URL{
+		Scheme: auth.scheme(),
+		Host:   ref.registry,
+		Path:   fmt.Sprintf("/v2/%s/%s", ref.repository, path),
+	}
+	scope := fmt.Sprintf("repository:%s:pull", ref.repository)
+	tokenKey := fmt.Sprintf("%s_%s_%s", ref.registry, scope, auth.username)
+
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		return req, nil
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	if token, ok := c.tokens.Load(tokenKey); ok {
+		req.Header.Set(headers.Authorization, "Bearer "+token.(string))
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	challenge := resp.Header.Get(headers.WWWAuthenticate)
+	if req, err = newRequest(); err != nil {
+		return nil, err
+	}
+	switch scheme, params := parseChallenge(challenge); scheme {
+	case "bearer":
+		if params["scope"] == "" {
+			params["scope"] = scope
+		}
+		token, err := c.fetchToken(ctx, params, auth)
+		if err != nil {
+			return nil, err
+		}
+		c.tokens.Store(tokenKey, token)
+		req.Header.Set(headers.Authorization, "Bearer "+token)
+	case "basic":
+		if auth.username == "" {
+			return nil, errors.Errorf("registry %s requires basic auth but no credential", ref.registry)
+		}
+		req.SetBasicAuth(auth.username, auth.password)
+	default:
+		return nil, errors.Errorf("registry %s returns unsupported auth challenge: %q", ref.registry, challenge)
+	}
+	return c.httpClient.Do(req)
+}
+
+// fetchToken requests a bearer token from the realm of challenge
+func (c *ociSourceClient) fetchToken(ctx context.Context, params map[string]string, auth *credential) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", errors.Errorf("invalid bearer realm %q", params["realm"])
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if auth.username != "" {
+		req.SetBasicAuth(auth.username, auth.password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("fetch token from %s: unexpected status code %d", realm.Host, resp.StatusCode)
+	}
+
+	var result struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", errors.Wrap(err, "decode token response")
+	}
+	if result.Token != "" {
+		return result.Token, nil
+	}
+	if result.AccessToken != "" {
+		return result.AccessToken, nil
+	}
+	return "", errors.New("token is empty")
+}
+
+// parseChallenge parses WWW-Authenticate header like: Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	scheme, rest := challenge, ""
+	if idx := strings.IndexByte(challenge, ' '); idx >= 0 {
+		scheme, rest = challenge[:idx], challenge[idx+1:]
+	}
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimLeft(rest, ", ") {
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if end := strings.IndexByte(rest, ','); end >= 0 {
+			value, rest = rest[:end], rest[end:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+	}
+	return strings.ToLower(scheme), params
+}
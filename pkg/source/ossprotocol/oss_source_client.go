@@ -19,7 +19,9 @@ package ossprotocol
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -39,7 +41,10 @@ const (
 	accessKeySecret = "accessKeySecret"
 )
 
+const listObjectsMaxKeys = 1000
+
 var _ source.ResourceClient = (*ossSourceClient)(nil)
+var _ source.ResourceLister = (*ossSourceClient)(nil)
 
 func init() {
 	if err := source.Register(OSSClient, NewOSSSourceClient(), adaptor); err != nil {
@@ -191,6 +196,49 @@ func (osc *ossSourceClient) GetLastModified(request *source.Request) (int64, err
 	return timeutils.UnixMillis(respHeader.Get(oss.HTTPHeaderLastModified)), nil
 }
 
+// List lists all objects with the url path as prefix, the path is treated as a directory
+func (osc *ossSourceClient) List(request *source.Request) ([]*url.URL, error) {
+	client, err := osc.getClient(request.Header)
+	if err != nil {
+		return nil, errors.Wrap(err, "get oss client")
+	}
+	bucket, err := client.Bucket(request.URL.Host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get oss bucket: %s", request.URL.Host)
+	}
+	prefix := strings.TrimPrefix(request.URL.Path, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	var (
+		urls   []*url.URL
+		marker string
+	)
+	for {
+		result, err := bucket.ListObjects(oss.Prefix(prefix), oss.Marker(marker), oss.MaxKeys(listObjectsMaxKeys))
+		if err != nil {
+			return nil, errors.Wrapf(err, "list oss objects with prefix: %s", prefix)
+		}
+		for _, object := range result.Objects {
+			// skip directory placeholder objects
+			if strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+			urls = append(urls, &url.URL{
+				Scheme: request.URL.Scheme,
+				Host:   request.URL.Host,
+				Path:   "/" + object.Key,
+			})
+		}
+		if !result.IsTruncated || result.NextMarker == "" {
+			break
+		}
+		marker = result.NextMarker
+	}
+	return urls, nil
+}
+
 func (osc *ossSourceClient) getClient(header source.Header) (*oss.Client, error) {
 	endpoint := header.Get(endpoint)
 	if stringutils.IsBlank(endpoint) {
@@ -0,0 +1,76 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ossprotocol
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/source"
+)
+
+const listBucketResultTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult>
+  <Name>bucket</Name>
+  <Prefix>dir/</Prefix>
+  <MaxKeys>1000</MaxKeys>
+  <IsTruncated>%t</IsTruncated>
+  <NextMarker>%s</NextMarker>
+  %s
+</ListBucketResult>`
+
+func TestOSSSourceClient_List(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	var prefixes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		prefixes = append(prefixes, query.Get("prefix"))
+		w.Header().Set("Content-Type", "application/xml")
+		switch query.Get("marker") {
+		case "":
+			fmt.Fprintf(w, listBucketResultTemplate, true, "dir/b",
+				"<Contents><Key>dir/</Key></Contents><Contents><Key>dir/a</Key></Contents><Contents><Key>dir/b</Key></Contents>")
+		case "dir/b":
+			fmt.Fprintf(w, listBucketResultTemplate, false, "",
+				"<Contents><Key>dir/sub/c</Key></Contents>")
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	request, err := source.NewRequest("oss://bucket/dir")
+	assert.Nil(err)
+	request.Header.Set(endpoint, server.URL)
+	request.Header.Set(accessKeyID, "id")
+	request.Header.Set(accessKeySecret, "secret")
+
+	urls, err := newOSSSourceClient().(*ossSourceClient).List(request)
+	assert.Nil(err)
+
+	var got []string
+	for _, u := range urls {
+		got = append(got, u.String())
+	}
+	assert.Equal([]string{"oss://bucket/dir/a", "oss://bucket/dir/b", "oss://bucket/dir/sub/c"}, got)
+	assert.Equal([]string{"dir/", "dir/"}, prefixes)
+}
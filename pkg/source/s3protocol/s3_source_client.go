@@ -0,0 +1,308 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3protocol
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/pkg/source"
+)
+
+const S3Client = "s3"
+
+// header keys for s3 client, when awsAccessKeyID and awsSecretAccessKey are absent,
+// credentials are loaded from the default aws chain: environment variables,
+// shared credentials file (~/.aws/credentials) and shared config file (~/.aws/config)
+const (
+	region           = "awsRegion"
+	endpoint         = "awsEndpoint"
+	accessKeyID      = "awsAccessKeyID"
+	secretAccessKey  = "awsSecretAccessKey"
+	sessionToken     = "awsSessionToken"
+	s3ForcePathStyle = "awsS3ForcePathStyle"
+
+	headerRange   = "Range"
+	defaultRegion = "us-east-1"
+
+	listObjectsMaxKeys = 1000
+)
+
+var _ source.ResourceClient = (*s3SourceClient)(nil)
+var _ source.ResourceLister = (*s3SourceClient)(nil)
+
+func init() {
+	if err := source.Register(S3Client, NewS3SourceClient(), adaptor); err != nil {
+		panic(err)
+	}
+}
+
+func adaptor(request *source.Request) *source.Request {
+	clonedRequest := request.Clone(request.Context())
+	if request.Header.Get(source.Range) != "" {
+		clonedRequest.Header.Set(headerRange, fmt.Sprintf("bytes=%s", request.Header.Get(source.Range)))
+		clonedRequest.Header.Del(source.Range)
+	}
+	return clonedRequest
+}
+
+func NewS3SourceClient(opts ...S3SourceClientOption) source.ResourceClient {
+	return newS3SourceClient(opts...)
+}
+
+func newS3SourceClient(opts ...S3SourceClientOption) *s3SourceClient {
+	sourceClient := &s3SourceClient{
+		clientMap: sync.Map{},
+	}
+	for i := range opts {
+		opts[i](sourceClient)
+	}
+	return sourceClient
+}
+
+type S3SourceClientOption func(p *s3SourceClient)
+
+// WithSharedConfigFiles sets the aws shared config files used when no credentials in request header
+func WithSharedConfigFiles(files ...string) S3SourceClientOption {
+	return func(sourceClient *s3SourceClient) {
+		sourceClient.sharedConfigFiles = files
+	}
+}
+
+// WithHTTPClient sets the http client used by s3 client
+func WithHTTPClient(client *http.Client) S3SourceClientOption {
+	return func(sourceClient *s3SourceClient) {
+		sourceClient.httpClient = client
+	}
+}
+
+// s3SourceClient is an implementation of the interface of source.ResourceClient.
+type s3SourceClient struct {
+	// region_endpoint_accessKeyID_secretAccessKey_sessionToken_forcePathStyle -> s3 client
+	clientMap         sync.Map
+	sharedConfigFiles []string
+	httpClient        *http.Client
+}
+
+func (sc *s3SourceClient) GetContentLength(request *source.Request) (int64, error) {
+	output, err := sc.headObject(request)
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
+	return aws.Int64Value(output.ContentLength), nil
+}
+
+func (sc *s3SourceClient) IsSupportRange(request *source.Request) (bool, error) {
+	if request.Header.Get(headerRange) == "" {
+		request.Header.Set(headerRange, "bytes=0-0")
+	}
+	if _, err := sc.headObject(request); err != nil {
+		if isNotFound(err) {
+			return false, source.ErrResourceNotReachable
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (sc *s3SourceClient) IsExpired(request *source.Request, info *source.ExpireInfo) (bool, error) {
+	output, err := sc.headObject(request)
+	if err != nil {
+		return false, err
+	}
+	return !(aws.StringValue(output.ETag) == info.ETag || formatLastModified(output.LastModified) == info.LastModified), nil
+}
+
+func (sc *s3SourceClient) Download(request *source.Request) (*source.Response, error) {
+	client, err := sc.getClient(request.Header)
+	if err != nil {
+		return nil, errors.Wrap(err, "get s3 client")
+	}
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(request.URL.Host),
+		Key:    aws.String(objectKey(request.URL)),
+	}
+	if rg := request.Header.Get(headerRange); rg != "" {
+		input.Range = aws.String(rg)
+	}
+	output, err := client.GetObjectWithContext(request.Context(), input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get s3 object: %s", request.URL.Path)
+	}
+	response := source.NewResponse(
+		output.Body,
+		source.WithContentLength(aws.Int64Value(output.ContentLength)),
+		source.WithExpireInfo(
+			source.ExpireInfo{
+				LastModified: formatLastModified(output.LastModified),
+				ETag:         aws.StringValue(output.ETag),
+			},
+		))
+	return response, nil
+}
+
+func (sc *s3SourceClient) GetLastModified(request *source.Request) (int64, error) {
+	output, err := sc.headObject(request)
+	if err != nil {
+		return -1, err
+	}
+	if output.LastModified == nil {
+		return 0, nil
+	}
+	return output.LastModified.UnixNano() / time.Millisecond.Nanoseconds(), nil
+}
+
+// List lists all objects with the url path as prefix, the path is treated as a directory
+func (sc *s3SourceClient) List(request *source.Request) ([]*url.URL, error) {
+	client, err := sc.getClient(request.Header)
+	if err != nil {
+		return nil, errors.Wrap(err, "get s3 client")
+	}
+	prefix := objectKey(request.URL)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	var urls []*url.URL
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(request.URL.Host),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int64(listObjectsMaxKeys),
+	}
+	err = client.ListObjectsV2PagesWithContext(request.Context(), input, func(output *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range output.Contents {
+			key := aws.StringValue(object.Key)
+			// skip directory placeholder objects
+			if strings.HasSuffix(key, "/") {
+				continue
+			}
+			urls = append(urls, &url.URL{
+				Scheme: request.URL.Scheme,
+				Host:   request.URL.Host,
+				Path:   "/" + key,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "list s3 objects with prefix: %s", prefix)
+	}
+	return urls, nil
+}
+
+func (sc *s3SourceClient) headObject(request *source.Request) (*s3.HeadObjectOutput, error) {
+	client, err := sc.getClient(request.Header)
+	if err != nil {
+		return nil, errors.Wrap(err, "get s3 client")
+	}
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(request.URL.Host),
+		Key:    aws.String(objectKey(request.URL)),
+	}
+	if rg := request.Header.Get(headerRange); rg != "" {
+		input.Range = aws.String(rg)
+	}
+	output, err := client.HeadObjectWithContext(request.Context(), input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "head s3 object: %s", request.URL.Path)
+	}
+	return output, nil
+}
+
+func (sc *s3SourceClient) getClient(header source.Header) (*s3.S3, error) {
+	var (
+		region          = header.Get(region)
+		endpoint        = header.Get(endpoint)
+		accessKeyID     = header.Get(accessKeyID)
+		secretAccessKey = header.Get(secretAccessKey)
+		sessionToken    = header.Get(sessionToken)
+		forcePathStyle  bool
+	)
+	if (accessKeyID == "") != (secretAccessKey == "") {
+		return nil, errors.New("awsAccessKeyID and awsSecretAccessKey must be set together")
+	}
+	if v := header.Get(s3ForcePathStyle); v != "" {
+		var err error
+		if forcePathStyle, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.Wrapf(err, "parse %s", s3ForcePathStyle)
+		}
+	}
+
+	clientKey := fmt.Sprintf("%s_%s_%s_%s_%s_%t", region, endpoint, accessKeyID, secretAccessKey, sessionToken, forcePathStyle)
+	if client, ok := sc.clientMap.Load(clientKey); ok {
+		return client.(*s3.S3), nil
+	}
+
+	cfg := aws.NewConfig().WithS3ForcePathStyle(forcePathStyle)
+	if region != "" {
+		cfg.WithRegion(region)
+	}
+	if endpoint != "" {
+		cfg.WithEndpoint(endpoint)
+	}
+	if accessKeyID != "" {
+		cfg.WithCredentials(credentials.NewStaticCredentials(accessKeyID, secretAccessKey, sessionToken))
+	}
+	if sc.httpClient != nil {
+		cfg.WithHTTPClient(sc.httpClient)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		SharedConfigState: session.SharedConfigEnable,
+		SharedConfigFiles: sc.sharedConfigFiles,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create aws session")
+	}
+	if aws.StringValue(sess.Config.Region) == "" {
+		sess.Config.WithRegion(defaultRegion)
+	}
+
+	actual, _ := sc.clientMap.LoadOrStore(clientKey, s3.New(sess))
+	return actual.(*s3.S3), nil
+}
+
+func objectKey(u *url.URL) string {
+	return strings.TrimPrefix(u.Path, "/")
+}
+
+func formatLastModified(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(http.TimeFormat)
+}
+
+func isNotFound(err error) bool {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) {
+		return reqErr.StatusCode() == http.StatusNotFound
+	}
+	return false
+}
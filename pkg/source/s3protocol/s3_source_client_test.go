@@ -0,0 +1,185 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3protocol
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/source"
+)
+
+const (
+	testBucket  = "bucket"
+	testContent = "hello dragonfly s3"
+	testETag    = `"5d41402abc4b2a76b9719d911017c592"`
+)
+
+var testLastModified = time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// newFakeS3Server returns a minimal path-style s3 server for objects in testBucket
+func newFakeS3Server(t *testing.T, objects map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/"+testBucket)
+		if path == "" || path == "/" {
+			listObjectsV2(w, r, objects)
+			return
+		}
+		content, ok := objects[strings.TrimPrefix(path, "/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", testETag)
+		w.Header().Set("Last-Modified", testLastModified.Format(http.TimeFormat))
+		status := http.StatusOK
+		if rg := r.Header.Get("Range"); rg != "" {
+			var start, end int
+			if _, err := fmt.Sscanf(rg, "bytes=%d-%d", &start, &end); err != nil {
+				t.Errorf("unexpected range %q", rg)
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+			content = content[start : end+1]
+			status = http.StatusPartialContent
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			io.WriteString(w, content)
+		}
+	}))
+}
+
+// listObjectsV2 returns one object per page to exercise continuation tokens
+func listObjectsV2(w http.ResponseWriter, r *http.Request, objects map[string]string) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	var keys []string
+	for _, key := range []string{"dir/", "dir/a", "dir/sub/b", "other"} {
+		if _, ok := objects[key]; ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	start := 0
+	if token := query.Get("continuation-token"); token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+	var body strings.Builder
+	body.WriteString(`<?xml version="1.0" encoding="UTF-8"?><ListBucketResult>`)
+	if start < len(keys) {
+		fmt.Fprintf(&body, "<Contents><Key>%s</Key><Size>%d</Size></Contents>", keys[start], len(objects[keys[start]]))
+	}
+	if start+1 < len(keys) {
+		fmt.Fprintf(&body, "<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>", start+1)
+	} else {
+		body.WriteString("<IsTruncated>false</IsTruncated>")
+	}
+	body.WriteString("</ListBucketResult>")
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, body.String())
+}
+
+func newTestRequest(t *testing.T, server *httptest.Server, rawURL string) *source.Request {
+	request, err := source.NewRequest(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set(endpoint, server.URL)
+	request.Header.Set(region, "us-east-1")
+	request.Header.Set(accessKeyID, "id")
+	request.Header.Set(secretAccessKey, "secret")
+	request.Header.Set(s3ForcePathStyle, "true")
+	return request
+}
+
+func TestS3SourceClient(t *testing.T) {
+	assert := testifyassert.New(t)
+	server := newFakeS3Server(t, map[string]string{
+		"dir/":      "",
+		"dir/a":     testContent,
+		"dir/sub/b": testContent,
+		"other":     testContent,
+	})
+	defer server.Close()
+	client := newS3SourceClient()
+
+	length, err := client.GetContentLength(newTestRequest(t, server, "s3://bucket/dir/a"))
+	assert.Nil(err)
+	assert.Equal(int64(len(testContent)), length)
+
+	support, err := client.IsSupportRange(newTestRequest(t, server, "s3://bucket/dir/a"))
+	assert.Nil(err)
+	assert.True(support)
+
+	_, err = client.IsSupportRange(newTestRequest(t, server, "s3://bucket/dir/none"))
+	assert.ErrorIs(err, source.ErrResourceNotReachable)
+
+	lastModified, err := client.GetLastModified(newTestRequest(t, server, "s3://bucket/dir/a"))
+	assert.Nil(err)
+	assert.Equal(testLastModified.UnixNano()/time.Millisecond.Nanoseconds(), lastModified)
+
+	expired, err := client.IsExpired(newTestRequest(t, server, "s3://bucket/dir/a"), &source.ExpireInfo{ETag: testETag})
+	assert.Nil(err)
+	assert.False(expired)
+	expired, err = client.IsExpired(newTestRequest(t, server, "s3://bucket/dir/a"), &source.ExpireInfo{ETag: `"changed"`})
+	assert.Nil(err)
+	assert.True(expired)
+
+	request := adaptor(func() *source.Request {
+		r := newTestRequest(t, server, "s3://bucket/dir/a")
+		r.Header.Set(source.Range, "6-14")
+		return r
+	}())
+	response, err := client.Download(request)
+	assert.Nil(err)
+	data, err := io.ReadAll(response.Body)
+	assert.Nil(err)
+	response.Body.Close()
+	assert.Equal(testContent[6:15], string(data))
+	assert.Equal(testETag, response.Header.Get(source.ETag))
+	assert.Equal(testLastModified.Format(http.TimeFormat), response.Header.Get(source.LastModified))
+
+	urls, err := client.List(newTestRequest(t, server, "s3://bucket/dir"))
+	assert.Nil(err)
+	var got []string
+	for _, u := range urls {
+		got = append(got, u.String())
+	}
+	assert.Equal([]string{"s3://bucket/dir/a", "s3://bucket/dir/sub/b"}, got)
+}
+
+func TestS3SourceClient_InvalidCredentials(t *testing.T) {
+	assert := testifyassert.New(t)
+	request, err := source.NewRequest("s3://bucket/key")
+	assert.Nil(err)
+	request.Header.Set(accessKeyID, "id")
+
+	_, err = newS3SourceClient().GetContentLength(request)
+	assert.NotNil(err)
+}
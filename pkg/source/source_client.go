@@ -254,6 +254,14 @@ func (c *clientWrapper) GetLastModified(request *Request) (int64, error) {
 	return c.rc.GetLastModified(c.adapter(request))
 }
 
+func (c *clientWrapper) List(request *Request) ([]*url.URL, error) {
+	lister, ok := c.rc.(ResourceLister)
+	if !ok {
+		return nil, errors.Wrapf(ErrClientNotSupportList, "scheme: %s", request.URL.Scheme)
+	}
+	return lister.List(c.adapter(request))
+}
+
 func GetContentLength(request *Request) (int64, error) {
 	client, ok := _defaultManager.GetClient(request.URL.Scheme)
 	if !ok {
@@ -0,0 +1,67 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package source
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClient struct{}
+
+func (c *fakeClient) GetContentLength(request *Request) (int64, error) { return -1, nil }
+
+func (c *fakeClient) IsSupportRange(request *Request) (bool, error) { return false, nil }
+
+func (c *fakeClient) IsExpired(request *Request, info *ExpireInfo) (bool, error) { return false, nil }
+
+func (c *fakeClient) Download(request *Request) (*Response, error) { return nil, nil }
+
+func (c *fakeClient) GetLastModified(request *Request) (int64, error) { return -1, nil }
+
+type fakeLister struct {
+	fakeClient
+}
+
+func (l *fakeLister) List(request *Request) ([]*url.URL, error) {
+	// the adapter of registered client is applied
+	return []*url.URL{{Scheme: request.URL.Scheme, Host: request.URL.Host, Path: request.Header.Get("path")}}, nil
+}
+
+func TestList(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(Register("lister", &fakeLister{}, func(request *Request) *Request {
+		request.Header.Set("path", "/adapted")
+		return request
+	}))
+	defer UnRegister("lister")
+	assert.Nil(Register("nolister", &fakeClient{}, func(request *Request) *Request { return request }))
+	defer UnRegister("nolister")
+
+	request, err := NewRequest("lister://host/dir")
+	assert.Nil(err)
+	urls, err := List(request)
+	assert.Nil(err)
+	assert.Equal([]*url.URL{{Scheme: "lister", Host: "host", Path: "/adapted"}}, urls)
+
+	request, err = NewRequest("nolister://host/dir")
+	assert.Nil(err)
+	_, err = List(request)
+	assert.ErrorIs(err, ErrClientNotSupportList)
+}
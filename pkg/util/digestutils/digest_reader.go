@@ -18,10 +18,10 @@ package digestutils
 
 import (
 	"crypto/md5"
-	"encoding/hex"
 	"hash"
 	"io"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
@@ -33,15 +33,23 @@ var (
 
 // digestReader reads stream with RateLimiter.
 type digestReader struct {
-	r      io.Reader
-	hash   hash.Hash
-	digest string
+	r io.Reader
+	// hash is used to validate digest
+	hash      hash.Hash
+	algorithm digest.Algorithm
+	digest    string
+	// extraHashes are only calculated, not validated
+	extraHashes map[digest.Algorithm]hash.Hash
 	*logger.SugaredLoggerOnWith
 }
 
 type DigestReader interface {
 	io.Reader
+	// Digest returns the md5 digest of contents
 	Digest() string
+	// DigestOf returns the digest of contents calculated with algorithm,
+	// empty string is returned when the algorithm is not calculated
+	DigestOf(algorithm digest.Algorithm) string
 }
 
 // TODO add AF_ALG digest https://github.com/golang/sys/commit/e24f485414aeafb646f6fca458b0bf869c0880a1
@@ -54,12 +62,43 @@ func NewDigestReader(log *logger.SugaredLoggerOnWith, reader io.Reader, digest .
 	return &digestReader{
 		SugaredLoggerOnWith: log,
 		digest:              d,
-		// TODO support more digest method like sha1, sha256
-		hash: md5.New(),
-		r:    reader,
+		algorithm:           Md5Hash,
+		hash:                md5.New(),
+		r:                   reader,
 	}
 }
 
+// NewDigestReaderWithAlgorithm validates contents with the digest calculated by algorithm,
+// the digests of extra algorithms are calculated at the same time, like md5 for old peers
+func NewDigestReaderWithAlgorithm(log *logger.SugaredLoggerOnWith, reader io.Reader,
+	algorithm digest.Algorithm, expected string, extra ...digest.Algorithm) (io.Reader, error) {
+	h := CreateHash(algorithm.String())
+	if h == nil {
+		return nil, errors.Errorf("digest algorithm %s is not supported", algorithm)
+	}
+	dr := &digestReader{
+		SugaredLoggerOnWith: log,
+		digest:              expected,
+		algorithm:           algorithm,
+		hash:                h,
+		r:                   reader,
+	}
+	for _, algo := range extra {
+		if algo == algorithm {
+			continue
+		}
+		eh := CreateHash(algo.String())
+		if eh == nil {
+			return nil, errors.Errorf("digest algorithm %s is not supported", algo)
+		}
+		if dr.extraHashes == nil {
+			dr.extraHashes = map[digest.Algorithm]hash.Hash{}
+		}
+		dr.extraHashes[algo] = eh
+	}
+	return dr, nil
+}
+
 func (dr *digestReader) Read(p []byte) (int, error) {
 	n, err := dr.r.Read(p)
 	if err != nil && err != io.EOF {
@@ -67,19 +106,33 @@ func (dr *digestReader) Read(p []byte) (int, error) {
 	}
 	if n > 0 {
 		dr.hash.Write(p[:n])
+		for _, h := range dr.extraHashes {
+			h.Write(p[:n])
+		}
 	}
 	if err == io.EOF && dr.digest != "" {
-		digest := dr.Digest()
-		if digest != dr.digest {
-			dr.Warnf("digest not match, desired: %s, actual: %s", dr.digest, digest)
+		actual := ToHashString(dr.hash)
+		if actual != dr.digest {
+			dr.Warnf("%s digest not match, desired: %s, actual: %s", dr.algorithm, dr.digest, actual)
 			return n, ErrDigestNotMatch
 		}
-		dr.Debugf("digest match: %s", digest)
+		dr.Debugf("%s digest match: %s", dr.algorithm, actual)
 	}
 	return n, err
 }
 
-// Digest returns the digest of contents.
+// Digest returns the md5 digest of contents.
 func (dr *digestReader) Digest() string {
-	return hex.EncodeToString(dr.hash.Sum(nil)[:16])
+	return dr.DigestOf(Md5Hash)
+}
+
+// DigestOf returns the digest of contents calculated with algorithm.
+func (dr *digestReader) DigestOf(algorithm digest.Algorithm) string {
+	if algorithm == dr.algorithm {
+		return ToHashString(dr.hash)
+	}
+	if h, ok := dr.extraHashes[algorithm]; ok {
+		return ToHashString(h)
+	}
+	return ""
 }
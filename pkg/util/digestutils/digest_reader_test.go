@@ -19,6 +19,7 @@ package digestutils
 import (
 	"bytes"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
 	"io"
 	"os"
@@ -48,3 +49,32 @@ func TestNewDigestReader(t *testing.T) {
 	assert.Nil(err)
 	assert.Equal(testBytes, data)
 }
+
+func TestNewDigestReaderWithAlgorithm(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	testBytes := []byte("hello world")
+	md5Hash := md5.New()
+	md5Hash.Write(testBytes)
+	sha256Hash := sha256.New()
+	sha256Hash.Write(testBytes)
+
+	reader, err := NewDigestReaderWithAlgorithm(logger.With("test", "test"), bytes.NewBuffer(testBytes),
+		Sha256Hash, ToHashString(sha256Hash), Md5Hash)
+	assert.Nil(err)
+	data, err := io.ReadAll(reader)
+	assert.Nil(err)
+	assert.Equal(testBytes, data)
+	assert.Equal(ToHashString(sha256Hash), reader.(DigestReader).DigestOf(Sha256Hash))
+	assert.Equal(ToHashString(md5Hash), reader.(DigestReader).Digest())
+
+	reader, err = NewDigestReaderWithAlgorithm(logger.With("test", "test"), bytes.NewBuffer(testBytes),
+		Sha256Hash, ToHashString(md5Hash))
+	assert.Nil(err)
+	_, err = io.ReadAll(reader)
+	assert.Equal(ErrDigestNotMatch, err)
+	assert.Empty(reader.(DigestReader).Digest())
+
+	_, err = NewDigestReaderWithAlgorithm(logger.With("test", "test"), bytes.NewBuffer(testBytes), "sha1", "")
+	assert.NotNil(err)
+}
@@ -67,6 +67,7 @@ func New() *Config {
 			RetryBackSourceLimit: 5,
 			RetryLimit:           20,
 			RetryInterval:        200 * time.Millisecond,
+			HostLoadThreshold:    0.9,
 			GC: &GCConfig{
 				PeerGCInterval: 10 * time.Minute,
 				PeerTTL:        24 * time.Hour,
@@ -135,6 +136,10 @@ func (c *Config) Validate() error {
 		return errors.New("scheduler requires parameter retryInterval")
 	}
 
+	if c.Scheduler.HostLoadThreshold < 0 || c.Scheduler.HostLoadThreshold > 1 {
+		return errors.New("scheduler requires parameter hostLoadThreshold between 0 and 1")
+	}
+
 	if c.Scheduler.GC.PeerGCInterval <= 0 {
 		return errors.New("scheduler requires parameter peerGCInterval")
 	}
@@ -244,6 +249,10 @@ type SchedulerConfig struct {
 	// Retry scheduling interval
 	RetryInterval time.Duration `yaml:"retryInterval" mapstructure:"retryInterval"`
 
+	// Host whose load ratio of cpu or memory exceeds the threshold is not selected as parent,
+	// zero means no limit
+	HostLoadThreshold float64 `yaml:"hostLoadThreshold" mapstructure:"hostLoadThreshold"`
+
 	// Task and peer gc configuration
 	GC *GCConfig `yaml:"gc" mapstructure:"gc"`
 }
@@ -44,6 +44,7 @@ func TestConfig_Load(t *testing.T) {
 			RetryBackSourceLimit: 2,
 			RetryLimit:           10,
 			RetryInterval:        1 * time.Second,
+			HostLoadThreshold:    0.8,
 			GC: &GCConfig{
 				PeerGCInterval: 1 * time.Minute,
 				PeerTTL:        5 * time.Minute,
@@ -17,12 +17,14 @@
 package resource
 
 import (
+	"math"
 	"sync"
 	"time"
 
 	"go.uber.org/atomic"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
 )
 
@@ -83,6 +85,15 @@ type Host struct {
 	// UploadLoadLimit is upload load limit count
 	UploadLoadLimit *atomic.Int32
 
+	// CPURatio is cpu usage of host reported by dfdaemon
+	CPURatio *atomic.Float64
+
+	// MemRatio is memory usage of host reported by dfdaemon
+	MemRatio *atomic.Float64
+
+	// DiskRatio is disk space usage of host reported by dfdaemon
+	DiskRatio *atomic.Float64
+
 	// Peer sync map
 	Peers *sync.Map
 
@@ -112,6 +123,9 @@ func NewHost(rawHost *scheduler.PeerHost, options ...HostOption) *Host {
 		NetTopology:     rawHost.NetTopology,
 		Location:        rawHost.Location,
 		UploadLoadLimit: atomic.NewInt32(defaultUploadLoadLimit),
+		CPURatio:        atomic.NewFloat64(0),
+		MemRatio:        atomic.NewFloat64(0),
+		DiskRatio:       atomic.NewFloat64(0),
 		Peers:           &sync.Map{},
 		IsCDN:           false,
 		CreateAt:        atomic.NewTime(time.Now()),
@@ -190,3 +204,16 @@ func (h *Host) LeavePeers() {
 func (h *Host) FreeUploadLoad() int32 {
 	return h.UploadLoadLimit.Load() - int32(h.LenPeers())
 }
+
+// StoreHostLoad set host load reported by dfdaemon
+func (h *Host) StoreHostLoad(load *base.HostLoad) {
+	h.CPURatio.Store(float64(load.CpuRatio))
+	h.MemRatio.Store(float64(load.MemRatio))
+	h.DiskRatio.Store(float64(load.DiskRatio))
+}
+
+// HostLoadRatio return the usage of the most saturated resource of cpu and memory,
+// disk space is not the load of host, because dfdaemon keeps its cache until the gc threshold
+func (h *Host) HostLoadRatio() float64 {
+	return math.Max(h.CPURatio.Load(), h.MemRatio.Load())
+}
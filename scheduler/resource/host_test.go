@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
 )
 
@@ -447,3 +448,48 @@ func TestHost_FreeUploadLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestHost_StoreHostLoad(t *testing.T) {
+	tests := []struct {
+		name   string
+		load   *base.HostLoad
+		expect func(t *testing.T, host *Host)
+	}{
+		{
+			name: "host load is not reported",
+			expect: func(t *testing.T, host *Host) {
+				assert := assert.New(t)
+				assert.Equal(host.HostLoadRatio(), float64(0))
+			},
+		},
+		{
+			name: "cpu is the most saturated resource",
+			load: &base.HostLoad{CpuRatio: 0.75, MemRatio: 0.5, DiskRatio: 0.25},
+			expect: func(t *testing.T, host *Host) {
+				assert := assert.New(t)
+				assert.Equal(host.CPURatio.Load(), float64(0.75))
+				assert.Equal(host.MemRatio.Load(), float64(0.5))
+				assert.Equal(host.DiskRatio.Load(), float64(0.25))
+				assert.Equal(host.HostLoadRatio(), float64(0.75))
+			},
+		},
+		{
+			name: "disk space is not host load",
+			load: &base.HostLoad{CpuRatio: 0.25, MemRatio: 0.5, DiskRatio: 1},
+			expect: func(t *testing.T, host *Host) {
+				assert := assert.New(t)
+				assert.Equal(host.HostLoadRatio(), float64(0.5))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			host := NewHost(mockRawHost)
+			if tc.load != nil {
+				host.StoreHostLoad(tc.load)
+			}
+			tc.expect(t, host)
+		})
+	}
+}
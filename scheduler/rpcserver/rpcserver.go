@@ -84,3 +84,13 @@ func (s *Server) ReportPeerResult(ctx context.Context, req *scheduler.PeerResult
 func (s *Server) LeaveTask(ctx context.Context, req *scheduler.PeerTarget) (*empty.Empty, error) {
 	return new(empty.Empty), s.service.LeaveTask(ctx, req)
 }
+
+// AnnounceHost announces the host and its cached tasks, it works as the keepalive of the host
+func (s *Server) AnnounceHost(ctx context.Context, req *scheduler.AnnounceHostRequest) (*empty.Empty, error) {
+	return new(empty.Empty), s.service.AnnounceHost(ctx, req)
+}
+
+// LeaveHost makes the host and all its peers unschedulable
+func (s *Server) LeaveHost(ctx context.Context, req *scheduler.LeaveHostRequest) (*empty.Empty, error) {
+	return new(empty.Empty), s.service.LeaveHost(ctx, req)
+}
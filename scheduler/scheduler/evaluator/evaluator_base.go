@@ -32,7 +32,10 @@ const (
 	finishedPieceWeight float64 = 0.3
 
 	// Free load weight
-	freeLoadWeight = 0.2
+	freeLoadWeight = 0.1
+
+	// Host load weight
+	hostLoadWeight = 0.1
 
 	// host type affinity weight
 	hostTypeAffinityWeight = 0.2
@@ -86,6 +89,7 @@ func (eb *evaluatorBase) Evaluate(parent *resource.Peer, child *resource.Peer, t
 
 	return finishedPieceWeight*calculatePieceScore(parent, child, totalPieceCount) +
 		freeLoadWeight*calculateFreeLoadScore(parent.Host) +
+		hostLoadWeight*calculateHostLoadScore(parent.Host) +
 		hostTypeAffinityWeight*calculateHostTypeAffinityScore(parent) +
 		idcAffinityWeight*calculateIDCAffinityScore(parent.Host, child.Host) +
 		netTopologyAffinityWeight*calculateMultiElementAffinityScore(parent.Host.NetTopology, child.Host.NetTopology) +
@@ -115,6 +119,12 @@ func calculateFreeLoadScore(host *resource.Host) float64 {
 	return float64(totalLoad-int32(load)) / float64(totalLoad)
 }
 
+// calculateHostLoadScore 0.0~1.0 larger and better
+func calculateHostLoadScore(host *resource.Host) float64 {
+	// The most saturated resource of cpu and memory determines the load of host
+	return maxScore - host.HostLoadRatio()
+}
+
 // calculateHostTypeAffinityScore 0.0~1.0 larger and better
 func calculateHostTypeAffinityScore(peer *resource.Peer) float64 {
 	// When the task is downloaded for the first time,
@@ -314,6 +314,61 @@ func TestEvaluatorBase_calculateFreeLoadScore(t *testing.T) {
 	}
 }
 
+func TestEvaluatorBase_calculateHostLoadScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(host *resource.Host)
+		expect func(t *testing.T, score float64)
+	}{
+		{
+			name: "host load is not reported",
+			mock: func(host *resource.Host) {},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(1))
+			},
+		},
+		{
+			name: "cpu of host is overloaded",
+			mock: func(host *resource.Host) {
+				host.StoreHostLoad(&base.HostLoad{CpuRatio: 0.75, MemRatio: 0.5, DiskRatio: 0.5})
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0.25))
+			},
+		},
+		{
+			name: "disk space of host is exhausted",
+			mock: func(host *resource.Host) {
+				host.StoreHostLoad(&base.HostLoad{CpuRatio: 0.5, MemRatio: 0.25, DiskRatio: 1})
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0.5))
+			},
+		},
+		{
+			name: "memory of host is exhausted",
+			mock: func(host *resource.Host) {
+				host.StoreHostLoad(&base.HostLoad{CpuRatio: 0.5, MemRatio: 1, DiskRatio: 0.5})
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			host := resource.NewHost(mockRawHost)
+			tc.mock(host)
+			tc.expect(t, calculateHostLoadScore(host))
+		})
+	}
+}
+
 func TestEvaluatorBase_calculateHostTypeAffinityScore(t *testing.T) {
 	tests := []struct {
 		name   string
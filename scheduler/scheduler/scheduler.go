@@ -244,6 +244,11 @@ func (s *scheduler) filterParents(peer *resource.Peer, blocklist set.SafeSet) []
 			return true
 		}
 
+		if s.config.HostLoadThreshold > 0 && parent.Host.HostLoadRatio() > s.config.HostLoadThreshold {
+			peer.Log.Infof("parent %s is not selected because its host load %.2f exceeds threshold", parent.ID, parent.Host.HostLoadRatio())
+			return true
+		}
+
 		parents = append(parents, parent)
 		parentIDs = append(parentIDs, parent.ID)
 		return true
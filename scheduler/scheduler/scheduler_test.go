@@ -42,6 +42,7 @@ var (
 		RetryLimit:           2,
 		RetryBackSourceLimit: 1,
 		RetryInterval:        10 * time.Millisecond,
+		HostLoadThreshold:    0.9,
 		BackSourceCount:      mockTaskBackToSourceLimit,
 		Algorithm:            evaluator.DefaultAlgorithm,
 	}
@@ -713,6 +714,48 @@ func TestScheduler_FindParent(t *testing.T) {
 				assert.False(ok)
 			},
 		},
+		{
+			name: "parent host load exceeds threshold",
+			mock: func(peer *resource.Peer, mockPeer *resource.Peer, blocklist set.SafeSet) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				mockPeer.FSM.SetState(resource.PeerStateRunning)
+				peer.Task.StorePeer(mockPeer)
+				mockPeer.Pieces.Set(0)
+				mockPeer.Host.StoreHostLoad(&base.HostLoad{CpuRatio: 0.5, MemRatio: 0.95, DiskRatio: 0.5})
+			},
+			expect: func(t *testing.T, parent *resource.Peer, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+		{
+			name: "parent host load does not exceed threshold",
+			mock: func(peer *resource.Peer, mockPeer *resource.Peer, blocklist set.SafeSet) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				mockPeer.FSM.SetState(resource.PeerStateRunning)
+				peer.Task.StorePeer(mockPeer)
+				mockPeer.Pieces.Set(0)
+				mockPeer.Host.StoreHostLoad(&base.HostLoad{CpuRatio: 0.9, MemRatio: 0.5, DiskRatio: 0.5})
+			},
+			expect: func(t *testing.T, parent *resource.Peer, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+			},
+		},
+		{
+			name: "parent disk space exceeds threshold",
+			mock: func(peer *resource.Peer, mockPeer *resource.Peer, blocklist set.SafeSet) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				mockPeer.FSM.SetState(resource.PeerStateRunning)
+				peer.Task.StorePeer(mockPeer)
+				mockPeer.Pieces.Set(0)
+				mockPeer.Host.StoreHostLoad(&base.HostLoad{CpuRatio: 0.5, MemRatio: 0.5, DiskRatio: 0.95})
+			},
+			expect: func(t *testing.T, parent *resource.Peer, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+			},
+		},
 		{
 			name: "find parent",
 			mock: func(peer *resource.Peer, mockPeer *resource.Peer, blocklist set.SafeSet) {
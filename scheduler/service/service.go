@@ -155,12 +155,14 @@ func (s *Service) RegisterPeerTask(ctx context.Context, req *rpcscheduler.PeerTa
 				DstPid:  parent.ID,
 				DstAddr: fmt.Sprintf("%s:%d", parent.Host.IP, parent.Host.DownloadPort),
 				PieceInfo: &base.PieceInfo{
-					PieceNum:    firstPiece.PieceNum,
-					RangeStart:  firstPiece.RangeStart,
-					RangeSize:   firstPiece.RangeSize,
-					PieceMd5:    firstPiece.PieceMd5,
-					PieceOffset: firstPiece.PieceOffset,
-					PieceStyle:  firstPiece.PieceStyle,
+					PieceNum:        firstPiece.PieceNum,
+					RangeStart:      firstPiece.RangeStart,
+					RangeSize:       firstPiece.RangeSize,
+					PieceMd5:        firstPiece.PieceMd5,
+					PieceOffset:     firstPiece.PieceOffset,
+					PieceStyle:      firstPiece.PieceStyle,
+					DigestAlgorithm: firstPiece.DigestAlgorithm,
+					PieceDigest:     firstPiece.PieceDigest,
 				},
 			}
 
@@ -245,6 +247,11 @@ func (s *Service) ReportPieceResult(stream rpcscheduler.Scheduler_ReportPieceRes
 
 		peer.Log.Infof("receive piece: %#v %#v", piece, piece.PieceInfo)
 
+		// Update host load reported by dfdaemon
+		if piece.HostLoad != nil {
+			peer.Host.StoreHostLoad(piece.HostLoad)
+		}
+
 		if piece.PieceInfo != nil {
 			// Handle begin of piece
 			if piece.PieceInfo.PieceNum == common.BeginOfPiece {
@@ -347,6 +354,70 @@ func (s *Service) LeaveTask(ctx context.Context, req *rpcscheduler.PeerTarget) e
 	return nil
 }
 
+// AnnounceHost updates the host and makes its cached tasks available for scheduling
+func (s *Service) AnnounceHost(ctx context.Context, req *rpcscheduler.AnnounceHostRequest) error {
+	host := s.loadOrCreateHost(req.PeerHost)
+	host.UpdateAt.Store(time.Now())
+	if req.UploadLoadLimit > 0 {
+		host.UploadLoadLimit.Store(req.UploadLoadLimit)
+	}
+	if req.HostLoad != nil {
+		host.StoreHostLoad(req.HostLoad)
+	}
+
+	for _, cachedTask := range req.CachedTasks {
+		task, ok := s.loadOrCreateCachedTask(cachedTask)
+		if !ok {
+			continue
+		}
+
+		s.registerCachedPeer(ctx, task, host, cachedTask)
+	}
+
+	host.Log.Infof("announce host with %d cached tasks", len(req.CachedTasks))
+	return nil
+}
+
+// LeaveHost makes the host and all its peers unschedulable
+func (s *Service) LeaveHost(ctx context.Context, req *rpcscheduler.LeaveHostRequest) error {
+	host, ok := s.resource.HostManager().Load(req.Uuid)
+	if !ok {
+		logger.Errorf("leave host: host %s is not exists", req.Uuid)
+		return dferrors.Newf(base.Code_SchedPeerNotFound, "host %s not found", req.Uuid)
+	}
+
+	host.Log.Info("leave host request")
+	var peers []*resource.Peer
+	blocklist := set.NewSafeSet()
+	host.Peers.Range(func(_, value interface{}) bool {
+		if peer, ok := value.(*resource.Peer); ok {
+			peers = append(peers, peer)
+			blocklist.Add(peer.ID)
+		}
+		return true
+	})
+	host.LeavePeers()
+
+	for _, peer := range peers {
+		// Reschedule a new parent to children of peer to exclude all peers of the leave host
+		peer.Children.Range(func(_, value interface{}) bool {
+			child, ok := value.(*resource.Peer)
+			if !ok {
+				return true
+			}
+
+			s.scheduler.ScheduleParent(ctx, child, blocklist)
+			return true
+		})
+
+		peer.DeleteParent()
+		s.resource.PeerManager().Delete(peer.ID)
+	}
+
+	s.resource.HostManager().Delete(host.ID)
+	return nil
+}
+
 // registerTask creates a new task or reuses a previous task
 func (s *Service) registerTask(ctx context.Context, req *rpcscheduler.PeerTaskRequest) (*resource.Task, error) {
 	task := resource.NewTask(idgen.TaskID(req.Url, req.UrlMeta), req.Url, s.config.Scheduler.BackSourceCount, req.UrlMeta)
@@ -383,7 +454,16 @@ func (s *Service) registerTask(ctx context.Context, req *rpcscheduler.PeerTaskRe
 
 // registerHost creates a new host or reuses a previous host
 func (s *Service) registerHost(ctx context.Context, req *rpcscheduler.PeerTaskRequest) *resource.Host {
-	rawHost := req.PeerHost
+	host := s.loadOrCreateHost(req.PeerHost)
+	if req.HostLoad != nil {
+		host.StoreHostLoad(req.HostLoad)
+	}
+
+	return host
+}
+
+// loadOrCreateHost returns the previous host or creates a new host
+func (s *Service) loadOrCreateHost(rawHost *rpcscheduler.PeerHost) *resource.Host {
 	host, ok := s.resource.HostManager().Load(rawHost.Uuid)
 	if !ok {
 		// Get scheduler cluster client config by manager
@@ -414,6 +494,70 @@ func (s *Service) registerPeer(ctx context.Context, req *rpcscheduler.PeerTaskRe
 	return peer
 }
 
+// registerCachedPeer creates a succeeded peer for the task cached in host,
+// so the host can be scheduled as a parent without downloading again
+// loadOrCreateCachedTask loads the task of cached task, the task is created with the url of cached task
+// when it does not exist, eg: scheduler restarted or the task has been gc
+func (s *Service) loadOrCreateCachedTask(cachedTask *rpcscheduler.CachedTask) (*resource.Task, bool) {
+	if task, ok := s.resource.TaskManager().Load(cachedTask.TaskId); ok {
+		return task, true
+	}
+
+	// Scheduler can not create the task without url
+	if cachedTask.Url == "" {
+		return nil, false
+	}
+
+	if taskID := idgen.TaskID(cachedTask.Url, cachedTask.UrlMeta); taskID != cachedTask.TaskId {
+		logger.Warnf("cached task %s does not match the task %s of url %s", cachedTask.TaskId, taskID, cachedTask.Url)
+		return nil, false
+	}
+
+	task, _ := s.resource.TaskManager().LoadOrStore(
+		resource.NewTask(cachedTask.TaskId, cachedTask.Url, s.config.Scheduler.BackSourceCount, cachedTask.UrlMeta))
+	return task, true
+}
+
+func (s *Service) registerCachedPeer(ctx context.Context, task *resource.Task, host *resource.Host, cachedTask *rpcscheduler.CachedTask) {
+	peer, loaded := s.resource.PeerManager().LoadOrStore(resource.NewPeer(cachedTask.PeerId, task, host))
+	if loaded {
+		// Keep the cached peer alive until the host stops announcing it
+		if peer.FSM.Is(resource.PeerStateSucceeded) {
+			peer.UpdateAt.Store(time.Now())
+		}
+		return
+	}
+
+	for _, event := range []string{resource.PeerEventRegisterNormal, resource.PeerEventDownload, resource.PeerEventDownloadSucceeded} {
+		if err := peer.FSM.Event(event); err != nil {
+			peer.Log.Errorf("peer fsm event failed: %v", err)
+			return
+		}
+	}
+
+	for i := int32(0); i < cachedTask.TotalPieceCount; i++ {
+		peer.Pieces.Set(uint(i))
+	}
+
+	// The task created by the cached task is not downloaded by cdn
+	if task.FSM.Is(resource.TaskStatePending) {
+		if err := task.FSM.Event(resource.TaskEventDownload); err != nil {
+			task.Log.Errorf("task fsm event failed: %v", err)
+			return
+		}
+	}
+
+	// The task is downloaded completely by the host
+	if task.FSM.Is(resource.TaskStateRunning) || task.FSM.Is(resource.TaskStateFailed) {
+		s.handleTaskSuccess(ctx, task, &rpcscheduler.PeerResult{
+			TotalPieceCount: cachedTask.TotalPieceCount,
+			ContentLength:   cachedTask.ContentLength,
+		})
+	}
+
+	peer.Log.Info("register cached peer")
+}
+
 // handleBeginOfPiece handles begin of piece
 func (s *Service) handleBeginOfPiece(ctx context.Context, peer *resource.Peer) {
 	switch peer.FSM.Current() {
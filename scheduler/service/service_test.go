@@ -1243,6 +1243,271 @@ func TestService_LeaveTask(t *testing.T) {
 	}
 }
 
+func TestService_AnnounceHost(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    *rpcscheduler.AnnounceHostRequest
+		mock   func(host *resource.Host, task *resource.Task, hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder)
+		expect func(t *testing.T, host *resource.Host, task *resource.Task, err error)
+	}{
+		{
+			name: "announce host with host load and upload load limit",
+			req: &rpcscheduler.AnnounceHostRequest{
+				PeerHost:        mockRawHost,
+				HostLoad:        &base.HostLoad{CpuRatio: 0.5},
+				UploadLoadLimit: 20,
+			},
+			mock: func(host *resource.Host, task *resource.Task, hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(host, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host, task *resource.Task, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(host.UploadLoadLimit.Load(), int32(20))
+				assert.Equal(host.HostLoadRatio(), float64(0.5))
+			},
+		},
+		{
+			name: "cached task does not exist",
+			req: &rpcscheduler.AnnounceHostRequest{
+				PeerHost: mockRawHost,
+				CachedTasks: []*rpcscheduler.CachedTask{
+					{TaskId: mockTaskID, PeerId: mockPeerID, ContentLength: 100, TotalPieceCount: 2},
+				},
+			},
+			mock: func(host *resource.Host, task *resource.Task, hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host, task *resource.Task, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(host.LenPeers(), 0)
+			},
+		},
+		{
+			name: "cached task does not exist and is created by url",
+			req: &rpcscheduler.AnnounceHostRequest{
+				PeerHost: mockRawHost,
+				CachedTasks: []*rpcscheduler.CachedTask{
+					{TaskId: mockTaskID, PeerId: mockPeerID, ContentLength: 100, TotalPieceCount: 2, Url: mockTaskURL, UrlMeta: mockTaskURLMeta},
+				},
+			},
+			mock: func(host *resource.Host, task *resource.Task, hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(nil, false).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.LoadOrStore(gomock.Any()).DoAndReturn(func(t *resource.Task) (*resource.Task, bool) {
+						// the mock task is the same as the created one
+						return task, false
+					}).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).DoAndReturn(func(peer *resource.Peer) (*resource.Peer, bool) {
+						return peer, false
+					}).Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host, task *resource.Task, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(task.FSM.Is(resource.TaskStateSucceeded))
+				assert.Equal(task.ContentLength.Load(), int64(100))
+				assert.Equal(task.TotalPieceCount.Load(), int32(2))
+			},
+		},
+		{
+			name: "cached task does not match url",
+			req: &rpcscheduler.AnnounceHostRequest{
+				PeerHost: mockRawHost,
+				CachedTasks: []*rpcscheduler.CachedTask{
+					{TaskId: mockTaskID, PeerId: mockPeerID, ContentLength: 100, TotalPieceCount: 2, Url: "http://example.com/bar", UrlMeta: mockTaskURLMeta},
+				},
+			},
+			mock: func(host *resource.Host, task *resource.Task, hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host, task *resource.Task, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(host.LenPeers(), 0)
+			},
+		},
+		{
+			name: "cached task exists and task state is TaskStateRunning",
+			req: &rpcscheduler.AnnounceHostRequest{
+				PeerHost: mockRawHost,
+				CachedTasks: []*rpcscheduler.CachedTask{
+					{TaskId: mockTaskID, PeerId: mockPeerID, ContentLength: 100, TotalPieceCount: 2},
+				},
+			},
+			mock: func(host *resource.Host, task *resource.Task, hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				task.FSM.SetState(resource.TaskStateRunning)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(task, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).DoAndReturn(func(peer *resource.Peer) (*resource.Peer, bool) {
+						return peer, false
+					}).Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host, task *resource.Task, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(task.FSM.Is(resource.TaskStateSucceeded))
+				assert.Equal(task.ContentLength.Load(), int64(100))
+				assert.Equal(task.TotalPieceCount.Load(), int32(2))
+			},
+		},
+		{
+			name: "cached peer already exists",
+			req: &rpcscheduler.AnnounceHostRequest{
+				PeerHost: mockRawHost,
+				CachedTasks: []*rpcscheduler.CachedTask{
+					{TaskId: mockTaskID, PeerId: mockPeerID, ContentLength: 100, TotalPieceCount: 2},
+				},
+			},
+			mock: func(host *resource.Host, task *resource.Task, hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer := resource.NewPeer(mockPeerID, task, host)
+				peer.FSM.SetState(resource.PeerStateSucceeded)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(task, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host, task *resource.Task, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(task.FSM.Is(resource.TaskStatePending))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			taskManager := resource.NewMockTaskManager(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			svc := New(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskBackToSourceLimit, mockTaskURLMeta)
+
+			tc.mock(mockHost, mockTask, hostManager, taskManager, peerManager, res.EXPECT(), hostManager.EXPECT(), taskManager.EXPECT(), peerManager.EXPECT())
+			tc.expect(t, mockHost, mockTask, svc.AnnounceHost(context.Background(), tc.req))
+		})
+	}
+}
+
+func TestService_LeaveHost(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(host *resource.Host, peer *resource.Peer, child *resource.Peer, hostManager resource.HostManager, peerManager resource.PeerManager, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder)
+		expect func(t *testing.T, peer *resource.Peer, err error)
+	}{
+		{
+			name: "host not found",
+			mock: func(host *resource.Host, peer *resource.Peer, child *resource.Peer, hostManager resource.HostManager, peerManager resource.PeerManager, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				dferr, ok := err.(*dferrors.DfError)
+				assert.True(ok)
+				assert.Equal(dferr.Code, base.Code_SchedPeerNotFound)
+			},
+		},
+		{
+			name: "host has no peers",
+			mock: func(host *resource.Host, peer *resource.Peer, child *resource.Peer, hostManager resource.HostManager, peerManager resource.PeerManager, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(host, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Delete(gomock.Eq(host.ID)).Return().Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "host has peers and the children of peers are rescheduled",
+			mock: func(host *resource.Host, peer *resource.Peer, child *resource.Peer, hostManager resource.HostManager, peerManager resource.PeerManager, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				host.StorePeer(peer)
+				peer.StoreChild(child)
+
+				blocklist := set.NewSafeSet()
+				blocklist.Add(peer.ID)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(host, true).Times(1),
+					ms.ScheduleParent(gomock.Any(), gomock.Eq(child), gomock.Eq(blocklist)).Return().Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Delete(gomock.Eq(peer.ID)).Return().Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Delete(gomock.Eq(host.ID)).Return().Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateLeave))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			svc := New(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskBackToSourceLimit, mockTaskURLMeta)
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			child := resource.NewPeer(idgen.PeerID("127.0.0.2"), mockTask, resource.NewHost(mockRawCDNHost))
+
+			tc.mock(mockHost, peer, child, hostManager, peerManager, scheduler.EXPECT(), res.EXPECT(), hostManager.EXPECT(), peerManager.EXPECT())
+			tc.expect(t, peer, svc.LeaveHost(context.Background(), &rpcscheduler.LeaveHostRequest{Uuid: mockRawHost.Uuid}))
+		})
+	}
+}
+
 func TestService_registerTask(t *testing.T) {
 	tests := []struct {
 		name string
@@ -1458,6 +1723,51 @@ func TestService_registerHost(t *testing.T) {
 				assert.Equal(host.UploadLoadLimit.Load(), int32(10))
 			},
 		},
+		{
+			name: "host already exists and reports host load",
+			req: &rpcscheduler.PeerTaskRequest{
+				Url:      mockTaskURL,
+				UrlMeta:  mockTaskURLMeta,
+				PeerHost: mockRawHost,
+				HostLoad: &base.HostLoad{CpuRatio: 0.5, MemRatio: 0.25, DiskRatio: 0.75},
+			},
+			mock: func(mockHost *resource.Host, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(mockHost, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host) {
+				assert := assert.New(t)
+				assert.Equal(host.ID, mockRawHost.Uuid)
+				assert.Equal(host.CPURatio.Load(), float64(0.5))
+				assert.Equal(host.MemRatio.Load(), float64(0.25))
+				assert.Equal(host.DiskRatio.Load(), float64(0.75))
+			},
+		},
+		{
+			name: "host does not exist and reports host load",
+			req: &rpcscheduler.PeerTaskRequest{
+				Url:      mockTaskURL,
+				UrlMeta:  mockTaskURLMeta,
+				PeerHost: mockRawHost,
+				HostLoad: &base.HostLoad{CpuRatio: 0.5},
+			},
+			mock: func(mockHost *resource.Host, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Uuid)).Return(nil, false).Times(1),
+					md.GetSchedulerClusterClientConfig().Return(types.SchedulerClusterClientConfig{}, false).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Store(gomock.Any()).Return().Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host) {
+				assert := assert.New(t)
+				assert.Equal(host.ID, mockRawHost.Uuid)
+				assert.Equal(host.HostLoadRatio(), float64(0.5))
+			},
+		},
 		{
 			name: "host does not exist and dynconfig get cluster client config failed",
 			req: &rpcscheduler.PeerTaskRequest{